@@ -0,0 +1,88 @@
+package htmplx
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithAccessLog logs every request while enabled, at info level with the handler's logger once it is served:
+// its method, path and route pattern, the status and number of bytes of the response, how long it took to serve,
+// and how long its templates took to parse.
+func (h *Handler[D]) WithAccessLog(enabled bool) *Handler[D] {
+	h.accessLog = enabled
+	return h
+}
+
+// requestStats are what is learned while serving a request, for the access log and metrics.
+type requestStats struct {
+	// pattern is the pattern of the route rendered, if any.
+	pattern string
+	// static is set when a static file is served.
+	static bool
+	// parse is the time taken to parse the templates.
+	parse time.Duration
+}
+
+type requestStatsContextKey struct{}
+
+// statsFromContext returns the stats of the request being served, or nil if they are not collected.
+func statsFromContext(ctx context.Context) *requestStats {
+	stats, _ := ctx.Value(requestStatsContextKey{}).(*requestStats)
+	return stats
+}
+
+// serveObserved serves the request with serve, then logs it and records its metrics, if enabled.
+func (h *Handler[D]) serveObserved(w http.ResponseWriter, r *http.Request, serve func(http.ResponseWriter, *http.Request)) {
+	start := time.Now()
+	stats := &requestStats{}
+	lw := &accessLogWriter{ResponseWriter: w}
+
+	serve(lw, r.WithContext(context.WithValue(r.Context(), requestStatsContextKey{}, stats)))
+
+	if lw.status == 0 {
+		lw.status = http.StatusOK
+	}
+	duration := time.Since(start)
+
+	h.metrics.observeRequest(stats.pattern, lw.status, stats.static, duration)
+	if !h.accessLog {
+		return
+	}
+
+	h.requestLog(r).With(
+		"method", r.Method,
+		"route", stats.pattern,
+		"status", lw.status,
+		"bytes", lw.bytes,
+		"duration", duration,
+		"parseDuration", stats.parse,
+	).Info("request")
+}
+
+// accessLogWriter records the status and size of a response.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
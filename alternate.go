@@ -0,0 +1,146 @@
+package htmplx
+
+import (
+	"html"
+	"html/template"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// printView is the printer-friendly view, available at every route without being declared
+// as an alternate, e.g. /invoice/42?view=print rendered with body.print.html.tmpl.
+// Declaring it as an alternate with "media": "print" advertises it with a <link> tag.
+const printView = "print"
+
+// alternate is a representation of a route other than the default, declared in a directory's _config.json.
+// Alternate views apply to the directory and those beneath it, while alternates with an href
+// only apply to the directory itself.
+//
+//	{
+//		"alternates": [
+//			{"view": "print", "media": "print"},
+//			{"view": "amp", "rel": "amphtml"},
+//			{"hreflang": "de", "href": "/de/ueber-uns"}
+//		]
+//	}
+//
+// An alternate with a view is served at the route with a ?view= query parameter,
+// rendered with the NAME.VIEW.html.tmpl templates along the path in place of NAME.html.tmpl,
+// e.g. body.amp.html.tmpl in place of body.html.tmpl.
+// An alternate with an href refers to another url entirely, e.g. a translation.
+type alternate struct {
+	View     string `json:"view"`
+	Href     string `json:"href"`
+	Rel      string `json:"rel"`
+	Hreflang string `json:"hreflang"`
+	Media    string `json:"media"`
+	Type     string `json:"type"`
+}
+
+func (a alternate) key() string {
+	switch {
+	case a.View != "":
+		return "view:" + a.View
+	case a.Hreflang != "":
+		return "hreflang:" + a.Hreflang
+	default:
+		return "href:" + a.Href
+	}
+}
+
+func (a alternate) href(route *Route, basePath string) string {
+	if a.View != "" {
+		return withBasePath(basePath, route.Path) + "?view=" + url.QueryEscape(a.View)
+	}
+	return withBasePath(basePath, a.Href)
+}
+
+// link renders the alternate as a <link> tag.
+func (a alternate) link(route *Route, basePath string) string {
+	rel := a.Rel
+	if rel == "" {
+		rel = "alternate"
+	}
+
+	var b strings.Builder
+	b.WriteString(`<link rel="` + html.EscapeString(rel) + `" href="` + html.EscapeString(a.href(route, basePath)) + `"`)
+	for _, attr := range [][2]string{
+		{"hreflang", a.Hreflang},
+		{"media", a.Media},
+		{"type", a.Type},
+	} {
+		if attr[1] != "" {
+			b.WriteString(` ` + attr[0] + `="` + html.EscapeString(attr[1]) + `"`)
+		}
+	}
+	b.WriteString(`>`)
+
+	return b.String()
+}
+
+// routeAlternates collects the alternates of the route, deeper directories
+// replacing alternate views of the same name declared in their parents.
+func (h requestHandler) routeAlternates(route *Route) ([]alternate, error) {
+	var alternates []alternate
+	index := make(map[string]int)
+
+	for i := 0; i <= len(route.Dirs); i++ {
+		cfg, err := h.readDirectoryConfig(strings.Join(route.Dirs[:i], "/"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range cfg.Alternates {
+			if a.View == "" && i < len(route.Dirs) {
+				continue
+			}
+			if j, ok := index[a.key()]; ok {
+				alternates[j] = a
+				continue
+			}
+			index[a.key()] = len(alternates)
+			alternates = append(alternates, a)
+		}
+	}
+
+	return alternates, nil
+}
+
+// alternateLinksFunc renders the <link> tags of the alternates of the route being rendered, and those of its
+// translations when the tree declares locales, other than those declared as alternates. Alternate views only
+// link to the default view, which links to the translations.
+func (h requestHandler) alternateLinksFunc() (template.HTML, error) {
+	alternates := h.alternates
+	if h.view == "" {
+		translations, err := h.localeAlternates()
+		if err != nil {
+			return "", err
+		}
+		for _, t := range translations {
+			if !slices.ContainsFunc(h.alternates, func(a alternate) bool { return a.key() == t.key() }) {
+				alternates = append(alternates, t)
+			}
+		}
+	}
+	return alternateLinks(h.route, alternates, h.view, h.basePath), nil
+}
+
+// alternateLinks renders the <link> tags of the route's alternates.
+// When rendering an alternate view, a canonical link to the default view is rendered in its place.
+func alternateLinks(route *Route, alternates []alternate, view, basePath string) template.HTML {
+	var links []string
+
+	if view != "" {
+		links = append(links, `<link rel="canonical" href="`+html.EscapeString(withBasePath(basePath, route.Path))+`">`)
+	}
+
+	for _, a := range alternates {
+		if view != "" && a.View == view {
+			continue
+		}
+		links = append(links, a.link(route, basePath))
+	}
+
+	return template.HTML(strings.Join(links, "\n"))
+}
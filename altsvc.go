@@ -0,0 +1,36 @@
+package htmplx
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AltSvc returns middleware setting an Alt-Svc header advertising an HTTP/3 endpoint on the given UDP port,
+// so browsers connected over HTTP/1.1 or HTTP/2 switch to HTTP/3 for later requests.
+// maxAge is how long browsers remember the endpoint, 24 hours if zero.
+// It only sets the header: htmplx does not serve HTTP/3 itself, so run the handler on an HTTP/3 server,
+// such as quic-go's http3.Server, listening on the port, alongside the HTTP/1.1 and HTTP/2 server:
+//
+//	h := htmplx.NewHandlerForDirectory[htmplx.RequestDataMap]("site").
+//		Use(htmplx.AltSvc(443, 0))
+//
+//	go http3.ListenAndServeQUIC(":443", "cert.pem", "key.pem", h)
+//	http.ListenAndServeTLS(":443", "cert.pem", "key.pem", h)
+func AltSvc(port int, maxAge time.Duration) func(http.Handler) http.Handler {
+	if maxAge <= 0 {
+		maxAge = 24 * time.Hour
+	}
+
+	value := `h3=":` + strconv.Itoa(port) + `"; ma=` + strconv.Itoa(int(maxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// requests already made over HTTP/3 need no advertisement.
+			if r.ProtoMajor < 3 {
+				w.Header().Set("Alt-Svc", value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
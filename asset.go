@@ -0,0 +1,182 @@
+package htmplx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// assetHashLen is the number of hex digits of the content hash in a fingerprinted asset path.
+	assetHashLen = 16
+	// immutableCacheControl is the Cache-Control of fingerprinted assets, whose content never changes.
+	immutableCacheControl = "public, max-age=31536000, immutable"
+)
+
+// assetFingerprints caches the content hashes of static files, rehashing a file when it changes.
+type assetFingerprints struct {
+	mu     sync.Mutex
+	hashes map[string]assetFingerprint
+}
+
+type assetFingerprint struct {
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+// hash returns the content hash of the file name, or fs.ErrNotExist if it is not a file.
+func (a *assetFingerprints) hash(fsys fs.FS, name string) (string, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory: %w", name, fs.ErrNotExist)
+	}
+
+	a.mu.Lock()
+	fp, ok := a.hashes[name]
+	a.mu.Unlock()
+
+	if ok && fp.modTime.Equal(info.ModTime()) && fp.size == info.Size() {
+		return fp.hash, nil
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", name, err)
+	}
+
+	fp = assetFingerprint{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		hash:    hex.EncodeToString(sum.Sum(nil))[:assetHashLen],
+	}
+
+	a.mu.Lock()
+	if a.hashes == nil {
+		a.hashes = make(map[string]assetFingerprint)
+	}
+	a.hashes[name] = fp
+	a.mu.Unlock()
+
+	return fp.hash, nil
+}
+
+// FingerprintAssets hashes every static file up front, rather than on first use by the asset template func.
+// Files are rehashed when they change either way.
+func (h *Handler[D]) FingerprintAssets() error {
+	rh := h.newRequestHandler(h.log)
+	return fs.WalkDir(h.fs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if path.Ext(name) == "" || rh.isHiddenFile(name) {
+			return nil
+		}
+
+		if _, err := h.assets.hash(h.fs, name); err != nil {
+			return fmt.Errorf("failed to fingerprint %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// asset returns the fingerprinted url of the static file at name, e.g. /css/app.3f2a9c1b5d7e4f60.css
+// for css/app.css, which is served with an immutable Cache-Control. The url changes with the content.
+// The minified file, e.g. css/app.min.css, is used when it exists, or the plain url in DevAssets mode.
+//
+//	<link rel="stylesheet" href="{{asset "css/app.css"}}">
+func (h requestHandler) asset(name string) (string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if path.Ext(name) == "" || h.isHiddenFile(name) {
+		return "", fmt.Errorf("asset %s: not a static file", name)
+	}
+
+	if h.assetMode == DevAssets {
+		if _, err := fs.Stat(h.fs, name); err != nil {
+			return "", fmt.Errorf("asset %s: not found", name)
+		}
+		return h.withBasePath("/" + name), nil
+	}
+
+	if minified := minifiedName(name); minified != name {
+		if _, err := fs.Stat(h.fs, minified); err == nil {
+			name = minified
+		}
+	}
+
+	hash, err := h.assets.hash(h.fs, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("asset %s: not found", name)
+		}
+		return "", fmt.Errorf("asset %s: %w", name, err)
+	}
+
+	return h.withBasePath("/" + fingerprintedName(name, hash)), nil
+}
+
+// fingerprintedName inserts hash before the extension of name.
+func fingerprintedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// resolveAsset returns the static file served at the fingerprinted filename,
+// if filename is not itself a file and the fingerprint matches the current content of the file.
+func (h requestHandler) resolveAsset(filename string) (original string, ok bool) {
+	ext := path.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	hash := strings.TrimPrefix(path.Ext(base), ".")
+	if len(hash) != assetHashLen || !isLowerHex(hash) {
+		return "", false
+	}
+
+	if _, err := fs.Stat(h.fs, filename); err == nil {
+		return "", false
+	}
+
+	original = strings.TrimSuffix(base, "."+hash) + ext
+
+	current, err := h.assets.hash(h.fs, original)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			h.log.With("error", err).
+				Warn("failed to hash asset " + original)
+		}
+		return "", false
+	}
+	if current != hash {
+		h.log.Debug("stale asset fingerprint: " + filename)
+		return "", false
+	}
+
+	return original, true
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
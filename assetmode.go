@@ -0,0 +1,39 @@
+package htmplx
+
+import (
+	"path"
+	"strings"
+)
+
+// AssetMode selects how static assets are referenced and served.
+type AssetMode int
+
+const (
+	// ProdAssets, the default, references minified assets by fingerprinted url and hides source maps.
+	ProdAssets AssetMode = iota
+	// DevAssets references unminified assets by their plain url and serves source maps.
+	DevAssets
+)
+
+// WithAssetMode sets how the asset template func references static files, and whether source maps are served.
+// In production, {{asset "js/app.js"}} references js/app.min.js, when it exists, by its fingerprinted url.
+// In development, it references js/app.js itself, so templates need not change between the two.
+func (h *Handler[D]) WithAssetMode(mode AssetMode) *Handler[D] {
+	h.assetMode = mode
+	return h
+}
+
+// isSourceMap reports whether filename is a source map, e.g. app.min.js.map.
+func isSourceMap(filename string) bool {
+	return path.Ext(filename) == ".map"
+}
+
+// minifiedName returns the name of the minified counterpart of name, e.g. js/app.min.js for js/app.js.
+func minifiedName(name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if path.Ext(base) == ".min" {
+		return name
+	}
+	return base + ".min" + ext
+}
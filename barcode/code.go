@@ -0,0 +1,178 @@
+// Package barcode encodes QR codes and Code 128 barcodes and renders them as inline SVG
+// or PNG data URIs for use in templates, e.g. on tickets, invoices, and device pairing pages.
+//
+// Add Funcs to a handler's template functions:
+//
+//	<img src="{{qrPNG .PairingURL}}" alt="Pairing code">
+//	{{barcodeSVG .Ticket.Number}}
+package barcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// Code is an encoded two dimensional (QR) or linear (Code 128) code, a grid of dark and light modules.
+type Code struct {
+	width, height int
+	dark          []bool
+
+	// quietZone is the number of light modules required around the code.
+	quietZone int
+	// linear codes are a single row of modules, drawn as bars.
+	linear bool
+}
+
+// linearBarHeight is the height, in modules, linear codes are drawn at.
+const linearBarHeight = 50
+
+func newCode(width, height int) *Code {
+	return &Code{
+		width:  width,
+		height: height,
+		dark:   make([]bool, width*height),
+	}
+}
+
+// Size is the number of modules across and down the code, not including its quiet zone.
+func (c *Code) Size() (width, height int) {
+	return c.width, c.height
+}
+
+// Dark reports whether the module at column x and row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.width || y >= c.height {
+		return false
+	}
+	return c.dark[y*c.width+x]
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.dark[y*c.width+x] = dark
+}
+
+// drawnSize is the size of the drawing, in modules, including the quiet zone.
+func (c *Code) drawnSize() (width, height int) {
+	height = c.height
+	if c.linear {
+		height = linearBarHeight
+	}
+	return c.width + 2*c.quietZone, height + 2*c.quietZone
+}
+
+// SVG renders the code as an svg element, scale pixels per module.
+func (c *Code) SVG(scale int) template.HTML {
+	if scale < 1 {
+		scale = 1
+	}
+	w, h := c.drawnSize()
+
+	var path strings.Builder
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			if c.linear {
+				fmt.Fprintf(&path, "M%d,%dh1v%dh-1z", x+c.quietZone, c.quietZone, linearBarHeight)
+			} else {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+c.quietZone, y+c.quietZone)
+			}
+		}
+	}
+
+	return template.HTML(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+			`<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="%s"/></svg>`,
+		w*scale, h*scale, w, h, path.String(),
+	))
+}
+
+// Image renders the code as a black and white image, scale pixels per module.
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	w, h := c.drawnSize()
+
+	img := image.NewPaletted(image.Rect(0, 0, w*scale, h*scale), color.Palette{color.White, color.Black})
+	for py := 0; py < h*scale; py++ {
+		y := py/scale - c.quietZone
+		if c.linear {
+			y = 0
+			if py/scale < c.quietZone || py/scale >= h-c.quietZone {
+				continue
+			}
+		}
+		for px := 0; px < w*scale; px++ {
+			if c.Dark(px/scale-c.quietZone, y) {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+
+	return img
+}
+
+// PNG encodes the code as a png image, scale pixels per module.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.Image(scale)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DataURI encodes the code as a png data uri, scale pixels per module, for an <img> src.
+func (c *Code) DataURI(scale int) (template.URL, error) {
+	b, err := c.PNG(scale)
+	if err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(b)), nil
+}
+
+// Funcs returns the template functions of this package:
+//
+//	qrSVG s          s encoded as a QR code, as an inline svg
+//	qrPNG s          s encoded as a QR code, as a png data uri
+//	barcodeSVG s     s encoded as a Code 128 barcode, as an inline svg
+//	barcodePNG s     s encoded as a Code 128 barcode, as a png data uri
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"qrSVG": func(s string) (template.HTML, error) {
+			c, err := QR(s)
+			if err != nil {
+				return "", err
+			}
+			return c.SVG(4), nil
+		},
+		"qrPNG": func(s string) (template.URL, error) {
+			c, err := QR(s)
+			if err != nil {
+				return "", err
+			}
+			return c.DataURI(4)
+		},
+		"barcodeSVG": func(s string) (template.HTML, error) {
+			c, err := Code128(s)
+			if err != nil {
+				return "", err
+			}
+			return c.SVG(2), nil
+		},
+		"barcodePNG": func(s string) (template.URL, error) {
+			c, err := Code128(s)
+			if err != nil {
+				return "", err
+			}
+			return c.DataURI(2)
+		},
+	}
+}
@@ -0,0 +1,60 @@
+package barcode
+
+import "fmt"
+
+// code128Patterns are the bar and space widths of each Code 128 symbol, starting with a bar.
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// Code128 encodes printable ASCII text as a Code 128 barcode, using code set B.
+func Code128(data string) (*Code, error) {
+	if data == "" {
+		return nil, errEmpty
+	}
+
+	symbols := []int{code128StartB}
+	checksum := code128StartB
+	for i, r := range data {
+		if r < 32 || r > 126 {
+			return nil, fmt.Errorf("barcode: %q cannot be encoded in Code 128 code set B", r)
+		}
+		v := int(r) - 32
+		symbols = append(symbols, v)
+		checksum += (i + 1) * v
+	}
+	symbols = append(symbols, checksum%103, code128Stop)
+
+	var modules []bool
+	for _, s := range symbols {
+		dark := true
+		for _, w := range code128Patterns[s] {
+			for n := 0; n < int(w-'0'); n++ {
+				modules = append(modules, dark)
+			}
+			dark = !dark
+		}
+	}
+
+	c := newCode(len(modules), 1)
+	c.quietZone = 10
+	c.linear = true
+	copy(c.dark, modules)
+
+	return c, nil
+}
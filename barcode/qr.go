@@ -0,0 +1,477 @@
+package barcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// QR encodes data as a QR code in byte mode, with the medium error correction level,
+// using the smallest version that fits.
+func QR(data string) (*Code, error) {
+	b := []byte(data)
+
+	version := 0
+	for v := 1; v <= 40; v++ {
+		if qrDataBits(v, len(b)) <= qrNumDataCodewords(v)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("barcode: %d bytes is too long for a QR code", len(b))
+	}
+
+	codewords := qrAddECCAndInterleave(qrDataCodewords(version, b), version)
+
+	q := newQR(version)
+	q.drawFunctionPatterns()
+	q.drawCodewords(codewords)
+
+	// pick the mask with the lowest penalty.
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			bestMask, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // undo
+	}
+	q.applyMask(bestMask)
+	q.drawFormatBits(bestMask)
+
+	return q.Code, nil
+}
+
+// qrECCCodewordsPerBlock and qrNumECCBlocks are indexed by version, for the medium error correction level.
+var (
+	qrECCCodewordsPerBlock = [41]int{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28}
+	qrNumECCBlocks         = [41]int{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49}
+)
+
+// qrFormatECLBits are the format bits of the medium error correction level.
+const qrFormatECLBits = 0
+
+// qrNumRawDataModules is the number of modules available for data and error correction codewords.
+func qrNumRawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		n -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+func qrNumDataCodewords(version int) int {
+	return qrNumRawDataModules(version)/8 - qrECCCodewordsPerBlock[version]*qrNumECCBlocks[version]
+}
+
+func qrCharCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+func qrDataBits(version, n int) int {
+	return 4 + qrCharCountBits(version) + 8*n
+}
+
+// qrDataCodewords encodes data in byte mode, padded to the version's capacity.
+func qrDataCodewords(version int, data []byte) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4) // byte mode
+	bits.append(len(data), qrCharCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacity := qrNumDataCodewords(version) * 8
+	bits.append(0, min(4, capacity-len(bits))) // terminator
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	return bits.bytes()
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (v>>i)&1 != 0)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+// qrAddECCAndInterleave splits data into blocks, appends each block's error correction codewords,
+// and interleaves the blocks.
+func qrAddECCAndInterleave(data []byte, version int) []byte {
+	numBlocks := qrNumECCBlocks[version]
+	blockECCLen := qrECCCodewordsPerBlock[version]
+	rawCodewords := qrNumRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := rsDivisor(blockECCLen)
+
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		datLen := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			datLen++
+		}
+
+		block := append([]byte(nil), data[k:k+datLen]...)
+		k += datLen
+
+		ecc := rsRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0) // placeholder, skipped when interleaving
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	out := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				out = append(out, block[i])
+			}
+		}
+	}
+
+	return out
+}
+
+// rsDivisor computes the Reed-Solomon generator polynomial of the given degree.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+type qr struct {
+	*Code
+	version    int
+	isFunction []bool
+}
+
+func newQR(version int) *qr {
+	size := version*4 + 17
+	c := newCode(size, size)
+	c.quietZone = 4
+	return &qr{
+		Code:       c,
+		version:    version,
+		isFunction: make([]bool, size*size),
+	}
+}
+
+func (q *qr) setFunction(x, y int, dark bool) {
+	q.set(x, y, dark)
+	q.isFunction[y*q.width+x] = true
+}
+
+func (q *qr) drawFunctionPatterns() {
+	size := q.width
+
+	// timing patterns
+	for i := 0; i < size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+
+	// finder patterns, with separators
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || y < 0 || x >= size || y >= size {
+					continue
+				}
+				dist := max(abs(dx), abs(dy))
+				q.setFunction(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+
+	// alignment patterns, except where they'd overlap finder patterns
+	positions := q.alignmentPositions()
+	last := len(positions) - 1
+	for i, py := range positions {
+		for j, px := range positions {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.setFunction(px+dx, py+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format bits, drawn once the mask is chosen
+	q.drawFormatBits(0)
+
+	if q.version >= 7 {
+		q.drawVersionBits()
+	}
+}
+
+func (q *qr) alignmentPositions() []int {
+	if q.version == 1 {
+		return nil
+	}
+
+	numAlign := q.version/7 + 2
+	step := (q.version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+
+	positions := make([]int, numAlign)
+	positions[0] = 6
+	for i, pos := numAlign-1, q.width-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+func (q *qr) drawFormatBits(mask int) {
+	size := q.width
+
+	data := qrFormatECLBits<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	// first copy, around the top left finder pattern
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+
+	// second copy, split between the other finder patterns
+	for i := 0; i < 8; i++ {
+		q.setFunction(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, size-15+i, bit(i))
+	}
+	q.setFunction(8, size-8, true) // always dark
+}
+
+func (q *qr) drawVersionBits() {
+	rem := q.version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := q.version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 != 0
+		a, b := q.width-11+i%3, i/3
+		q.setFunction(a, b, dark)
+		q.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the zigzag pattern, two columns at a time from the bottom right.
+func (q *qr) drawCodewords(data []byte) {
+	size := q.width
+	i := 0
+
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 { // upward
+					y = size - 1 - vert
+				}
+				if !q.isFunction[y*size+x] && i < len(data)*8 {
+					q.set(x, y, (data[i>>3]>>(7-i&7))&1 != 0)
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *qr) applyMask(mask int) {
+	size := q.width
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.isFunction[y*size+x] {
+				q.set(x, y, !q.Dark(x, y))
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code may be to scan, per the QR specification's mask evaluation rules.
+func (q *qr) penalty() int {
+	size := q.width
+	result := 0
+
+	line := make([]bool, size)
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < size; a++ {
+			for b := 0; b < size; b++ {
+				if vertical {
+					line[b] = q.Dark(a, b)
+				} else {
+					line[b] = q.Dark(b, a)
+				}
+			}
+			result += linePenalty(line)
+		}
+	}
+
+	// 2x2 blocks of the same color
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := q.Dark(x, y)
+			if c {
+				dark++
+			}
+			if x < size-1 && y < size-1 && c == q.Dark(x+1, y) && c == q.Dark(x, y+1) && c == q.Dark(x+1, y+1) {
+				result += 3
+			}
+		}
+	}
+
+	// balance of dark and light modules
+	total := size * size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * 10
+
+	return result
+}
+
+var (
+	finderLikeBefore = []bool{false, false, false, false, true, false, true, true, true, false, true}
+	finderLikeAfter  = []bool{true, false, true, true, true, false, true, false, false, false, false}
+)
+
+// linePenalty scores runs of five or more modules of the same color and finder-like patterns in a row or column.
+func linePenalty(line []bool) int {
+	result := 0
+
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			result += 3 + run - 5
+		}
+		run = 1
+	}
+
+	for i := 0; i+len(finderLikeBefore) <= len(line); i++ {
+		if matches(line[i:], finderLikeBefore) || matches(line[i:], finderLikeAfter) {
+			result += 40
+		}
+	}
+
+	return result
+}
+
+func matches(line, pattern []bool) bool {
+	for i, p := range pattern {
+		if line[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+var errEmpty = errors.New("barcode: empty content")
@@ -0,0 +1,37 @@
+package htmplx
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// WithBasePath sets the path the handler is mounted under, stripped from requests by the mux, e.g.
+//
+//	mux.Handle("/app/", http.StripPrefix("/app", h.WithBasePath("/app")))
+//
+// The urls the handler generates are under it: those of the url and asset template funcs, canonical, alternate
+// and poll urls, redirects, sitemaps, directory listings and PushURL.
+func (h *Handler[D]) WithBasePath(basePath string) *Handler[D] {
+	h.basePath = strings.TrimSuffix(path.Clean("/"+basePath), "/")
+	return h
+}
+
+// PushURL sets the HX-Push-Url response header, so htmx pushes the url of the route at routePath, e.g. /blog/42,
+// onto the browser history, under the base path.
+func (h *Handler[D]) PushURL(w http.ResponseWriter, routePath string) {
+	w.Header().Set("HX-Push-Url", withBasePath(h.basePath, routePath))
+}
+
+// withBasePath prefixes the root relative url p with basePath. Other urls are returned as is.
+func withBasePath(basePath, p string) string {
+	if basePath == "" || !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") {
+		return p
+	}
+	return basePath + p
+}
+
+// withBasePath prefixes the root relative url p with the base path.
+func (h requestHandler) withBasePath(p string) string {
+	return withBasePath(h.basePath, p)
+}
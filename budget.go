@@ -0,0 +1,94 @@
+package htmplx
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// Budget is a performance budget of the pages of a route. Pages exceeding it are still served,
+// but logged with a warning and counted by BudgetOverruns.
+type Budget struct {
+	// MaxSize is the largest size of a page, in bytes, or 0 for no limit.
+	MaxSize int64
+	// MaxRenderTime is the longest a page takes to render, its data callback included, or 0 for no limit.
+	MaxRenderTime time.Duration
+}
+
+// WithBudget sets the budget of the pages whose path matches pattern, as by path.Match, e.g. /blog/*.
+// Of several budgets matching a path, the first set applies. Static files have no budget.
+// It panics if pattern is malformed.
+func (h *Handler[D]) WithBudget(pattern string, budget Budget) *Handler[D] {
+	if _, err := path.Match(pattern, ""); err != nil {
+		panic(fmt.Sprintf("htmplx: WithBudget: %s: %v", pattern, err))
+	}
+	h.budgets.budgets = append(h.budgets.budgets, patternBudget{pattern: pattern, budget: budget})
+	return h
+}
+
+// BudgetOverrun counts the pages exceeding the budget set for a pattern.
+type BudgetOverrun struct {
+	Pattern string
+	// Size counts the pages larger than MaxSize.
+	Size int
+	// RenderTime counts the pages slower to render than MaxRenderTime.
+	RenderTime int
+}
+
+// BudgetOverruns counts the pages exceeding their budget since the handler was created, by pattern,
+// in the order the budgets were set, for exporting as metrics.
+func (h *Handler[D]) BudgetOverruns() []BudgetOverrun {
+	h.budgets.mu.Lock()
+	defer h.budgets.mu.Unlock()
+
+	overruns := make([]BudgetOverrun, len(h.budgets.budgets))
+	for i, b := range h.budgets.budgets {
+		overruns[i] = BudgetOverrun{Pattern: b.pattern, Size: b.size, RenderTime: b.renderTime}
+	}
+	return overruns
+}
+
+type budgets struct {
+	mu      sync.Mutex
+	budgets []patternBudget
+}
+
+type patternBudget struct {
+	pattern string
+	budget  Budget
+	// size and renderTime count the overruns.
+	size, renderTime int
+}
+
+// checkBudget warns if the page served for r exceeded the budget of its path, if any.
+func (h *Handler[D]) checkBudget(r *http.Request, l *slog.Logger, size int64, renderTime time.Duration) {
+	if len(h.budgets.budgets) == 0 {
+		return
+	}
+
+	h.budgets.mu.Lock()
+	defer h.budgets.mu.Unlock()
+
+	for i := range h.budgets.budgets {
+		b := &h.budgets.budgets[i]
+		if ok, _ := path.Match(b.pattern, r.URL.Path); !ok {
+			continue
+		}
+
+		l = l.With("budget", b.pattern)
+		if b.budget.MaxSize > 0 && size > b.budget.MaxSize {
+			b.size++
+			l.With("size", size, "maxSize", b.budget.MaxSize).
+				Warn("page exceeds its size budget")
+		}
+		if b.budget.MaxRenderTime > 0 && renderTime > b.budget.MaxRenderTime {
+			b.renderTime++
+			l.With("renderTime", renderTime, "maxRenderTime", b.budget.MaxRenderTime).
+				Warn("page exceeds its render time budget")
+		}
+		return
+	}
+}
@@ -0,0 +1,208 @@
+package htmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BuildStep is a step of an asset pipeline run outside of htmplx, e.g. Tailwind or esbuild,
+// which writes its outputs into the handler's directory.
+//
+//	htmplx.BuildStep{
+//		Name:    "tailwind",
+//		Command: []string{"npx", "tailwindcss", "-i", "src/app.css", "-o", "site/css/app.css", "--minify"},
+//		Sources: []string{"src", "site/*.html.tmpl"},
+//	}
+type BuildStep struct {
+	// Name identifies the step in logs and errors.
+	Name string
+	// Command is the program to run and its arguments.
+	Command []string
+	// Dir is the working directory of the command, the current directory if empty.
+	Dir string
+	// Sources are glob patterns, relative to Dir, of the files the step builds from.
+	// Directories include every file within them. WatchBuild reruns the step when they change.
+	Sources []string
+}
+
+// WithBuildSteps sets the asset pipeline run by Build and WatchBuild, in order.
+func (h *Handler[D]) WithBuildSteps(steps ...BuildStep) *Handler[D] {
+	h.buildSteps = steps
+	return h
+}
+
+// Build runs every build step, in order, then fingerprints the static files, including the outputs,
+// and warms up the routes set by WithWarmup. It is the production build, run before serving.
+func (h *Handler[D]) Build(ctx context.Context) error {
+	if err := h.build(ctx); err != nil {
+		return err
+	}
+	// routes are warmed up once built, as requests would render them.
+	return h.Warmup(ctx)
+}
+
+func (h *Handler[D]) build(ctx context.Context) error {
+	h.building.Lock()
+	defer h.building.Unlock()
+
+	if h.validate {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+	} else {
+		h.warnAmbiguousDirectories(h.tree.load())
+	}
+
+	for _, step := range h.buildSteps {
+		if err := h.runBuildStep(ctx, step); err != nil {
+			return err
+		}
+	}
+
+	if err := h.FingerprintAssets(); err != nil {
+		return fmt.Errorf("failed to fingerprint assets: %w", err)
+	}
+
+	return nil
+}
+
+// WatchBuild runs every build step, then reruns a step, and the steps after it, whenever its sources change,
+// checking every interval, until ctx is done. Requests wait while steps run, so pages are never rendered
+// against half built assets. Failed steps are logged, not returned, so a broken source can be fixed and rebuilt.
+// It is meant for development.
+func (h *Handler[D]) WatchBuild(ctx context.Context, interval time.Duration) error {
+	if len(h.buildSteps) == 0 {
+		return nil
+	}
+
+	sources := make([]map[string]time.Time, len(h.buildSteps))
+	first := 0
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for i, step := range h.buildSteps {
+			modTimes, err := step.sourceModTimes()
+			if err != nil {
+				h.log.With("step", step.Name, "error", err).
+					Error("failed to check build step sources")
+				continue
+			}
+			if first < 0 && !sameModTimes(sources[i], modTimes) {
+				first = i
+			}
+			sources[i] = modTimes
+		}
+
+		if first >= 0 {
+			h.building.Lock()
+			for _, step := range h.buildSteps[first:] {
+				if err := h.runBuildStep(ctx, step); err != nil {
+					if ctx.Err() == nil {
+						h.log.With("error", err).
+							Error("build step failed")
+					}
+					break
+				}
+			}
+			h.building.Unlock()
+			first = -1
+
+			// outputs may be sources themselves, and must not trigger another build.
+			for i, step := range h.buildSteps {
+				if modTimes, err := step.sourceModTimes(); err == nil {
+					sources[i] = modTimes
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *Handler[D]) runBuildStep(ctx context.Context, step BuildStep) error {
+	if len(step.Command) == 0 {
+		return fmt.Errorf("build step %s: no command", step.Name)
+	}
+
+	l := h.log.With("step", step.Name)
+	l.Debug("running build step")
+
+	start := time.Now()
+
+	cmd := exec.CommandContext(ctx, step.Command[0], step.Command[1:]...)
+	cmd.Dir = step.Dir
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("build step %s: %w: %s", step.Name, err, strings.TrimSpace(string(out)))
+	}
+
+	l.With("duration", time.Since(start)).
+		Info("build step finished")
+
+	return nil
+}
+
+// sourceModTimes returns the modification time of each file matched by the step's sources.
+func (step BuildStep) sourceModTimes() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+
+	for _, pattern := range step.Sources {
+		matches, err := filepath.Glob(filepath.Join(step.Dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid source pattern %s: %w", pattern, err)
+		}
+
+		for _, match := range matches {
+			err := filepath.WalkDir(match, func(name string, d fs.DirEntry, err error) error {
+				if err != nil {
+					if errors.Is(err, fs.ErrNotExist) {
+						return nil
+					}
+					return err
+				}
+				if d.IsDir() {
+					return nil
+				}
+
+				info, err := d.Info()
+				if err != nil {
+					if errors.Is(err, fs.ErrNotExist) {
+						return nil
+					}
+					return err
+				}
+				modTimes[name] = info.ModTime()
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read sources %s: %w", match, err)
+			}
+		}
+	}
+
+	return modTimes, nil
+}
+
+func sameModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, t := range a {
+		if u, ok := b[name]; !ok || !t.Equal(u) {
+			return false
+		}
+	}
+	return true
+}
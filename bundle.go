@@ -0,0 +1,150 @@
+package htmplx
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const (
+	// bundleTreeDir is the directory of a bundle holding the tree.
+	bundleTreeDir = "tree"
+	// bundleRoutesFile is the route index of a bundle.
+	bundleRoutesFile = "routes.json"
+)
+
+// ExportBundle writes the tree to w as a single artifact, a zip archive of its files and an index of its routes,
+// for runtimes without a file system, such as WASM and edge runtimes, which load it with LoadBundle.
+// Files are stored uncompressed, so that they are read without inflating them on every request.
+// Dotfiles and directories, such as .env and .git, are left out, other than .well-known, as are source maps
+// outside of DevAssets mode.
+func (h *Handler[D]) ExportBundle(ctx context.Context, w io.Writer) error {
+	l := h.log.With("bundle", true)
+	l.Info("exporting bundle")
+
+	if h.validate {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+	}
+
+	routes, err := h.Routes()
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	err = h.walkBundledFiles(ctx, func(name string, info fs.FileInfo, b []byte) error {
+		l.Debug("bundling " + name)
+		return writeBundleFile(zw, path.Join(bundleTreeDir, name), info, b)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to bundle files: %w", err)
+	}
+
+	index, err := json.Marshal(routes)
+	if err != nil {
+		return fmt.Errorf("failed to bundle routes: %w", err)
+	}
+	if err := writeBundleFile(zw, bundleRoutesFile, nil, index); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// walkBundledFiles calls fn with every file of the tree served from a bundle, leaving out dotfiles and directories,
+// other than .well-known, and source maps outside of DevAssets mode.
+func (h *Handler[D]) walkBundledFiles(ctx context.Context, fn func(name string, info fs.FileInfo, b []byte) error) error {
+	fsys := h.tree.load()
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(d.Name(), ".") && d.Name() != ".well-known" {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || isSourceMap(name) && h.assetMode != DevAssets {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		return fn(name, info, b)
+	})
+}
+
+func writeBundleFile(zw *zip.Writer, name string, info fs.FileInfo, b []byte) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Store}
+	if info != nil {
+		header.Modified = info.ModTime()
+	}
+
+	f, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to bundle %s: %w", name, err)
+	}
+	if _, err := f.Write(b); err != nil {
+		return fmt.Errorf("failed to bundle %s: %w", name, err)
+	}
+	return nil
+}
+
+// Bundle is a tree exported by ExportBundle.
+type Bundle struct {
+	// FS is the tree, to serve with NewHandler.
+	FS fs.FS
+	// Routes is the index of the tree's routes, e.g. to configure which paths an edge platform routes to the handler.
+	Routes []RouteInfo
+}
+
+// LoadBundle loads a bundle written by ExportBundle, such as one embedded in a WASM binary, to serve from memory:
+//
+//	//go:embed site.zip
+//	var site []byte
+//
+//	bundle, err := htmplx.LoadBundle(site)
+//	h := htmplx.NewHandler[htmplx.RequestDataMap](bundle.FS)
+func LoadBundle(b []byte) (*Bundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bundle: %w", err)
+	}
+
+	index, err := fs.ReadFile(zr, bundleRoutesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bundle: %w", err)
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(index, &bundle.Routes); err != nil {
+		return nil, fmt.Errorf("failed to load bundle routes: %w", err)
+	}
+
+	if bundle.FS, err = fs.Sub(zr, bundleTreeDir); err != nil {
+		return nil, fmt.Errorf("failed to load bundle: %w", err)
+	}
+
+	return &bundle, nil
+}
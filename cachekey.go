@@ -0,0 +1,82 @@
+package htmplx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CacheKeyFunc derives what a rendered page varies by beyond its url, for caching personalized pages,
+// e.g. the user's role for role based navigation, or their locale.
+// It must never return anything unique to a user, such as their id, or cached pages would leak between users,
+// and the cache would be of no use.
+type CacheKeyFunc func(r *http.Request) string
+
+// WithCacheKey sets what rendered pages vary by beyond their url, when cached.
+func (h *Handler[D]) WithCacheKey(key CacheKeyFunc) *Handler[D] {
+	h.cacheKey = key
+	return h
+}
+
+// CacheKey returns the key of the page rendered for the request, for caching it:
+// its url, with its host if hosts have trees of their own, what WithCacheKey derives from the request,
+// and its timezone, if WithTimezoneResolver is set.
+// Requests with the same key render the same page, but for its request id, see WithRequestIDs.
+func (h *Handler[D]) CacheKey(r *http.Request) string {
+	key := r.URL.Path
+	if len(h.hosts) > 0 {
+		key = requestHost(r) + key
+	}
+	if r.URL.RawQuery != "" {
+		key += "?" + r.URL.RawQuery
+	}
+	if h.cacheKey != nil {
+		key += "\x00" + h.cacheKey(r)
+	}
+	if h.timezone != nil {
+		key += "\x00" + h.timezoneKey(r)
+	}
+	return key
+}
+
+// timezoneKey is the name of the request's timezone, for the keys of the pages and fragments rendered in it.
+func (h *Handler[D]) timezoneKey(r *http.Request) string {
+	if loc := h.resolveTimezone(r); loc != nil {
+		return loc.String()
+	}
+	return ""
+}
+
+// CacheKeyHeader varies the cache key by the values of the request headers, e.g. Accept-Language.
+func CacheKeyHeader(names ...string) CacheKeyFunc {
+	return func(r *http.Request) string {
+		values := make([]string, len(names))
+		for i, name := range names {
+			values[i] = strings.Join(r.Header.Values(name), ",")
+		}
+		return strings.Join(values, "\x00")
+	}
+}
+
+// CacheKeyCookie varies the cache key by the values of the request cookies, e.g. a role or theme cookie.
+func CacheKeyCookie(names ...string) CacheKeyFunc {
+	return func(r *http.Request) string {
+		values := make([]string, len(names))
+		for i, name := range names {
+			if c, err := r.Cookie(name); err == nil {
+				values[i] = c.Value
+			}
+		}
+		return strings.Join(values, "\x00")
+	}
+}
+
+// CacheKeys varies the cache key by every one of keys.
+func CacheKeys(keys ...CacheKeyFunc) CacheKeyFunc {
+	return func(r *http.Request) string {
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = key(r)
+		}
+		return strings.Join(values, "\x00")
+	}
+}
@@ -0,0 +1,174 @@
+package htmplx
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// CanonicalMode is how pages declare their canonical url, so that search engines index one url per page.
+type CanonicalMode int
+
+const (
+	// CanonicalNone declares no canonical url, the default.
+	CanonicalNone CanonicalMode = iota
+	// CanonicalLink adds a <link rel="canonical"> to every page, the route's path without a query string.
+	CanonicalLink
+	// CanonicalRedirect also permanently redirects requests for other forms of the path,
+	// e.g. /blog/42/ and /blog//42 to /blog/42, or /blog/42/ under AddTrailingSlash.
+	CanonicalRedirect
+)
+
+// WithCanonicalRoutes sets how pages declare their canonical url.
+// Use DuplicateRoutes to find urls that more than one directory could serve.
+func (h *Handler[D]) WithCanonicalRoutes(mode CanonicalMode) *Handler[D] {
+	h.canonical = mode
+	return h
+}
+
+// WithCaseInsensitivePaths matches path segments to directories regardless of case while enabled,
+// so /About resolves to the about directory, as marketing links and typed urls often differ in case.
+// Requests are permanently redirected to the path with the directories' case, e.g. /about.
+// A directory named exactly as the segment takes precedence, and static files still match by exact name.
+func (h *Handler[D]) WithCaseInsensitivePaths(enabled bool) *Handler[D] {
+	h.caseInsensitive = enabled
+	return h
+}
+
+// redirectToMatchedCase redirects the request to the path of its route in the case of its directories,
+// if it differs from the request's path, when paths are matched regardless of case.
+func (h *Handler[D]) redirectToMatchedCase(w http.ResponseWriter, r *http.Request) bool {
+	if !h.caseInsensitive {
+		return false
+	}
+	route, ok := RouteFromContext(r.Context())
+	if !ok {
+		return false
+	}
+
+	matched := route.matchedPath()
+	if strings.HasSuffix(r.URL.Path, "/") && matched != "/" {
+		matched += "/"
+	}
+	if matched == r.URL.Path || !strings.EqualFold(matched, r.URL.Path) {
+		return false
+	}
+
+	u := *r.URL
+	u.Path = withBasePath(h.basePath, matched)
+	u.RawPath = ""
+	http.Redirect(w, r, u.RequestURI(), http.StatusMovedPermanently)
+
+	return true
+}
+
+// canonicalPath is the preferred form of a url path: cleaned, and without a trailing slash.
+func canonicalPath(urlPath string) string {
+	return path.Clean("/" + urlPath)
+}
+
+// canonicalLink renders the <link rel="canonical"> of the page, if enabled.
+func (h requestHandler) canonicalLink() template.HTML {
+	if h.canonical == CanonicalNone || h.route == nil {
+		return ""
+	}
+	return template.HTML(`<link rel="canonical" href="` + html.EscapeString(h.withBasePath(h.slashes.canonical(h.route.Path))) + `">`)
+}
+
+// canonical is the canonical form of a url path under the policy, with a trailing slash only if added by it.
+func (p SlashPolicy) canonical(urlPath string) string {
+	if p == AddTrailingSlash {
+		return p.apply(urlPath)
+	}
+	return canonicalPath(urlPath)
+}
+
+// redirectToCanonical redirects the request to the canonical form of its path, if enabled and not already.
+func (h *Handler[D]) redirectToCanonical(w http.ResponseWriter, r *http.Request) bool {
+	if h.canonical != CanonicalRedirect {
+		return false
+	}
+
+	canonical := h.slashes.canonical(r.URL.Path)
+	if canonical == r.URL.Path {
+		return false
+	}
+
+	u := *r.URL
+	u.Path = withBasePath(h.basePath, canonical)
+	u.RawPath = ""
+	http.Redirect(w, r, u.RequestURI(), http.StatusMovedPermanently)
+
+	return true
+}
+
+// DuplicateRoute is a directory that shadows a sibling regex directory also matching its name,
+// e.g. blog/new and blog/{[a-z]+}. The directory takes precedence, so the regex directory never serves /blog/new.
+type DuplicateRoute struct {
+	// Dir is the path of the directory, e.g. /blog/new.
+	Dir string
+	// Regex is the path of the regex directory it shadows, e.g. /blog/{[a-z]+}.
+	Regex string
+}
+
+// DuplicateRoutes lists the directories shadowing a regex directory, which is usually unintended:
+// either the regex should exclude the name, or the directory should not exist.
+func (h *Handler[D]) DuplicateRoutes() ([]DuplicateRoute, error) {
+	var duplicates []DuplicateRoute
+
+	err := fs.WalkDir(h.fs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if name != "." && name == d.Name() && isReservedDir(name) {
+			return fs.SkipDir
+		}
+
+		entries, err := fs.ReadDir(h.fs, name)
+		if err != nil {
+			return err
+		}
+
+		var regexDirs []string
+		var regexps []*regexp.Regexp
+		for _, e := range entries {
+			if e.IsDir() && isRegexPathPart(e.Name()) {
+				re, err := compileRegexPathPart(e.Name())
+				if err != nil {
+					return fmt.Errorf("invalid regex directory name %s: %w", e.Name(), err)
+				}
+				regexDirs = append(regexDirs, e.Name())
+				regexps = append(regexps, re)
+			}
+		}
+
+		for _, e := range entries {
+			if !e.IsDir() || isRegexPathPart(e.Name()) || name == "." && isReservedDir(e.Name()) {
+				continue
+			}
+			for i, re := range regexps {
+				if re.MatchString(e.Name()) {
+					duplicates = append(duplicates, DuplicateRoute{
+						Dir:   canonicalPath(path.Join(name, e.Name())),
+						Regex: canonicalPath(path.Join(name, regexDirs[i])),
+					})
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate routes: %w", err)
+	}
+
+	return duplicates, nil
+}
@@ -0,0 +1,219 @@
+package htmplx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// maxCaptureBodyLen is the most of a response body kept in a capture.
+const maxCaptureBodyLen = 1 << 20
+
+// redactedHeaders are request headers never captured, since they carry credentials.
+var redactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// Capture is a recorded page request with what is needed to render it again, exactly as it was:
+// the route it resolved to, the data the data callback returned, and the time it was rendered at.
+type Capture struct {
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	// Pattern is the directory path the request resolved to.
+	Pattern string `json:"pattern,omitempty"`
+	// Data is the JSON encoding of the data returned by the data callback.
+	Data       json.RawMessage `json:"data,omitempty"`
+	DataStatus int             `json:"dataStatus,omitempty"`
+	DataError  string          `json:"dataError,omitempty"`
+	// Status and Body are the response, the body truncated to 1MiB.
+	Status int    `json:"status"`
+	Body   string `json:"body,omitempty"`
+}
+
+// WithCapture records the page requests for which sample returns true, passing each capture to save
+// once the response is written. Credentials in request headers are not recorded.
+// A capture is replayed with Replay, rendering the page with the recorded data in place of the data callback.
+//
+//	h.WithCapture(htmplx.SampleRate(0.001), htmplx.CaptureToDir("/var/lib/site/captures"))
+func (h *Handler[D]) WithCapture(sample func(*http.Request) bool, save func(Capture) error) *Handler[D] {
+	h.captureSample = sample
+	h.captureSave = save
+	return h
+}
+
+// SampleRate samples about rate of requests, from 0 to 1.
+func SampleRate(rate float64) func(*http.Request) bool {
+	return func(*http.Request) bool {
+		return rand.Float64() < rate
+	}
+}
+
+// CaptureToDir saves captures as JSON files in dir.
+func CaptureToDir(dir string) func(Capture) error {
+	var n atomic.Int64
+
+	return func(c Capture) error {
+		b, err := json.MarshalIndent(c, "", "\t")
+		if err != nil {
+			return fmt.Errorf("failed to encode capture: %w", err)
+		}
+
+		name := "capture-" + c.Time.UTC().Format("20060102T150405.000000000") + "-" + strconv.FormatInt(n.Add(1), 10) + ".json"
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0o644); err != nil {
+			return fmt.Errorf("failed to save capture: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// ReadCapture reads a capture saved by CaptureToDir.
+func ReadCapture(filename string) (Capture, error) {
+	var c Capture
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("invalid capture %s: %w", filename, err)
+	}
+
+	return c, nil
+}
+
+// Replay renders the captured request again, with the captured data and at the captured time,
+// so that a page rendered in production can be reproduced against the local templates.
+func (h *Handler[D]) Replay(w http.ResponseWriter, c Capture) error {
+	ctx := contextWithCapture(context.Background(), &captureContext{capture: &c, replay: true})
+
+	r, err := http.NewRequestWithContext(ctx, c.Method, c.URL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid capture: %w", err)
+	}
+	r.Header = c.Header.Clone()
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+
+	h.ServeHTTP(w, r)
+
+	return nil
+}
+
+type captureContext struct {
+	capture *Capture
+	// replay is set when the capture is being replayed rather than recorded.
+	replay bool
+}
+
+type captureContextKey struct{}
+
+func contextWithCapture(ctx context.Context, c *captureContext) context.Context {
+	return context.WithValue(ctx, captureContextKey{}, c)
+}
+
+func captureFromContext(ctx context.Context) (*captureContext, bool) {
+	c, ok := ctx.Value(captureContextKey{}).(*captureContext)
+	return c, ok
+}
+
+// startCapture starts recording the request, if it is sampled, returning the request and response writer to use,
+// and a function to call once the response is written.
+func (h *Handler[D]) startCapture(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	if h.captureSample == nil || !h.captureSample(r) {
+		return w, r, func() {}
+	}
+	if c, ok := captureFromContext(r.Context()); ok && c.replay {
+		return w, r, func() {}
+	}
+
+	header := r.Header.Clone()
+	for _, name := range redactedHeaders {
+		header.Del(name)
+	}
+
+	c := &Capture{
+		Time:   h.now(),
+		Method: r.Method,
+		URL:    r.URL.String(),
+		Header: header,
+	}
+	cw := &captureWriter{ResponseWriter: w}
+
+	return cw, r.WithContext(contextWithCapture(r.Context(), &captureContext{capture: c})), func() {
+		c.Status = cw.status
+		if c.Status == 0 {
+			c.Status = http.StatusOK
+		}
+		c.Body = cw.body.String()
+
+		go func() {
+			if err := h.captureSave(*c); err != nil {
+				h.requestLog(r).With("error", err).
+					Error("failed to save capture")
+			}
+		}()
+	}
+}
+
+// capturedData decodes the data of a capture being replayed.
+func capturedData[D RequestData](c *Capture) (data D, status int, err error) {
+	if len(c.Data) > 0 {
+		if err := json.Unmarshal(c.Data, &data); err != nil {
+			return data, http.StatusInternalServerError, fmt.Errorf("failed to decode captured data: %w", err)
+		}
+	}
+	if c.DataError != "" {
+		err = fmt.Errorf("captured error: %s", c.DataError)
+	}
+
+	return data, c.DataStatus, err
+}
+
+// recordData records the data loaded for a captured request.
+func recordData[D RequestData](c *Capture, data D, status int, err error) {
+	if b, err := json.Marshal(data); err == nil {
+		c.Data = b
+	}
+	c.DataStatus = status
+	if err != nil {
+		c.DataError = err.Error()
+	}
+}
+
+// captureWriter records the status and body written to a response.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if room := maxCaptureBodyLen - w.body.Len(); room > 0 {
+		w.body.Write(p[:min(len(p), room)])
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,60 @@
+package htmplx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// sinceParam is the query parameter of a change token, e.g. /stats?since=3f2a9c1b5d7e4f60.
+const sinceParam = "since"
+
+// WithChangeTokens lets polled routes skip rendering when their data has not changed.
+// A page's change token is a hash of its data. A request with ?since= set to the current token
+// responds 204 No Content, which htmx ignores, keeping the element as is.
+// The pollURL template func links the page with its current token, for a fragment polling itself:
+//
+//	<div hx-get="{{pollURL}}" hx-trigger="every 2s" hx-swap="outerHTML">...</div>
+//
+// Only the data is hashed, so a change of templates alone is not picked up by pages already polling.
+func (h *Handler[D]) WithChangeTokens(enabled bool) *Handler[D] {
+	h.changeTokens = enabled
+	return h
+}
+
+// dataChangeToken hashes the data, or returns "" if it cannot be encoded.
+func dataChangeToken(data any) string {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:assetHashLen]
+}
+
+// unchangedSince reports whether the request's ?since= token is the current one.
+func unchangedSince(r *http.Request, token string) bool {
+	return token != "" && r.URL.Query().Get(sinceParam) == token
+}
+
+// pollURL returns the url of the page with its change token.
+func (h requestHandler) pollURL() string {
+	if h.route == nil {
+		return ""
+	}
+
+	query := url.Values{}
+	if h.view != "" {
+		query.Set("view", h.view)
+	}
+	if *h.changeToken != "" {
+		query.Set(sinceParam, *h.changeToken)
+	}
+
+	if len(query) == 0 {
+		return h.withBasePath(h.route.Path)
+	}
+	return h.withBasePath(h.route.Path) + "?" + query.Encode()
+}
@@ -0,0 +1,76 @@
+package htmplx
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Client is what WithClassifier classifies the client of a request as.
+type Client struct {
+	// Bot is set for automated clients, such as crawlers, link previewers and uptime monitors.
+	Bot bool
+	// Name is the name of the client, such as Googlebot, if known.
+	Name string
+}
+
+// Classifier classifies the client of a request, by its user agent or by asking a bot detection service.
+// It is called once per request, so should cache what it looks up.
+type Classifier func(r *http.Request) Client
+
+// WithClassifier classifies the client of every request, such as with UserAgentClassifier, so that the data
+// callback can treat bots differently, e.g. skipping personalization for crawlers, with ClientFromContext.
+// Pages cached by WithResponseCache are cached apart for bots.
+func (h *Handler[D]) WithClassifier(classifier Classifier) *Handler[D] {
+	h.classifier = classifier
+	return h
+}
+
+// DefaultBotUserAgents are the user agent substrings of common bots, the most specific first.
+var DefaultBotUserAgents = []string{
+	"Googlebot", "bingbot", "DuckDuckBot", "Baiduspider", "YandexBot", "Applebot",
+	"facebookexternalhit", "Twitterbot", "LinkedInBot", "Slackbot", "Discordbot",
+	"bot", "crawler", "spider",
+}
+
+// UserAgentClassifier classifies clients whose User-Agent contains one of names, ignoring case, as bots,
+// named after the first name matched. DefaultBotUserAgents are used if no names are given.
+func UserAgentClassifier(names ...string) Classifier {
+	if len(names) == 0 {
+		names = DefaultBotUserAgents
+	}
+
+	lower := make([]string, len(names))
+	for i, name := range names {
+		lower[i] = strings.ToLower(name)
+	}
+
+	return func(r *http.Request) Client {
+		ua := strings.ToLower(r.UserAgent())
+		for i, name := range lower {
+			if strings.Contains(ua, name) {
+				return Client{Bot: true, Name: names[i]}
+			}
+		}
+		return Client{}
+	}
+}
+
+type clientContextKey struct{}
+
+// ClientFromContext returns the client the request was classified as by WithClassifier, if enabled.
+func ClientFromContext(ctx context.Context) (Client, bool) {
+	c, ok := ctx.Value(clientContextKey{}).(Client)
+	return c, ok
+}
+
+// classify returns the request with its client in its context, if a classifier is set and it has none yet.
+func (h *Handler[D]) classify(r *http.Request) *http.Request {
+	if h.classifier == nil {
+		return r
+	}
+	if _, ok := ClientFromContext(r.Context()); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), clientContextKey{}, h.classifier(r)))
+}
@@ -0,0 +1,319 @@
+// Command htmplx serves or exports a directory tree of htmplx templates, without writing any Go.
+//
+//	htmplx serve ./public --addr :8080
+//	htmplx build ./public -o dist
+//	htmplx replay ./public capture.json
+//	htmplx validate ./public
+//	htmplx routes ./public --format openapi
+//	htmplx lint ./public
+//	htmplx bundle ./public -o site.zip
+//	htmplx generate ./public -o site_gen.go
+//
+// Templates have the default funcs, and .md files are rendered as Markdown.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/angelbeltran/htmplx"
+	"github.com/angelbeltran/htmplx/markdown"
+)
+
+const usage = `usage:
+  htmplx serve DIR [--addr :8080] [--dev] [--cert cert.pem --key key.pem]
+  htmplx build DIR [-o dist] [ROUTE...]
+  htmplx replay DIR CAPTURE
+  htmplx validate DIR
+  htmplx routes DIR [--format json|openapi] [--title TITLE]
+  htmplx lint DIR [ROUTE...]
+  htmplx bundle DIR [-o site.zip]
+  htmplx generate DIR [-o site_gen.go] [-pkg main] [-var site]
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "serve":
+		err = serve(ctx, args)
+	case "build":
+		err = build(ctx, args)
+	case "replay":
+		err = replay(args)
+	case "validate":
+		err = validate(args)
+	case "routes":
+		err = routes(args)
+	case "lint":
+		err = lint(args)
+	case "bundle":
+		err = bundle(ctx, args)
+	case "generate":
+		err = generate(ctx, args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s", cmd, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "htmplx:", err)
+		os.Exit(1)
+	}
+}
+
+func newHandler(dir string) *htmplx.Handler[htmplx.RequestDataMap] {
+	return htmplx.NewHandlerForDirectory[htmplx.RequestDataMap](dir).
+		WithData(func(*http.Request) htmplx.RequestDataMap { return htmplx.RequestDataMap{} }).
+		WithDefaultFuncs().
+		WithMarkdown(markdown.Renderer{HeadingIDs: true}).
+		WithValidation(true)
+}
+
+// parseArgs parses the flags of a subcommand, which may follow its positional arguments.
+func parseArgs(fset *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fset.Parse(args); err != nil {
+			return nil, err
+		}
+		if fset.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fset.Arg(0))
+		args = fset.Args()[1:]
+	}
+}
+
+func serve(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fset.String("addr", ":8080", "address to listen on: host:port, unix:/path or systemd")
+	dev := fset.Bool("dev", false, "serve development assets, unminified and with source maps, and reload pages on change")
+	cert := fset.String("cert", "", "PEM file of the TLS certificate to serve https with")
+	key := fset.String("key", "", "PEM file of the TLS certificate's key")
+
+	dirs, err := parseArgs(fset, args)
+	if err != nil {
+		return err
+	}
+	if len(dirs) != 1 {
+		return errors.New("serve takes a single directory")
+	}
+
+	h := newHandler(dirs[0])
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	if *dev {
+		h.WithAssetMode(htmplx.DevAssets).
+			WithLiveReload(true)
+	} else if err := h.FingerprintAssets(); err != nil {
+		return err
+	}
+
+	var opts []htmplx.ServeOption
+	if *cert != "" || *key != "" {
+		opts = append(opts, htmplx.ServeTLS(*cert, *key))
+	}
+
+	fmt.Fprintf(os.Stderr, "serving %s on %s\n", dirs[0], *addr)
+	return htmplx.Serve(ctx, *addr, h, opts...)
+}
+
+func build(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("build", flag.ExitOnError)
+	out := fset.String("o", "dist", "directory to export to")
+
+	positional, err := parseArgs(fset, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 {
+		return errors.New("build takes a directory, and optionally the routes to export")
+	}
+
+	h := newHandler(positional[0])
+	if err := h.FingerprintAssets(); err != nil {
+		return err
+	}
+
+	return h.Export(ctx, *out, positional[1:])
+}
+
+// bundle writes the tree to a single file, for runtimes without a file system.
+func bundle(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("bundle", flag.ExitOnError)
+	out := fset.String("o", "site.zip", "file to write the bundle to")
+
+	positional, err := parseArgs(fset, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("bundle takes a directory")
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := newHandler(positional[0]).ExportBundle(ctx, f); err != nil {
+		f.Close()
+		os.Remove(*out)
+		return err
+	}
+	return f.Close()
+}
+
+// generate writes the tree precompiled to Go source, see htmplx.Handler.GenerateGo.
+func generate(ctx context.Context, args []string) error {
+	fset := flag.NewFlagSet("generate", flag.ExitOnError)
+	out := fset.String("o", "site_gen.go", "file to write the source to")
+	pkg := fset.String("pkg", "main", "package of the source")
+	name := fset.String("var", "site", "variable the tree is declared as")
+
+	positional, err := parseArgs(fset, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("generate takes a directory")
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := newHandler(positional[0]).GenerateGo(ctx, f, *pkg, *name); err != nil {
+		f.Close()
+		os.Remove(*out)
+		return err
+	}
+	return f.Close()
+}
+
+func replay(args []string) error {
+	if len(args) != 2 {
+		return errors.New("replay takes a directory and a capture file")
+	}
+
+	c, err := htmplx.ReadCapture(args[1])
+	if err != nil {
+		return err
+	}
+
+	w := &stdoutResponseWriter{header: make(http.Header), out: os.Stdout}
+	if err := newHandler(args[0]).Replay(w, c); err != nil {
+		return err
+	}
+	if w.status != 0 && w.status != c.Status {
+		fmt.Fprintf(os.Stderr, "status %d, captured %d\n", w.status, c.Status)
+	}
+
+	return nil
+}
+
+// validate reports invalid templates, and warns of directories shadowing regex directories.
+func validate(args []string) error {
+	if len(args) != 1 {
+		return errors.New("validate takes a single directory")
+	}
+
+	h := newHandler(args[0])
+
+	duplicates, err := h.DuplicateRoutes()
+	if err != nil {
+		return err
+	}
+	for _, d := range duplicates {
+		fmt.Fprintf(os.Stderr, "warning: %s shadows %s\n", d.Dir, d.Regex)
+	}
+
+	return h.Validate()
+}
+
+// routes writes the routes of the tree, for gateways, uptime checks and documentation.
+func routes(args []string) error {
+	fset := flag.NewFlagSet("routes", flag.ExitOnError)
+	format := fset.String("format", "json", "format of the routes: json or openapi")
+	title := fset.String("title", "", "title of the OpenAPI document, the directory's name by default")
+
+	dirs, err := parseArgs(fset, args)
+	if err != nil {
+		return err
+	}
+	if len(dirs) != 1 {
+		return errors.New("routes takes a single directory")
+	}
+	if *title == "" {
+		*title = filepath.Base(dirs[0])
+	}
+
+	return newHandler(dirs[0]).WriteRoutes(os.Stdout, htmplx.RoutesFormat(*format), *title)
+}
+
+// lint reports the accessibility and htmx wiring issues of the rendered pages, failing if there are any.
+func lint(args []string) error {
+	if len(args) < 1 {
+		return errors.New("lint takes a directory, and optionally the routes to check")
+	}
+
+	var routes []string
+	if len(args) > 1 {
+		routes = args[1:]
+	}
+
+	issues, err := newHandler(args[0]).Lint(context.Background(), routes)
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issues found", len(issues))
+	}
+
+	return nil
+}
+
+// stdoutResponseWriter writes a response body to out.
+type stdoutResponseWriter struct {
+	header http.Header
+	status int
+	out    io.Writer
+}
+
+func (w *stdoutResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *stdoutResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *stdoutResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.out.Write(p)
+}
@@ -0,0 +1,56 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"strings"
+)
+
+// componentsDir is the directory of templates shared by every page, outside of the url tree.
+const componentsDir = "_components"
+
+// isReservedDir reports whether the root level directory name holds files htmplx uses itself,
+// and is therefore not a route.
+func isReservedDir(name string) bool {
+	return name == componentsDir || name == iconsDir
+}
+
+// loadComponents parses every template in _components, and its subdirectories, into layout.
+// Components are named after their path within _components, without the extension,
+// e.g. {{template "forms/input" .}} for _components/forms/input.html.tmpl.
+// Templates along the route take precedence over components of the same name.
+func (h requestHandler) loadComponents(layout *template.Template) error {
+	// front matter describes pages, not the components they use.
+	h.page = nil
+
+	err := fs.WalkDir(h.fs, componentsDir, func(filename string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.IsDir() {
+			return nil
+		}
+		ext, ok := h.templateExt(filename)
+		if !ok {
+			return nil
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(filename, componentsDir+"/"), ext)
+		h.log.Debug("loading component " + name)
+
+		if _, err := h.loadTemplate(layout, name, filename); err != nil {
+			return fmt.Errorf("failed to parse component %s: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to load components: %w", err)
+	}
+
+	return nil
+}
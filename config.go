@@ -0,0 +1,66 @@
+package htmplx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// directoryConfigFilename is the name of the optional per-directory configuration file.
+// It is never served.
+const directoryConfigFilename = "_config.json"
+
+// directoryConfig is the content of a directory's _config.json file.
+type directoryConfig struct {
+	// Schedule defines templates that are only in effect during a window of time.
+	Schedule []scheduleBlock `json:"schedule"`
+	// Alternates declares alternate representations of the directory's routes.
+	Alternates []alternate `json:"alternates"`
+	// PDF enables rendering the directory's routes, and those of its subdirectories, as pdfs.
+	PDF *bool `json:"pdf"`
+	// Headers are set on responses for the directory's routes and files, and those of its subdirectories.
+	// An empty value removes a header set by a parent directory.
+	Headers map[string]string `json:"headers"`
+	// Sitemap sets the priority and change frequency of the directory's pages in the sitemap, or excludes them.
+	Sitemap *sitemapConfig `json:"sitemap"`
+	// Locales declares the locales the tree is served in, and their translations of directory names.
+	// It is only read from the root directory.
+	Locales *localesConfig `json:"locales"`
+}
+
+// readDirectoryConfig reads the _config.json file in dir, if any.
+// Configs are read at most once per request.
+func (h requestHandler) readDirectoryConfig(dir string) (cfg directoryConfig, err error) {
+	if cfg, ok := h.configs[dir]; ok {
+		return cfg, nil
+	}
+	defer func() {
+		if err == nil && h.configs != nil {
+			h.configs[dir] = cfg
+		}
+	}()
+
+	filename := path.Join(dir, directoryConfigFilename)
+
+	b, err := h.readFile(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+
+	h.log.Debug("directory config found: " + filename)
+
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid directory config %s: %w", filename, err)
+	}
+
+	return cfg, nil
+}
+
+func isDirectoryConfigFile(filename string) bool {
+	return path.Base(filename) == directoryConfigFilename
+}
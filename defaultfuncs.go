@@ -0,0 +1,186 @@
+package htmplx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// WithDefaultFuncs makes DefaultFuncs available to templates.
+// Functions passed to WithFuncs take precedence.
+func (h *Handler[D]) WithDefaultFuncs() *Handler[D] {
+	h.defaultFuncs = true
+	return h
+}
+
+// DefaultFuncs returns the standard library of template functions enabled by WithDefaultFuncs.
+// Arguments are ordered so the value operated on may be piped in, e.g. {{.Title | truncate 20}}.
+//
+//	dict "k1" v1 "k2" v2   map[string]any of key value pairs
+//	list v1 v2             []any of the values
+//	default d v            v, or d if v is empty
+//	coalesce v1 v2         the first non-empty value
+//	join sep list          elements of a slice joined by sep
+//	lower s, upper s       s in lower or upper case
+//	date layout t          time.Time t formatted with layout, e.g. "2006-01-02"; empty for the zero time
+//	json v                 v encoded as JSON
+//	safeHTML s             s as trusted, unescaped html
+//	truncate n s           s cut to n characters, with an ellipsis if cut
+//	add a b..., sub a b    exact decimal sum and difference, as a Decimal, e.g. add 0.1 0.2 is 0.3
+//	mul a b..., div a b    exact decimal product and quotient
+//	round places v         v rounded to places decimal places, halves away from zero
+//	currency symbol v      v as money, e.g. currency "$" 1234.5 is $1,234.50
+//	humanizeBytes n        n bytes in binary units, e.g. 1.5 KiB
+//	compactNumber n        n with a short suffix, e.g. 1.2k or 3.4M
+//
+// Numeric arguments may be integers, floats, numeric strings or Decimals.
+func DefaultFuncs() template.FuncMap {
+	return template.FuncMap{
+		"dict":     dict,
+		"list":     list,
+		"default":  defaultValue,
+		"coalesce": coalesce,
+		"join":     join,
+		"lower":    strings.ToLower,
+		"upper":    strings.ToUpper,
+		"date":     formatDate,
+		"json":     toJSON,
+		"safeHTML": safeHTML,
+		"truncate": truncate,
+
+		"add":           add,
+		"sub":           sub,
+		"mul":           mul,
+		"div":           div,
+		"round":         round,
+		"currency":      currency,
+		"humanizeBytes": humanizeBytes,
+		"compactNumber": compactNumber,
+	}
+}
+
+func dict(kvs ...any) (map[string]any, error) {
+	if len(kvs)%2 != 0 {
+		return nil, errors.New("dict: odd number of arguments")
+	}
+
+	m := make(map[string]any, len(kvs)/2)
+	for i := 0; i < len(kvs); i += 2 {
+		k, ok := kvs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: key %v is not a string", kvs[i])
+		}
+		m[k] = kvs[i+1]
+	}
+
+	return m, nil
+}
+
+func list(items ...any) []any {
+	return items
+}
+
+func defaultValue(d, v any) any {
+	if isEmpty(v) {
+		return d
+	}
+	return v
+}
+
+func coalesce(vs ...any) any {
+	for _, v := range vs {
+		if !isEmpty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// isEmpty reports whether v is nil, a zero value, or an empty collection.
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Interface, reflect.Pointer:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+func join(sep string, items any) (string, error) {
+	switch items := items.(type) {
+	case nil:
+		return "", nil
+	case []string:
+		return strings.Join(items, sep), nil
+	}
+
+	rv := reflect.ValueOf(items)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: %T is not a slice", items)
+	}
+
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+
+	return strings.Join(parts, sep), nil
+}
+
+func formatDate(layout string, t any) (string, error) {
+	switch t := t.(type) {
+	case nil:
+		return "", nil
+	case time.Time:
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	case *time.Time:
+		if t == nil || t.IsZero() {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	default:
+		return "", fmt.Errorf("date: %T is not a time.Time", t)
+	}
+}
+
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("json: %w", err)
+	}
+	return string(b), nil
+}
+
+func safeHTML(s string) template.HTML {
+	return template.HTML(s)
+}
+
+func truncate(n int, s string) string {
+	if n < 0 {
+		n = 0
+	}
+
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n == 0 {
+		return ""
+	}
+
+	return string(runes[:n-1]) + "…"
+}
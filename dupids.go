@@ -0,0 +1,70 @@
+package htmplx
+
+import (
+	"html/template"
+	"log/slog"
+	"slices"
+)
+
+// WithDuplicateIDCheck checks every page rendered for elements sharing an id while enabled, for development.
+// Duplicate ids silently break htmx targets, labels and fragment links, and are easily introduced by composing
+// a page from many templates, so each is logged with a warning naming the templates declaring it.
+func (h *Handler[D]) WithDuplicateIDCheck(enabled bool) *Handler[D] {
+	h.duplicateIDCheck = enabled
+	return h
+}
+
+// duplicateID is an element with the id of an earlier element of a page.
+type duplicateID struct {
+	id  string
+	tag htmlTag
+	// first is the line of the first element with the id.
+	first int
+}
+
+// findDuplicateIDs returns the elements of a page with the id of an earlier element.
+func findDuplicateIDs(tags []htmlTag) []duplicateID {
+	firstLine := make(map[string]int)
+
+	var duplicates []duplicateID
+	for _, tag := range tags {
+		id, ok := tag.attrs["id"]
+		if tag.end || !ok || id == "" {
+			continue
+		}
+		if line, ok := firstLine[id]; ok {
+			duplicates = append(duplicates, duplicateID{id: id, tag: tag, first: line})
+			continue
+		}
+		firstLine[id] = tag.line
+	}
+	return duplicates
+}
+
+// checkDuplicateIDs warns of the duplicate ids of the page rendered from layout,
+// naming the templates whose source declares each.
+func checkDuplicateIDs(l *slog.Logger, layout *template.Template, page string) {
+	duplicates := findDuplicateIDs(scanHTMLTags(page))
+	if len(duplicates) == 0 {
+		return
+	}
+
+	declaredBy := make(map[string][]string)
+	for _, t := range layout.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		for _, tag := range scanHTMLTags(t.Tree.Root.String()) {
+			if id := tag.attrs["id"]; id != "" && !tag.end && !slices.Contains(declaredBy[id], t.Name()) {
+				declaredBy[id] = append(declaredBy[id], t.Name())
+			}
+		}
+	}
+
+	for _, d := range duplicates {
+		templates := declaredBy[d.id]
+		slices.Sort(templates)
+		l.With("id", d.id, "line", d.tag.line, "firstLine", d.first, "templates", templates).
+			Warn("duplicate element id")
+	}
+}
@@ -0,0 +1,234 @@
+package htmplx
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// editorOpenPath opens a template in the local editor.
+	editorOpenPath = "/_htmplx/open"
+	// sourceAttr is the attribute naming the template file and line an element comes from.
+	sourceAttr = "data-htmplx-src"
+	// editorTokenHeader is the header of requests opening templates, holding the editor's token.
+	editorTokenHeader = "X-Htmplx-Editor-Token"
+)
+
+// editorScript opens the template of an element alt-clicked in the local editor.
+// The url opening templates and the editor's token are formatted in.
+const editorScript = `<script>
+document.addEventListener("click", (e) => {
+	const el = e.altKey && e.target.closest("[` + sourceAttr + `]");
+	if (!el) {
+		return;
+	}
+	e.preventDefault();
+	e.stopPropagation();
+	fetch("%s?src=" + encodeURIComponent(el.getAttribute("` + sourceAttr + `")), {
+		method: "POST",
+		headers: { "` + editorTokenHeader + `": "%s" },
+	});
+}, true);
+</script>`
+
+// unannotatedElements are the elements not annotated with their source, those of the document's head.
+var unannotatedElements = []string{"html", "head", "meta", "link", "title", "base", "script", "style"}
+
+type editor struct {
+	// root is the directory of the tree on disk.
+	root string
+	// command is the editor's command line.
+	command []string
+	// token authorizes requests opening templates, which only pages served by the handler know,
+	// so that other pages open in the browser cannot run the editor.
+	token string
+}
+
+// WithEditor annotates rendered elements with the template file and line they come from, for development,
+// so as to find which of the templates overriding one another produced them, e.g.
+//
+//	<li data-htmplx-src="blog/_components/card.html.tmpl:12">
+//
+// Alt-clicking an element opens its template in the local editor, as does a POST request for
+// /_htmplx/open?src=blog/_components/card.html.tmpl:12 from the local host. command is the editor's
+// command line, run without a shell, with {file} and {line} replaced, e.g. "code --goto {file}:{line}",
+// and root is the directory of the tree on disk, the files being relative to it. Pages include the script
+// with the liveReload template func, along with a token generated for the process, which requests must send,
+// and only same origin requests are served. An empty command disables it.
+func (h *Handler[D]) WithEditor(root, command string) *Handler[D] {
+	if command == "" {
+		h.editor = nil
+		return h
+	}
+	token := make([]byte, 16)
+	rand.Read(token)
+	h.editor = &editor{root: root, command: strings.Fields(command), token: hex.EncodeToString(token)}
+	return h
+}
+
+// annotateSource annotates the elements of the source b of the template read from filename with their source,
+// if enabled.
+func (h requestHandler) annotateSource(filename string, b []byte) []byte {
+	if h.editor == nil {
+		return b
+	}
+	return annotateElements(filename, b)
+}
+
+// annotateElements adds the source attribute to the start tags of template source b, outside of actions,
+// comments and raw text, right after the tag name so that it stays in the tag whatever the rest of it is.
+// Front matter is left as is, though its lines are counted.
+func annotateElements(filename string, b []byte) []byte {
+	src := string(b)
+
+	var out strings.Builder
+	line := 1
+	i := 0
+	if _, content, _ := parseFrontMatter(b); len(content) < len(b) {
+		i = len(b) - len(content)
+		line += strings.Count(src[:i], "\n")
+		out.WriteString(src[:i])
+	}
+
+	for i < len(src) {
+		rest := src[i:]
+
+		// n is how much of rest is written as is.
+		var n int
+		switch {
+		case strings.HasPrefix(rest, "{{"):
+			n = indexPast(rest, "}}")
+		case strings.HasPrefix(rest, "<!--"):
+			n = indexPast(rest, "-->")
+		case rest[0] == '<' && len(rest) > 1 && ('a' <= rest[1] && rest[1] <= 'z' || 'A' <= rest[1] && rest[1] <= 'Z'):
+			n = 1
+			for n < len(rest) && isTagNameByte(rest[n]) {
+				n++
+			}
+			name := strings.ToLower(rest[1:n])
+			out.WriteString(rest[:n])
+			if !slices.Contains(unannotatedElements, name) {
+				fmt.Fprintf(&out, ` %s="%s:%d"`, sourceAttr, filename, line)
+			}
+			i += n
+			rest = rest[n:]
+
+			n = 0
+			// the content of raw text elements is not markup.
+			if slices.Contains(rawTextElements, name) {
+				if n = strings.Index(strings.ToLower(rest), "</"+name); n < 0 {
+					n = len(rest)
+				}
+			}
+		default:
+			if n = strings.IndexAny(rest[1:], "<{"); n < 0 {
+				n = len(rest)
+			} else {
+				n++
+			}
+		}
+
+		out.WriteString(rest[:n])
+		line += strings.Count(rest[:n], "\n")
+		i += n
+	}
+
+	return []byte(out.String())
+}
+
+// indexPast returns the index of s just past the first delim, or the length of s if there is none.
+func indexPast(s, delim string) int {
+	if i := strings.Index(s, delim); i >= 0 {
+		return i + len(delim)
+	}
+	return len(s)
+}
+
+// serveEditorOpen opens the template of the src parameter, file:line, in the local editor.
+// Only POST requests from the local host, made by pages of the same origin with the editor's token, are served,
+// as they run the editor.
+func (h *Handler[D]) serveEditorOpen(w http.ResponseWriter, r *http.Request, l *slog.Logger) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err != nil || !net.ParseIP(host).IsLoopback() {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if !isSameOriginRequest(r) ||
+		subtle.ConstantTimeCompare([]byte(r.Header.Get(editorTokenHeader)), []byte(h.editor.token)) != 1 {
+		l.Warn("refused to open editor for request without the editor's token or from another site")
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	src := r.URL.Query().Get("src")
+	sep := strings.LastIndexByte(src, ':')
+	file := src[:max(sep, 0)]
+	line, err := strconv.Atoi(src[sep+1:])
+	if sep < 0 || err != nil || line < 1 || !fs.ValidPath(file) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if info, err := fs.Stat(h.tree.load(), file); err != nil || info.IsDir() {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	replacer := strings.NewReplacer("{file}", filepath.Join(h.editor.root, filepath.FromSlash(file)), "{line}", strconv.Itoa(line))
+	args := make([]string, len(h.editor.command))
+	for i, arg := range h.editor.command {
+		args[i] = replacer.Replace(arg)
+	}
+
+	l = l.With("file", file, "line", line)
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		l.With("error", err).
+			Error("failed to open editor")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	go cmd.Wait()
+	l.Debug("opened editor")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// isSameOriginRequest reports whether the request was made by a page of the origin it is for, by its
+// Sec-Fetch-Site and Origin headers, if sent.
+func isSameOriginRequest(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" {
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return false
+		}
+	}
+	return true
+}
+
+// editorScriptFunc returns the script opening the template of alt-clicked elements, if enabled.
+func (h requestHandler) editorScriptFunc() string {
+	if h.editor == nil {
+		return ""
+	}
+	return fmt.Sprintf(editorScript, template.JSEscapeString(h.withBasePath(editorOpenPath)),
+		template.JSEscapeString(h.editor.token))
+}
@@ -0,0 +1,33 @@
+package htmplx
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// NewHandlerForEmbed serves the root directory of an embedded file system, e.g. "site" for files embedded with
+// //go:embed site, or "." for the whole file system.
+// It returns an error if root is not a directory of efs.
+//
+// Embedded files have no modification times, so are served without a Last-Modified header
+// and listed in the sitemap without a last modification date.
+func NewHandlerForEmbed[D RequestData](efs embed.FS, root string) (*Handler[D], error) {
+	root = path.Clean(root)
+
+	info, err := fs.Stat(efs, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find embedded directory %s: %w", root, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("embedded %s is not a directory", root)
+	}
+
+	dir, err := fs.Sub(efs, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use embedded directory %s: %w", root, err)
+	}
+
+	return NewHandler[D](dir), nil
+}
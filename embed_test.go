@@ -0,0 +1,112 @@
+package htmplx
+
+import (
+	"embed"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// embedded holds testdata/embed as //go:embed does by default, without its files starting with . or _.
+//
+//go:embed testdata/embed
+var embedded embed.FS
+
+// embeddedAll holds every file of testdata/embed.
+//
+//go:embed all:testdata/embed
+var embeddedAll embed.FS
+
+func newTestHandlerForEmbed(t *testing.T, efs embed.FS, root string) *Handler[RequestDataMap] {
+	t.Helper()
+	h, err := NewHandlerForEmbed[RequestDataMap](efs, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.WithLogHandlers(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestNewHandlerForEmbedRoot(t *testing.T) {
+	tests := []struct {
+		root    string
+		wantErr bool
+	}{
+		{root: "testdata/embed"},
+		{root: "testdata/embed/"},
+		{root: "./testdata/embed"},
+		{root: "testdata/./embed/about/.."},
+		{root: "testdata/embed/about"},
+		{root: "."},
+		{root: "testdata/embed/style.css", wantErr: true},
+		{root: "testdata/missing", wantErr: true},
+		// embed.FS names never begin with a slash.
+		{root: "/testdata/embed", wantErr: true},
+		{root: "../testdata/embed", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := NewHandlerForEmbed[RequestDataMap](embedded, tt.root)
+		if gotErr := err != nil; gotErr != tt.wantErr {
+			t.Errorf("root %q: got error %v, want error %t", tt.root, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewHandlerForEmbedServes(t *testing.T) {
+	tests := []struct {
+		name       string
+		efs        embed.FS
+		root       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"page", embedded, "testdata/embed", "/", http.StatusOK, "<p>home</p>"},
+		{"nested page", embedded, "testdata/embed", "/about", http.StatusOK, "<p>about</p>"},
+		{"static file", embedded, "testdata/embed", "/style.css", http.StatusOK, "margin: 0"},
+		// the root is the top of the tree served, its prefix is not part of the path.
+		{"root prefix", embedded, "testdata/embed", "/testdata/embed/style.css", http.StatusNotFound, ""},
+		{"root directory", embedded, ".", "/testdata/embed/style.css", http.StatusOK, "margin: 0"},
+		{"subdirectory root", embedded, "testdata/embed/about", "/", http.StatusOK, "<p>about</p>"},
+		{"template source", embedded, "testdata/embed", "/body.html.tmpl", http.StatusNotFound, ""},
+		// files starting with . or _ are only embedded with the all: prefix, and hidden when they are.
+		{"dotfile not embedded", embedded, "testdata/embed", "/.env", http.StatusNotFound, ""},
+		{"dotfile embedded", embeddedAll, "testdata/embed", "/.env", http.StatusNotFound, ""},
+		{"underscore file not embedded", embedded, "testdata/embed", "/_draft.css", http.StatusNotFound, ""},
+		{"underscore file embedded", embeddedAll, "testdata/embed", "/_draft.css", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandlerForEmbed(t, tt.efs, tt.root)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("got body %q, want it to contain %q", w.Body, tt.wantBody)
+			}
+			// embedded files have no modification time.
+			if lm := w.Header().Get("Last-Modified"); lm != "" {
+				t.Errorf("got Last-Modified %s, want none", lm)
+			}
+		})
+	}
+}
+
+func TestEmbeddedHiddenFiles(t *testing.T) {
+	for name, efs := range map[string]embed.FS{"default": embedded, "all": embeddedAll} {
+		_, errEnv := efs.Open("testdata/embed/.env")
+		_, errDraft := efs.Open("testdata/embed/_draft.css")
+		wantEmbedded := name == "all"
+		if (errEnv == nil) != wantEmbedded || (errDraft == nil) != wantEmbedded {
+			t.Errorf("%s: got .env error %v and _draft.css error %v, want embedded %t", name, errEnv, errDraft, wantEmbedded)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package htmplx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// precompressedEncodings are the sidecar file extensions checked for a static file,
+// in order of preference, along with the content coding they represent.
+var precompressedEncodings = []struct {
+	coding string
+	ext    string
+}{
+	{coding: "br", ext: ".br"},
+	{coding: "gzip", ext: ".gz"},
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header value permits the given content coding.
+func acceptsEncoding(acceptEncoding, coding string) bool {
+	wildcard := false
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		switch name {
+		case coding:
+			return q > 0
+		case "*":
+			wildcard = q > 0
+		}
+	}
+
+	return wildcard
+}
@@ -0,0 +1,124 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrorBudget rolls back a promoted tree, to the version it replaced, once its pages fail to render too often,
+// protecting production from bad template pushes.
+type ErrorBudget struct {
+	// MaxErrors is the number of render errors tolerated within Window; one more rolls the tree back.
+	MaxErrors int
+	Window    time.Duration
+	// Alert, if set, is called with the reason once a tree is rolled back.
+	Alert func(error)
+}
+
+// WithErrorBudget rolls back trees promoted by PromoteTree that exceed the error budget.
+// Only errors rendering pages of the tree count, not error statuses reported by, nor panics of, the data callback,
+// nor errors rendering the trees of other hosts or tenants.
+func (h *Handler[D]) WithErrorBudget(budget ErrorBudget) *Handler[D] {
+	h.errorBudget = &errorBudget{ErrorBudget: budget}
+	return h
+}
+
+type errorBudget struct {
+	ErrorBudget
+
+	mu sync.Mutex
+	// errs are the times of the recent render errors of the current tree, oldest first.
+	errs []time.Time
+}
+
+// recordRenderError counts a failure to render the request's page against the error budget of the current tree,
+// rolling it back if the budget is exceeded.
+func (h *Handler[D]) recordRenderError(r *http.Request, err error) {
+	b := h.errorBudget
+	if b == nil || !chargesErrorBudget(r, err) {
+		return
+	}
+	if _, _, ok := h.hostTree(r); ok {
+		return
+	}
+
+	now := h.now()
+
+	b.mu.Lock()
+	for len(b.errs) > 0 && now.Sub(b.errs[0]) > b.Window {
+		b.errs = b.errs[1:]
+	}
+	b.errs = append(b.errs, now)
+	exceeded := len(b.errs) > b.MaxErrors
+	b.mu.Unlock()
+
+	if !exceeded {
+		return
+	}
+
+	from, to, rollbackErr := h.rollbackTree()
+	if rollbackErr != nil {
+		// there is no previous version to fall back to.
+		return
+	}
+
+	alert := fmt.Errorf("rolled back version %q to %q: more than %d render errors within %s: %w",
+		from, to, b.MaxErrors, b.Window, err)
+	h.log.With("error", alert).
+		Error("tree rolled back")
+	if b.Alert != nil {
+		b.Alert(alert)
+	}
+}
+
+// chargesErrorBudget reports whether the error rendering the request's page is the fault of the tree,
+// rather than of the data callback or of a staged tree being warmed up.
+func chargesErrorBudget(r *http.Request, err error) bool {
+	if _, ok := r.Context().Value(treeContextKey{}).(fs.FS); ok {
+		return false
+	}
+	var panicErr *panicError
+	return !errors.As(err, &panicErr) || !panicErr.data
+}
+
+// resetErrorBudget starts counting render errors afresh, for a new tree.
+func (h *Handler[D]) resetErrorBudget() {
+	if b := h.errorBudget; b != nil {
+		b.mu.Lock()
+		b.errs = nil
+		b.mu.Unlock()
+	}
+}
+
+// RollbackTree switches back to the version of the tree replaced by the last PromoteTree,
+// the last known good version. A tree can be rolled back once per promotion.
+func (h *Handler[D]) RollbackTree() error {
+	from, to, err := h.rollbackTree()
+	if err != nil {
+		return err
+	}
+	h.log.With("from", from, "to", to).
+		Info("tree rolled back")
+	return nil
+}
+
+func (h *Handler[D]) rollbackTree() (from, to string, err error) {
+	// the tree is swapped under the lock, so that a tree promoted meanwhile is not replaced by a stale one.
+	h.tree.mu.Lock()
+	defer h.tree.mu.Unlock()
+
+	previous := h.tree.previous
+	if previous == nil {
+		return "", "", errors.New("no previous tree to roll back to")
+	}
+	h.tree.previous = nil
+
+	current := h.tree.current.Swap(previous)
+	h.treeChanged()
+
+	return current.version, previous.version, nil
+}
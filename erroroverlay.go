@@ -0,0 +1,99 @@
+package htmplx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// errorOverlayStyle is the style of the overlay showing template errors over pages.
+const errorOverlayStyle = "position:fixed;inset:0;z-index:2147483647;overflow:auto;margin:0;padding:2em;" +
+	"background:rgba(24,24,27,.95);color:#fca5a5;font:14px/1.5 ui-monospace,monospace;cursor:pointer"
+
+// templateErrorsPage is served in place of pages while the tree is invalid, reloading once it is fixed.
+var templateErrorsPage = template.Must(template.New("errors").Parse(`<!DOCTYPE html>
+<html>
+	<head>
+		<meta charset="utf-8">
+		<title>Template errors</title>
+	</head>
+	<body>
+		<div id="htmplx-errors" style="{{.Style}}">
+			<h2>Template errors</h2>
+			{{- range .Errors}}
+			<pre style="white-space:pre-wrap">{{.}}</pre>
+			{{- end}}
+		</div>
+		{{.Script}}
+	</body>
+</html>
+`))
+
+// splitErrors returns the messages of the errors joined in err, as returned by Validate.
+func splitErrors(err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		return []string{err.Error()}
+	}
+
+	var messages []string
+	for _, err := range joined.Unwrap() {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+// writeTemplateErrorsEvent writes an event reporting the errors of the tree to a page connected for live reload,
+// its data a json object, e.g. {"errors": [{"message": "template: body.html.tmpl:3: unexpected EOF"}]}.
+func writeTemplateErrorsEvent(w io.Writer, errs []string) {
+	type templateError struct {
+		Message string `json:"message"`
+	}
+	event := struct {
+		Errors []templateError `json:"errors"`
+	}{}
+	for _, err := range errs {
+		event.Errors = append(event.Errors, templateError{Message: err})
+	}
+
+	b, _ := json.Marshal(event)
+	fmt.Fprintf(w, "event: template-errors\ndata: %s\n\n", b)
+}
+
+// serveTemplateErrors serves the errors of the tree in place of a page navigated to while it is invalid,
+// returning false if it is valid, or the request is not for a page.
+func (h *Handler[D]) serveTemplateErrors(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead || path.Ext(r.URL.Path) != "" ||
+		r.Header.Get("HX-Request") != "" {
+		return false
+	}
+	errs := h.liveReload.templateErrors()
+	if len(errs) == 0 {
+		return false
+	}
+
+	var b strings.Builder
+	err := templateErrorsPage.Execute(&b, map[string]any{
+		"Style":  template.CSS(errorOverlayStyle),
+		"Errors": errs,
+		"Script": h.newRequestHandler(h.log).liveReloadScriptFunc(),
+	})
+	if err != nil {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusInternalServerError)
+	io.WriteString(w, b.String())
+	return true
+}
@@ -0,0 +1,43 @@
+package htmplx
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+)
+
+// WithErrorReporter reports the errors failing requests to report, such as to an error tracking service:
+// those of 500 responses, including templates failing to parse or execute and data callbacks reporting
+// a 5xx status, and panics, reported as errors of their value. Panics are reported whether recovered,
+// as while rendering, or not, as in middleware and method handlers, which panic on after being reported.
+// FingerprintError groups the errors reported as they are logged. report is called on the goroutine serving
+// the request, so should not block it.
+func (h *Handler[D]) WithErrorReporter(report func(ctx context.Context, r *http.Request, err error)) *Handler[D] {
+	h.errorReporter = report
+	return h
+}
+
+// reportError reports the error failing the request, if an error reporter is set.
+func (h *Handler[D]) reportError(r *http.Request, err error) {
+	if h.errorReporter != nil {
+		h.errorReporter(r.Context(), r, err)
+	}
+}
+
+// reportPanic reports a panic serving the request, then panics on, to be handled by the http server.
+// It must be deferred.
+func (h *Handler[D]) reportPanic(r *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	// aborting a response is not a failure.
+	if v != http.ErrAbortHandler {
+		panicErr, ok := v.(*panicError)
+		if !ok {
+			panicErr = &panicError{value: v, stack: debug.Stack()}
+		}
+		h.reportError(r, panicErr)
+	}
+	panic(v)
+}
@@ -0,0 +1,237 @@
+package htmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// exportLinkRegexp matches the root relative links of an exported page, which are crawled for more routes.
+var exportLinkRegexp = regexp.MustCompile(`(?i)\shref\s*=\s*["'](/[^"'#?]*)`)
+
+// Export renders routes to html files in outDir, /blog as blog/index.html, and copies the static files,
+// so the directory can be deployed as a static site.
+// If routes is empty, every directory not under a regex directory is exported, and the links of every
+// exported page are followed to find the routes of regex directories, such as /blog/42.
+// Routes that are not found are skipped when discovered, and an error when listed.
+func (h *Handler[D]) Export(ctx context.Context, outDir string, routes []string) error {
+	l := h.log.With("outDir", outDir)
+	l.Info("exporting site")
+
+	if h.validate {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+	}
+
+	explicit := len(routes) > 0
+	if !explicit {
+		var err error
+		if routes, err = h.exportableRoutes(h.fs); err != nil {
+			return err
+		}
+	}
+
+	exported := make(map[string]bool)
+
+	for len(routes) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// the routes found are rendered concurrently, and the links of their pages are the next routes.
+		var jobs []RenderJob[D]
+		for _, route := range routes {
+			route = path.Clean("/" + route)
+			if exported[route] {
+				continue
+			}
+			exported[route] = true
+			jobs = append(jobs, RenderJob[D]{Route: route})
+		}
+		routes = nil
+
+		for i, res := range h.RenderAll(ctx, jobs, runtime.GOMAXPROCS(0)) {
+			links, err := h.exportRoute(outDir, jobs[i].Route, res, explicit)
+			if err != nil {
+				return err
+			}
+			if !explicit {
+				routes = append(routes, links...)
+			}
+		}
+	}
+
+	if err := h.exportFiles(outDir); err != nil {
+		return err
+	}
+
+	// a sitemap lists absolute urls, so is only exported when its base url is known.
+	if h.sitemap != nil && h.sitemap.BaseURL != "" {
+		rec := httptest.NewRecorder()
+		h.serveSitemap(rec, httptest.NewRequest(http.MethodGet, h.sitemap.Path, nil).WithContext(ctx), l)
+		if rec.Code != http.StatusOK {
+			return fmt.Errorf("failed to export sitemap: %s", rec.Body)
+		}
+		if err := writeExportFile(filepath.Join(outDir, filepath.FromSlash(strings.TrimPrefix(h.sitemap.Path, "/"))), rec.Body.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if h.serviceWorker != nil {
+		rec := httptest.NewRecorder()
+		h.serveServiceWorker(rec, httptest.NewRequest(http.MethodGet, h.serviceWorker.Path, nil).WithContext(ctx), l)
+		if rec.Code != http.StatusOK {
+			return fmt.Errorf("failed to export service worker: %s", rec.Body)
+		}
+		if err := writeExportFile(filepath.Join(outDir, filepath.FromSlash(strings.TrimPrefix(h.serviceWorker.Path, "/"))), rec.Body.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	l.With("routes", len(exported)).
+		Info("site exported")
+
+	return nil
+}
+
+// exportableRoutes lists the url paths of the directories of the tree outside of regex directories.
+func (h *Handler[D]) exportableRoutes(fsys fs.FS) ([]string, error) {
+	routes := []string{"/"}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || name == "." {
+			return nil
+		}
+		if isRegexPathPart(d.Name()) || name == d.Name() && isReservedDir(name) || h.hiddenFiles.hides(d.Name()) {
+			return fs.SkipDir
+		}
+
+		routes = append(routes, "/"+name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	return routes, nil
+}
+
+// exportRoute writes the rendered route to outDir, returning the root relative links of the page.
+func (h *Handler[D]) exportRoute(outDir, route string, res RenderResult, mustExist bool) ([]string, error) {
+	l := h.routeLog(route)
+
+	if (res.Status == http.StatusNotFound || res.Status == http.StatusGone) && !mustExist {
+		l.Debug("skipping route without a page")
+		return nil, nil
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	b := res.Body
+
+	filename := filepath.Join(outDir, filepath.FromSlash(strings.TrimPrefix(route, "/")), "index.html")
+	if err := writeExportFile(filename, b); err != nil {
+		return nil, err
+	}
+	l.Debug("exported " + filename)
+
+	var links []string
+	for _, m := range exportLinkRegexp.FindAllSubmatch(b, -1) {
+		link := string(m[1])
+		if path.Ext(link) != "" || strings.HasPrefix(link, "//") {
+			continue
+		}
+		// links under the base path are to routes of the handler, others are not.
+		if h.basePath != "" {
+			var ok bool
+			if link, ok = strings.CutPrefix(link, h.basePath); !ok || link != "" && link[0] != '/' {
+				continue
+			}
+		}
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// exportFiles copies the static files to outDir, and the fingerprinted copies of those that were fingerprinted.
+func (h *Handler[D]) exportFiles(outDir string) error {
+	rh := h.newRequestHandler(h.log)
+
+	err := fs.WalkDir(h.fs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name == d.Name() && isReservedDir(name) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path.Ext(name) == "" || rh.isHiddenFile(name) || isSourceMap(name) && h.assetMode != DevAssets {
+			return nil
+		}
+
+		b, err := fs.ReadFile(h.fs, name)
+		if err != nil {
+			return err
+		}
+
+		return writeExportFile(filepath.Join(outDir, filepath.FromSlash(name)), b)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export files: %w", err)
+	}
+
+	h.assets.mu.Lock()
+	fingerprinted := make(map[string]string, len(h.assets.hashes))
+	for name, fp := range h.assets.hashes {
+		fingerprinted[name] = fingerprintedName(name, fp.hash)
+	}
+	h.assets.mu.Unlock()
+
+	names := make([]string, 0, len(fingerprinted))
+	for name := range fingerprinted {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		b, err := fs.ReadFile(h.fs, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("failed to export %s: %w", name, err)
+		}
+		if err := writeExportFile(filepath.Join(outDir, filepath.FromSlash(fingerprinted[name])), b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeExportFile(filename string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return fmt.Errorf("failed to export %s: %w", filename, err)
+	}
+	if err := os.WriteFile(filename, b, 0o644); err != nil {
+		return fmt.Errorf("failed to export %s: %w", filename, err)
+	}
+	return nil
+}
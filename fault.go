@@ -0,0 +1,102 @@
+package htmplx
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// FaultTarget is what a Fault is injected into.
+type FaultTarget int
+
+const (
+	// FaultFS injects into every file system read of a request, templates and static files alike.
+	FaultFS FaultTarget = 1 << iota
+	// FaultData injects into the data callback, which is not called when the fault fails.
+	FaultData
+	// FaultTemplate injects into template execution.
+	FaultTemplate
+)
+
+// Fault is an artificial delay or failure, for exercising timeouts, circuit breakers and fallbacks in tests.
+type Fault struct {
+	// Target is where the fault is injected, one or more FaultTargets or'ed together.
+	Target FaultTarget
+	// Match selects the requests the fault is injected into. nil matches every request.
+	Match func(*http.Request) bool
+	// Latency is added before each read, data callback or execution, ending early if the request is canceled.
+	Latency time.Duration
+	// Err, if not nil, is the failure. A *StatusError fails the data callback with its status code.
+	Err error
+}
+
+// WithFaults injects faults into matching requests. It is meant for tests, never production.
+//
+//	h.WithFaults(htmplx.Fault{
+//		Target:  htmplx.FaultData,
+//		Match:   func(r *http.Request) bool { return strings.HasPrefix(r.URL.Path, "/orders") },
+//		Latency: 2 * time.Second,
+//	})
+func (h *Handler[D]) WithFaults(faults ...Fault) *Handler[D] {
+	h.faults = append(h.faults, faults...)
+	return h
+}
+
+// injectFault applies the faults matching r and target, returning the first failure.
+func (h *Handler[D]) injectFault(r *http.Request, target FaultTarget) error {
+	return injectFaults(r.Context(), h.matchingFaults(r, target))
+}
+
+func (h *Handler[D]) matchingFaults(r *http.Request, target FaultTarget) []Fault {
+	var faults []Fault
+	for _, f := range h.faults {
+		if f.Target&target != 0 && (f.Match == nil || f.Match(r)) {
+			faults = append(faults, f)
+		}
+	}
+	return faults
+}
+
+func injectFaults(ctx context.Context, faults []Fault) error {
+	for _, f := range faults {
+		if f.Latency > 0 {
+			t := time.NewTimer(f.Latency)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+		}
+		if f.Err != nil {
+			return f.Err
+		}
+	}
+	return nil
+}
+
+// faultFS returns the file system to serve r from, injecting the FaultFS faults matching r.
+func (h *Handler[D]) faultFS(r *http.Request) fs.FS {
+	fsys := h.requestFS(r)
+	faults := h.matchingFaults(r, FaultFS)
+	if len(faults) == 0 {
+		return fsys
+	}
+	return &faultyFS{FS: fsys, ctx: r.Context(), faults: faults}
+}
+
+// faultyFS injects faults into every Open.
+// It is not a ReadDirFS or ReadFileFS, so that reads of every kind go through Open.
+type faultyFS struct {
+	fs.FS
+	ctx    context.Context
+	faults []Fault
+}
+
+func (f *faultyFS) Open(name string) (fs.File, error) {
+	if err := injectFaults(f.ctx, f.faults); err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return f.FS.Open(name)
+}
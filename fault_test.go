@@ -0,0 +1,183 @@
+package htmplx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+// newFaultTestHandler serves a tree with a page under /orders, its data loaded by a callback counting its calls,
+// and a 503 page to fall back on.
+func newFaultTestHandler(calls *atomic.Int32) *Handler[RequestDataMap] {
+	return newTestHandler(htmplxtest.FS(map[string]string{
+		"body.html.tmpl":        `<main>home</main>`,
+		"orders/body.html.tmpl": `<main>orders: {{.orders}}</main>`,
+		"503.html.tmpl":         `<main>orders are unavailable, try again later</main>`,
+		"static/site.css":       `body { margin: 0; }`,
+	})).WithData(func(r *http.Request) RequestDataMap {
+		calls.Add(1)
+		return RequestDataMap{"orders": 3}
+	})
+}
+
+func isOrders(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/orders")
+}
+
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestFaultLatencyTimesOut(t *testing.T) {
+	var calls atomic.Int32
+	h := newFaultTestHandler(&calls).WithFaults(Fault{
+		Target:  FaultData,
+		Match:   isOrders,
+		Latency: 10 * time.Second,
+	})
+	timeout := http.TimeoutHandler(h, 50*time.Millisecond, "timed out")
+
+	start := time.Now()
+	w := get(timeout, "/orders")
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "timed out" {
+		t.Errorf("got %d %q, want the timeout response", w.Code, w.Body)
+	}
+	// the latency ends early once the request is canceled.
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s, want the fault to end with the request", elapsed)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("data callback called %d times, want none", calls.Load())
+	}
+
+	// requests the fault does not match are unaffected.
+	if w := get(timeout, "/"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "home") {
+		t.Errorf("got %d %q, want the home page", w.Code, w.Body)
+	}
+}
+
+func TestFaultFallsBackToStatusPage(t *testing.T) {
+	var calls atomic.Int32
+	h := newFaultTestHandler(&calls).WithFaults(Fault{
+		Target: FaultData,
+		Match:  isOrders,
+		Err:    &StatusError{Code: http.StatusServiceUnavailable, Err: errors.New("orders service down")},
+	})
+
+	w := get(h, "/orders")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if !strings.Contains(w.Body.String(), "orders are unavailable") {
+		t.Errorf("got body %q, want the 503 page", w.Body)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("data callback called %d times, want none", calls.Load())
+	}
+}
+
+func TestFaultTargets(t *testing.T) {
+	errInjected := errors.New("injected")
+
+	tests := []struct {
+		name       string
+		target     FaultTarget
+		path       string
+		wantStatus int
+	}{
+		{"data", FaultData, "/orders", http.StatusInternalServerError},
+		{"template", FaultTemplate, "/orders", http.StatusInternalServerError},
+		{"fs page", FaultFS, "/orders", http.StatusInternalServerError},
+		{"fs static file", FaultFS, "/static/site.css", http.StatusInternalServerError},
+		{"template static file", FaultTemplate, "/static/site.css", http.StatusOK},
+		{"data and template", FaultData | FaultTemplate, "/orders", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			h := newFaultTestHandler(&calls).WithFaults(Fault{Target: tt.target, Err: errInjected})
+
+			if w := get(h, tt.path); w.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// breaker is a minimal circuit breaker, opening after threshold consecutive server errors.
+type breaker struct {
+	next      http.Handler
+	threshold int
+
+	mu       sync.Mutex
+	failures int
+}
+
+func (b *breaker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	open := b.failures >= b.threshold
+	b.mu.Unlock()
+	if open {
+		http.Error(w, "circuit open", http.StatusServiceUnavailable)
+		return
+	}
+
+	rec := httptest.NewRecorder()
+	b.next.ServeHTTP(rec, r)
+
+	b.mu.Lock()
+	if rec.Code >= http.StatusInternalServerError {
+		b.failures++
+	} else {
+		b.failures = 0
+	}
+	b.mu.Unlock()
+
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+}
+
+func TestFaultOpensCircuitBreaker(t *testing.T) {
+	var failing atomic.Bool
+	var calls atomic.Int32
+	h := newFaultTestHandler(&calls).WithFaults(Fault{
+		Target: FaultData,
+		Match:  func(r *http.Request) bool { return isOrders(r) && failing.Load() },
+		Err:    errors.New("orders service down"),
+	})
+	b := &breaker{next: h, threshold: 3}
+
+	if w := get(b, "/orders"); w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d before the fault", w.Code, http.StatusOK)
+	}
+
+	failing.Store(true)
+	for i := range b.threshold {
+		if w := get(b, "/orders"); w.Code != http.StatusInternalServerError {
+			t.Fatalf("failure %d: got status %d, want %d", i+1, w.Code, http.StatusInternalServerError)
+		}
+	}
+
+	// the breaker is open, so the handler is no longer called, even once the fault is gone.
+	failing.Store(false)
+	w := get(b, "/orders")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "circuit open") {
+		t.Errorf("got %d %q, want the breaker open", w.Code, w.Body)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("data callback called %d times, want once, before the fault", calls.Load())
+	}
+}
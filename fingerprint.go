@@ -0,0 +1,194 @@
+package htmplx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// ErrorKind is the kind of failure a render error is, for grouping errors.
+type ErrorKind string
+
+const (
+	// ErrorKindParse is a template that does not parse.
+	ErrorKindParse ErrorKind = "parse"
+	// ErrorKindExecute is a template that fails to execute, e.g. a field missing from the data or a func that fails.
+	ErrorKindExecute ErrorKind = "execute"
+	// ErrorKindEscape is a template html/template cannot escape safely, or that calls a template not defined.
+	ErrorKindEscape ErrorKind = "escape"
+	// ErrorKindData is a data callback reporting a server error, e.g. its database is down.
+	ErrorKindData ErrorKind = "data"
+	// ErrorKindFile is a file of the tree that cannot be read.
+	ErrorKindFile ErrorKind = "file"
+	// ErrorKindPanic is a panic while rendering.
+	ErrorKindPanic ErrorKind = "panic"
+	// ErrorKindOther is any other error.
+	ErrorKindOther ErrorKind = "other"
+)
+
+// ErrorFingerprint identifies a group of render errors by what failed, so alerts can tell one broken template
+// from a database that is down without parsing log messages.
+// The fingerprint of an error is stable across requests and restarts.
+type ErrorFingerprint struct {
+	// ID is a short hash of the kind and template.
+	ID   string
+	Kind ErrorKind
+	// Template is the name of the template that failed, such as a template file or a template it defines,
+	// or the file that could not be read. It is empty if unknown.
+	Template string
+}
+
+func (fp ErrorFingerprint) String() string {
+	if fp.Template == "" {
+		return fmt.Sprintf("%s %s", fp.ID, fp.Kind)
+	}
+	return fmt.Sprintf("%s %s %s", fp.ID, fp.Kind, fp.Template)
+}
+
+// templateErrorRegexp matches the name of the template at the start of a text/template error message,
+// e.g. body in "template: body:3:7: executing ..." or "html/template:body:3:7: ...", which is where parsing or
+// executing failed.
+var templateErrorRegexp = regexp.MustCompile(`template: ?([^:\s]+):\d+`)
+
+// FingerprintError groups a render error, such as one reported by RenderTemplate, by its kind and template.
+func FingerprintError(err error) ErrorFingerprint {
+	var (
+		fp        ErrorFingerprint
+		panicErr  *panicError
+		statusErr *StatusError
+		execErr   template.ExecError
+		escapeErr *htmltemplate.Error
+		pathErr   *fs.PathError
+	)
+
+	switch {
+	case errors.As(err, &panicErr):
+		fp.Kind = ErrorKindPanic
+	case errors.As(err, &escapeErr):
+		fp.Kind = ErrorKindEscape
+		fp.Template = escapeErr.Name
+	case errors.As(err, &execErr):
+		fp.Kind = ErrorKindExecute
+		fp.Template = execErr.Name
+	case errors.As(err, &statusErr):
+		fp.Kind = ErrorKindData
+	case errors.As(err, &pathErr):
+		fp.Kind = ErrorKindFile
+		fp.Template = pathErr.Path
+	case templateErrorRegexp.MatchString(err.Error()):
+		fp.Kind = ErrorKindParse
+	default:
+		fp.Kind = ErrorKindOther
+	}
+
+	// the message names the template the error is in, rather than the one executed, which includes it.
+	if fp.Kind == ErrorKindParse || fp.Kind == ErrorKindExecute || fp.Kind == ErrorKindEscape {
+		if m := templateErrorRegexp.FindStringSubmatch(err.Error()); m != nil {
+			fp.Template = m[1]
+		}
+	}
+
+	sum := sha256.Sum256([]byte(string(fp.Kind) + "\x00" + fp.Template))
+	fp.ID = hex.EncodeToString(sum[:])[:assetHashLen]
+
+	return fp
+}
+
+// RenderErrorCount counts the render errors of a fingerprint.
+type RenderErrorCount struct {
+	ErrorFingerprint
+	Count    int
+	LastSeen time.Time
+	// LastError is the message of the latest error.
+	LastError string
+}
+
+// RenderErrors counts the errors rendering pages since the handler was created, by fingerprint,
+// the most frequent first, for exporting as metrics.
+// Errors reported by the data callback count once it reports a server error status.
+func (h *Handler[D]) RenderErrors() []RenderErrorCount {
+	h.renderErrors.mu.Lock()
+	counts := make([]RenderErrorCount, 0, len(h.renderErrors.counts))
+	for _, c := range h.renderErrors.counts {
+		counts = append(counts, *c)
+	}
+	h.renderErrors.mu.Unlock()
+
+	slices.SortFunc(counts, func(a, b RenderErrorCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return a.LastSeen.Compare(b.LastSeen)
+	})
+
+	return counts
+}
+
+type renderErrorCounts struct {
+	mu     sync.Mutex
+	counts map[string]*RenderErrorCount
+}
+
+// countRenderError fingerprints a render error, counting it.
+func (h *Handler[D]) countRenderError(err error) ErrorFingerprint {
+	fp := FingerprintError(err)
+
+	h.renderErrors.mu.Lock()
+	defer h.renderErrors.mu.Unlock()
+
+	if h.renderErrors.counts == nil {
+		h.renderErrors.counts = make(map[string]*RenderErrorCount)
+	}
+	c, ok := h.renderErrors.counts[fp.ID]
+	if !ok {
+		c = &RenderErrorCount{ErrorFingerprint: fp}
+		h.renderErrors.counts[fp.ID] = c
+	}
+	c.Count++
+	c.LastSeen = h.now()
+	c.LastError = err.Error()
+
+	return fp
+}
+
+// panicError is a panic recovered while rendering.
+type panicError struct {
+	value any
+	stack []byte
+	// data is set when the data callback panicked, rather than a template func.
+	data bool
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// serveFileRecovered is ServeFile, with a panic reported as an error.
+func (h *Handler[D]) serveFileRecovered(r *http.Request) (out io.ReadCloser, contentType string, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			// aborting a response is meant to be handled by the http server.
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			panicErr, ok := v.(*panicError)
+			if !ok {
+				panicErr = &panicError{value: v, stack: debug.Stack()}
+			}
+			out, contentType, err = nil, "", panicErr
+		}
+	}()
+
+	return h.ServeFile(r)
+}
@@ -0,0 +1,72 @@
+package htmplx
+
+import (
+	"context"
+	"html/template"
+	"net/url"
+	"slices"
+)
+
+type formValuesContextKey struct{}
+
+// ContextWithFormValues returns a copy of ctx carrying the values of a form submission that failed, such as
+// r.PostForm, so that re-rendering the form with it keeps the user's input, through the oldValue, checked and
+// selected template funcs. A handler for the submission re-renders the form's page with a GET request,
+//
+//	get := r.Clone(htmplx.ContextWithFormValues(r.Context(), r.PostForm))
+//	get.Method = http.MethodGet
+//	h.ServeHTTP(w, get)
+//
+// or the form alone, with RenderTemplate.
+func ContextWithFormValues(ctx context.Context, values url.Values) context.Context {
+	return context.WithValue(ctx, formValuesContextKey{}, values)
+}
+
+// FormValuesFromContext returns the form values of ctx set by ContextWithFormValues, if any.
+func FormValuesFromContext(ctx context.Context) (url.Values, bool) {
+	values, ok := ctx.Value(formValuesContextKey{}).(url.Values)
+	return values, ok
+}
+
+// oldValue returns the submitted value of the form field name, if any.
+//
+//	<input name="email" value="{{oldValue "email"}}">
+func (h requestHandler) oldValue(name string) string {
+	return h.form.Get(name)
+}
+
+// checked returns the checked attribute if the form field name was submitted, with value if given,
+// for checkboxes and radio buttons.
+//
+//	<input type="checkbox" name="newsletter" {{checked "newsletter"}}>
+//	<input type="radio" name="plan" value="pro" {{checked "plan" "pro"}}>
+func (h requestHandler) checked(name string, value ...string) template.HTMLAttr {
+	if h.submitted(name, value) {
+		return "checked"
+	}
+	return ""
+}
+
+// selected returns the selected attribute if the form field name was submitted with value, for options.
+//
+//	<option value="nl" {{selected "country" "nl"}}>
+func (h requestHandler) selected(name, value string) template.HTMLAttr {
+	if h.submitted(name, []string{value}) {
+		return "selected"
+	}
+	return ""
+}
+
+// submitted reports whether the form field name was submitted, with the value, if given.
+func (h requestHandler) submitted(name string, value []string) bool {
+	values, ok := h.form[name]
+	if !ok {
+		return false
+	}
+	for _, v := range value {
+		if !slices.Contains(values, v) {
+			return false
+		}
+	}
+	return true
+}
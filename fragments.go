@@ -0,0 +1,138 @@
+package htmplx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxFragments is the number of cached fragments above which expired fragments are dropped.
+const maxFragments = 1024
+
+// fragmentCache holds the rendered output of template regions cached by the cache template func.
+type fragmentCache struct {
+	mu        sync.Mutex
+	fragments map[string]cachedFragment
+}
+
+type cachedFragment struct {
+	name    string
+	html    template.HTML
+	expires time.Time
+}
+
+// fragmentRenderer renders and caches the fragments of a request.
+type fragmentRenderer struct {
+	cache *fragmentCache
+	// key is what the request's fragments vary by, derived by WithCacheKey.
+	key string
+	// layout is the request's templates, once parsed.
+	layout *template.Template
+}
+
+// PurgeFragments removes the named fragments cached by the cache template func, for every cache key,
+// e.g. PurgeFragments("sidebar") once the sidebar's content changes. Every fragment is removed if no name is given.
+func (h *Handler[D]) PurgeFragments(names ...string) {
+	h.fragments.mu.Lock()
+	defer h.fragments.mu.Unlock()
+
+	if len(names) == 0 {
+		h.fragments.fragments = nil
+		return
+	}
+
+	for key, f := range h.fragments.fragments {
+		for _, name := range names {
+			if f.name == name {
+				delete(h.fragments.fragments, key)
+			}
+		}
+	}
+}
+
+// newFragmentRenderer returns the fragment renderer of the request.
+func (h *Handler[D]) newFragmentRenderer(r *http.Request) *fragmentRenderer {
+	f := &fragmentRenderer{cache: &h.fragments}
+	if h.cacheKey != nil {
+		f.key = h.cacheKey(r)
+	}
+	// times are rendered in the request's timezone.
+	if h.timezone != nil {
+		f.key += "\x00" + h.timezoneKey(r)
+	}
+	// hosts have trees, and so fragments, of their own.
+	if len(h.hosts) > 0 {
+		f.key = requestHost(r) + "\x00" + f.key
+	}
+	return f
+}
+
+// cacheFragment renders the template named name with data, caching the output for ttl, a duration such as "5m".
+// Fragments are cached per cache key, as derived by WithCacheKey, so are shared by the pages of every request
+// with the same key, e.g. {{cache "sidebar" "5m" .}} rendering the sidebar once per role or locale every 5 minutes.
+func (h requestHandler) cacheFragment(name string, ttl any, data ...any) (template.HTML, error) {
+	if h.fragments == nil || h.fragments.layout == nil {
+		return "", errors.New("cache: no templates to render")
+	}
+	if len(data) > 1 {
+		return "", fmt.Errorf("cache: too many arguments for %s", name)
+	}
+
+	var d time.Duration
+	switch ttl := ttl.(type) {
+	case string:
+		var err error
+		if d, err = time.ParseDuration(ttl); err != nil {
+			return "", fmt.Errorf("cache: invalid ttl for %s: %w", name, err)
+		}
+	case time.Duration:
+		d = ttl
+	default:
+		return "", fmt.Errorf("cache: invalid ttl for %s: %T is not a duration", name, ttl)
+	}
+
+	c := h.fragments.cache
+	key := name + "\x00" + h.fragments.key
+
+	c.mu.Lock()
+	f, ok := c.fragments[key]
+	c.mu.Unlock()
+	if ok && h.now.Before(f.expires) {
+		h.log.Debug("cached fragment found: " + name)
+		h.metrics.observeLookup("fragment", true)
+		return f.html, nil
+	}
+	h.metrics.observeLookup("fragment", false)
+
+	var dot any
+	if len(data) > 0 {
+		dot = data[0]
+	}
+
+	var buf bytes.Buffer
+	if err := h.fragments.layout.ExecuteTemplate(&buf, name, dot); err != nil {
+		return "", fmt.Errorf("cache: failed to render %s: %w", name, err)
+	}
+	html := template.HTML(buf.String())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fragments == nil {
+		c.fragments = make(map[string]cachedFragment)
+	}
+	if len(c.fragments) >= maxFragments {
+		for k, f := range c.fragments {
+			if !h.now.Before(f.expires) {
+				delete(c.fragments, k)
+			}
+		}
+	}
+	c.fragments[key] = cachedFragment{name: name, html: html, expires: h.now.Add(d)}
+
+	return html, nil
+}
@@ -0,0 +1,403 @@
+package htmplx
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page is the metadata of the page being rendered, from the front matter of its templates and markdown files.
+// Front matter is YAML between --- lines, or TOML between +++ lines, at the very start of a file:
+//
+//	---
+//	title: Hello, World
+//	tags: [go, htmx]
+//	---
+//	<article>...</article>
+//
+// The front matter of every file along the route is merged, deeper files taking precedence.
+// Only the common subset of YAML and TOML is supported: scalars, lists and nested maps.
+// Templates access it as .Page, when the data implements PageData, or with the page template func.
+type Page struct {
+	Title       string
+	Description string
+	Tags        []string
+	Date        time.Time
+	// Params are every front matter value, by key as written, including those above.
+	Params map[string]any
+}
+
+// PageData is implemented by request data that accepts the page's front matter, such as RequestDataMap.
+type PageData interface {
+	SetPage(page Page)
+}
+
+func (d RequestDataMap) SetPage(page Page) {
+	d["Page"] = page
+}
+
+// set sets the value of key from front matter.
+func (p *Page) set(key string, value any) {
+	if p.Params == nil {
+		p.Params = make(map[string]any)
+	}
+	p.Params[key] = value
+
+	switch strings.ToLower(key) {
+	case "title":
+		p.Title = fmt.Sprint(value)
+	case "description":
+		p.Description = fmt.Sprint(value)
+	case "tags":
+		p.Tags = nil
+		if list, ok := value.([]any); ok {
+			for _, v := range list {
+				p.Tags = append(p.Tags, fmt.Sprint(v))
+			}
+		} else if value != nil {
+			p.Tags = []string{fmt.Sprint(value)}
+		}
+	case "date":
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, fmt.Sprint(value)); err == nil {
+				p.Date = t
+				break
+			}
+		}
+	}
+}
+
+// stripFrontMatter removes the front matter from the source b of a template read from filename,
+// adding it to the page's.
+func (h requestHandler) stripFrontMatter(filename string, b []byte) ([]byte, error) {
+	values, content, err := parseFrontMatter(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid front matter in %s: %w", filename, err)
+	}
+	if values == nil || h.page == nil {
+		return content, nil
+	}
+
+	h.log.Debug("front matter found: " + filename)
+	for _, kv := range values {
+		h.page.set(kv.key, kv.value)
+	}
+
+	return content, nil
+}
+
+type frontMatterValue struct {
+	key   string
+	value any
+}
+
+// parseFrontMatter splits b into its front matter, in the order written, and the rest of its content.
+func parseFrontMatter(b []byte) (values []frontMatterValue, content []byte, err error) {
+	for _, delim := range []string{"---", "+++"} {
+		first, rest, ok := cutLine(b)
+		if !ok || strings.TrimRight(string(first), " \t\r") != delim {
+			continue
+		}
+
+		var lines []string
+		for {
+			line, next, ok := cutLine(rest)
+			if !ok {
+				return nil, b, nil
+			}
+			rest = next
+			if strings.TrimRight(string(line), " \t\r") == delim {
+				break
+			}
+			lines = append(lines, strings.TrimRight(string(line), "\r"))
+		}
+
+		if delim == "---" {
+			values, err = parseYAMLFrontMatter(lines)
+		} else {
+			values, err = parseTOMLFrontMatter(lines)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if values == nil {
+			values = []frontMatterValue{}
+		}
+
+		return values, rest, nil
+	}
+
+	return nil, b, nil
+}
+
+// cutLine cuts the first line from b, reporting whether there was a complete line.
+func cutLine(b []byte) (line, rest []byte, ok bool) {
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		if len(b) == 0 {
+			return nil, nil, false
+		}
+		return b, nil, true
+	}
+	return b[:i], b[i+1:], true
+}
+
+// parseYAMLFrontMatter parses YAML mappings of scalars, flow and block sequences, and nested mappings.
+func parseYAMLFrontMatter(lines []string) ([]frontMatterValue, error) {
+	values, _, err := parseYAMLMapping(lines, 0, 0)
+	return values, err
+}
+
+func parseYAMLMapping(lines []string, i, indent int) ([]frontMatterValue, int, error) {
+	var values []frontMatterValue
+
+	for i < len(lines) {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			i++
+			continue
+		}
+
+		lineIndent := len(line) - len(strings.TrimLeft(line, " "))
+		if lineIndent < indent {
+			break
+		}
+		if lineIndent > indent {
+			return nil, i, fmt.Errorf("line %d: unexpected indentation", i+1)
+		}
+
+		key, rawValue, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, i, fmt.Errorf("line %d: expected key: value", i+1)
+		}
+		key = unquoteYAML(strings.TrimSpace(key))
+		rawValue = stripYAMLComment(strings.TrimSpace(rawValue))
+		i++
+
+		if rawValue != "" {
+			values = append(values, frontMatterValue{key, parseYAMLScalarOrFlow(rawValue)})
+			continue
+		}
+
+		// a block sequence or nested mapping, or null, follows.
+		next := i
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if next == len(lines) {
+			values = append(values, frontMatterValue{key, nil})
+			continue
+		}
+
+		nextLine := lines[next]
+		nextIndent := len(nextLine) - len(strings.TrimLeft(nextLine, " "))
+		nextTrimmed := strings.TrimSpace(nextLine)
+
+		switch {
+		case strings.HasPrefix(nextTrimmed, "- ") || nextTrimmed == "-":
+			var list []any
+			for i = next; i < len(lines); i++ {
+				item := strings.TrimSpace(lines[i])
+				if item == "" {
+					continue
+				}
+				if !strings.HasPrefix(item, "-") || len(lines[i])-len(strings.TrimLeft(lines[i], " ")) != nextIndent {
+					break
+				}
+				list = append(list, parseYAMLScalarOrFlow(stripYAMLComment(strings.TrimSpace(item[1:]))))
+			}
+			values = append(values, frontMatterValue{key, list})
+		case nextIndent > indent:
+			nested, end, err := parseYAMLMapping(lines, next, nextIndent)
+			if err != nil {
+				return nil, end, err
+			}
+			m := make(map[string]any, len(nested))
+			for _, kv := range nested {
+				m[kv.key] = kv.value
+			}
+			values = append(values, frontMatterValue{key, m})
+			i = end
+		default:
+			values = append(values, frontMatterValue{key, nil})
+		}
+	}
+
+	return values, i, nil
+}
+
+func stripYAMLComment(s string) string {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, `'`) {
+		return s
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+func parseYAMLScalarOrFlow(s string) any {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		list := []any{}
+		for _, item := range splitFlowItems(s[1 : len(s)-1]) {
+			list = append(list, parseYAMLScalar(item))
+		}
+		return list
+	}
+	return parseYAMLScalar(s)
+}
+
+func parseYAMLScalar(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, `'`) {
+		return unquoteYAML(s)
+	}
+	return parseNumberOrString(s)
+}
+
+func unquoteYAML(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+// parseTOMLFrontMatter parses TOML key/value pairs, single line arrays, and tables.
+func parseTOMLFrontMatter(lines []string) ([]frontMatterValue, error) {
+	var values []frontMatterValue
+	var table map[string]any
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			table = make(map[string]any)
+			values = append(values, frontMatterValue{strings.TrimSpace(trimmed[1 : len(trimmed)-1]), table})
+			continue
+		}
+
+		key, rawValue, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key = unquoteTOML(strings.TrimSpace(key))
+
+		value, err := parseTOMLValue(stripTOMLComment(strings.TrimSpace(rawValue)))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		if table != nil {
+			table[key] = value
+		} else {
+			values = append(values, frontMatterValue{key, value})
+		}
+	}
+
+	return values, nil
+}
+
+func stripTOMLComment(s string) string {
+	inString := byte(0)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString != 0 && c == '\\' && inString == '"':
+			i++
+		case inString != 0 && c == inString:
+			inString = 0
+		case inString == 0 && (c == '"' || c == '\''):
+			inString = c
+		case inString == 0 && c == '#':
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
+
+func parseTOMLValue(s string) (any, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, `'`):
+		return unquoteTOML(s), nil
+	case strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"):
+		list := []any{}
+		for _, item := range splitFlowItems(s[1 : len(s)-1]) {
+			v, err := parseTOMLValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	return parseNumberOrString(s), nil
+}
+
+func unquoteTOML(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// splitFlowItems splits the comma separated items of a single line list, respecting quotes.
+func splitFlowItems(s string) []string {
+	var items []string
+	inString := byte(0)
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString == '"' && c == '\\':
+			i++
+		case inString != 0 && c == inString:
+			inString = 0
+		case inString == 0 && (c == '"' || c == '\''):
+			inString = c
+		case inString == 0 && c == ',':
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+func parseNumberOrString(s string) any {
+	if n, err := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package htmplx
+
+import (
+	"html/template"
+	"time"
+)
+
+// builtinFuncs are the template functions available to every template.
+// These are placeholders, defined so templates parse, replaced by each request's own.
+// Functions passed to WithFuncs take precedence.
+var builtinFuncs = template.FuncMap{
+	"activeClass":    func(string, string, ...string) (string, error) { return "", nil },
+	"alternateLinks": func() (template.HTML, error) { return "", nil },
+	"asset":          func(string) (string, error) { return "", nil },
+	"cache":          func(string, any, ...any) (template.HTML, error) { return "", nil },
+	"canonicalLink":  func() template.HTML { return "" },
+	"checked":        func(string, ...string) template.HTMLAttr { return "" },
+	"hreflangLinks":  func() (template.HTML, error) { return "", nil },
+	"icon":           func(string, ...string) (template.HTML, error) { return "", nil },
+	"iconSprite":     func() template.HTML { return "" },
+	"isActive":       func(string, ...string) (bool, error) { return false, nil },
+	"liveReload":     func() template.HTML { return "" },
+	"localDate":      func(string, any) (string, error) { return "", nil },
+	"localeURL":      func(string) (string, error) { return "", nil },
+	"localTime":      func(any) (time.Time, error) { return time.Time{}, nil },
+	"oldValue":       func(string) string { return "" },
+	"page":           func() Page { return Page{} },
+	"pollURL":        func() string { return "" },
+	"requestID":      func() string { return "" },
+	"route":          func() Route { return Route{} },
+	"selected":       func(string, string) template.HTMLAttr { return "" },
+	"timezone":       func() string { return "" },
+	"url":            func(string, ...any) (string, error) { return "", nil },
+}
+
+// requestFuncs returns the built-in template functions bound to the request being rendered.
+func (h requestHandler) requestFuncs() template.FuncMap {
+	icons := &iconSprite{rh: h}
+
+	return template.FuncMap{
+		"activeClass": h.activeClass,
+		"alternateLinks": func() (template.HTML, error) {
+			return h.alternateLinksFunc()
+		},
+		"asset":         h.asset,
+		"cache":         h.cacheFragment,
+		"canonicalLink": h.canonicalLink,
+		"checked":       h.checked,
+		"hreflangLinks": h.hreflangLinks,
+		"icon":          icons.icon,
+		"iconSprite":    icons.sheet,
+		"isActive":      h.isActive,
+		"liveReload":    h.liveReloadScriptFunc,
+		"localDate":     h.localDate,
+		"localeURL":     h.localeURL,
+		"localTime":     h.localTime,
+		"oldValue":      h.oldValue,
+		"page":          func() Page { return *h.page },
+		"pollURL":       h.pollURL,
+		"requestID":     h.requestIDFunc,
+		"route":         h.currentRoute,
+		"selected":      h.selected,
+		"timezone":      h.timezoneName,
+		"url":           h.reverseURL,
+	}
+}
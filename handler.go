@@ -2,6 +2,7 @@ package htmplx
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
@@ -10,17 +11,27 @@ import (
 	"log/slog"
 	"mime"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 func NewHandler[D RequestData](dir fs.FS) *Handler[D] {
+	tree := newTreeFS(dir)
+	levels := newLogLevels()
 	return &Handler[D]{
-		log: newLogger(),
-		fs:  dir,
+		log:       newLogger(levels, defaultLogFormat()),
+		logLevels: levels,
+		fs:        tree,
+		tree:      tree,
+		now:       time.Now,
 	}
 }
 
@@ -33,90 +44,389 @@ func (h *Handler[D]) WithData(data func(*http.Request) D) *Handler[D] {
 	return h
 }
 
+// WithDataErr sets a data callback that may fail, reporting the http status code to respond with,
+// e.g. 404 when the requested entity does not exist. A status of 0 with a non-nil error means 500,
+// unless the error is ErrNotFound, which means 404.
+// An error status is rendered with the template named after the status, e.g. 404.html.tmpl,
+// in place of the body, found along the route or at the root.
+// WithDataErr takes precedence over WithData.
+func (h *Handler[D]) WithDataErr(data func(*http.Request) (D, int, error)) *Handler[D] {
+	h.dataErr = data
+	return h
+}
+
 func (h *Handler[D]) WithFuncs(funcs func(*http.Request) template.FuncMap) *Handler[D] {
 	h.funcs = funcs
 	return h
 }
 
 type Handler[D RequestData] struct {
-	log   *slog.Logger
-	fs    fs.FS
-	data  func(*http.Request) D
-	funcs func(*http.Request) template.FuncMap
+	log *slog.Logger
+	// logLevels are the levels logged, by default and by route.
+	logLevels *logLevels
+
+	fs fs.FS
+	// tree is fs, the version of the tree currently served.
+	tree      *treeFS
+	data      func(*http.Request) D
+	dataErr   func(*http.Request) (D, int, error)
+	funcs     func(*http.Request) template.FuncMap
+	overrides templateOverrides
+	now       func() time.Time
+	purgers   []Purger
+	pdf       PDFRenderer
+	// pdfBaseURL is the scheme and host pdf renderers resolve relative urls against.
+	pdfBaseURL string
+	assets     assetFingerprints
+	fragments  fragmentCache
+	assetMode  AssetMode
+	// renderErrors counts the errors rendering pages by fingerprint.
+	renderErrors renderErrorCounts
+
+	serviceWorker *ServiceWorker
+	markdown      MarkdownRenderer
+
+	captureSample func(*http.Request) bool
+	captureSave   func(Capture) error
+
+	faults []Fault
+
+	liveReload    *liveReload
+	canonical     CanonicalMode
+	changeTokens  bool
+	slashes       SlashPolicy
+	slashRedirect int
+	cacheKey      CacheKeyFunc
+	hiddenFiles   *HiddenFilePolicy
+	templateExts  []string
+	indexFiles    []string
+	basePath      string
+	hosts         map[string]fs.FS
+	hostAssets    map[string]*assetFingerprints
+	tenants       map[string]*Tenant[D]
+	// directoryListing is set when directories without a body of their own are listed.
+	directoryListing bool
+	timezone         func(*http.Request) *time.Location
+	longPoll         *longPoll
+	sitemap          *Sitemap
+	errorBudget      *errorBudget
+	responseCache    *responseCache
+	sandbox          *Sandbox
+	precedence       DirectoryPrecedence
+	classifier       Classifier
+	warmup           *warmup
+	budgets          budgets
+	accessLog        bool
+	duplicateIDCheck bool
+	metrics          *Metrics
+	caseInsensitive  bool
+	editor           *editor
+	requestIDs       bool
+	errorReporter    func(context.Context, *http.Request, error)
+	precompiled      *Precompiled
+
+	buildSteps []BuildStep
+	// building is held by running build steps, and by requests while build steps are configured.
+	building sync.RWMutex
+
+	defaultFuncs bool
+	// validate is set when Build and Export validate the tree first.
+	validate bool
+
+	// methodHandlers are the handlers of HandleMethod, by route pattern and method.
+	methodHandlers map[string]map[string]http.Handler
+
+	middleware []func(http.Handler) http.Handler
+	chain      http.Handler
 }
 
 func (h *Handler[D]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	// the event stream is long lived, so must not hold up builds.
+	if h.liveReload != nil && r.URL.Path == liveReloadPath {
+		h.serveLiveReload(w, r, h.requestLog(r))
+		return
+	}
+	if h.liveReload != nil && h.serveTemplateErrors(w, r) {
+		return
+	}
+	if h.editor != nil && r.URL.Path == editorOpenPath {
+		h.serveEditorOpen(w, r, h.requestLog(r))
+		return
+	}
+
+	if len(h.buildSteps) > 0 {
+		h.building.RLock()
+		defer h.building.RUnlock()
+	}
+
+	r = h.classify(withNFCPath(r))
+	if h.requestIDs {
+		r = h.withRequestID(w, r)
+	}
+	if h.errorReporter != nil {
+		defer h.reportPanic(r)
+	}
+
+	if h.accessLog || h.metrics != nil {
+		h.serveObserved(w, r, h.serve)
 		return
 	}
+	h.serve(w, r)
+}
+
+// serve serves the request through the middleware, if any.
+func (h *Handler[D]) serve(w http.ResponseWriter, r *http.Request) {
+	if h.chain == nil {
+		h.serveHTTP(w, r)
+		return
+	}
+
+	h.chain.ServeHTTP(w, h.withResolvedRoute(r))
+}
 
-	l := h.log.With("path", r.URL.Path)
+func (h *Handler[D]) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	l := h.requestLog(r)
 	l.Debug("handling request")
 	defer l.Debug("request served")
 
-	out, contentType, err := h.ServeFile(r)
-	if err != nil {
+	if err := checkPath(r.URL); err != nil {
 		l.With("error", err).
-			Error("internal server error")
-		w.WriteHeader(http.StatusInternalServerError)
+			Debug("bad request")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.serveMethod(w, r, l)
+		return
+	}
+
+	if h.redirectToSlashPolicy(w, r) {
+		return
+	}
+
+	if h.serviceWorker != nil && r.URL.Path == h.serviceWorker.Path {
+		h.serveServiceWorker(w, r, l)
+		return
+	}
+
+	if h.sitemap != nil && r.URL.Path == h.sitemap.Path {
+		h.serveSitemap(w, r, l)
+		return
+	}
+
+	if ext := path.Ext(r.URL.Path); ext != "" && !h.isHiddenRequestFile(r, l) {
+		if ext == ".pdf" && h.pdf != nil && h.servePDF(w, r, l) {
+			return
+		}
+
+		rh := h.newRequestHandler(l)
+		rh.fs = h.faultFS(r)
+		rh.assets = h.requestAssets(r)
+		rh.location = h.resolveTimezone(r)
+		filename := strings.Join(splitPath(r.URL.Path), "/")
+		if original, ok := rh.resolveAsset(filename); ok {
+			l.Debug("serving fingerprinted asset: " + original)
+			w.Header().Set("Cache-Control", immutableCacheControl)
+			filename = original
+		}
+		if err := rh.setDirectoryHeaders(w, splitPath(path.Dir(r.URL.Path))); err != nil {
+			rh.internalServerError(w, r, err)
+			return
+		}
+		if h.serveTextTemplate(w, r, rh, filename) {
+			return
+		}
+		if stats := statsFromContext(r.Context()); stats != nil {
+			stats.static = true
+		}
+		rh.serveFile(w, r, filename)
+		return
+	}
+
+	if h.redirectToCanonical(w, r) {
 		return
 	}
+
+	w, r, finishCapture := h.startCapture(w, r)
+	defer finishCapture()
+
+	if route, ok := RouteFromContext(r.Context()); !ok || route.Path != r.URL.Path {
+		r = h.withResolvedRoute(r)
+	}
+	if h.redirectToMatchedCase(w, r) {
+		return
+	}
+	if route, ok := RouteFromContext(r.Context()); ok {
+		if stats := statsFromContext(r.Context()); stats != nil {
+			stats.pattern = route.Pattern()
+		}
+
+		rh := h.newRequestHandler(l)
+		rh.fs = h.requestFS(r)
+		if err := rh.setDirectoryHeaders(w, route.Dirs); err != nil {
+			rh.internalServerError(w, r, err)
+			return
+		}
+		// the representation of a route with a text body depends on the Accept header.
+		if found, _ := rh.hasTextBody(route); found {
+			w.Header().Add("Vary", "Accept")
+		}
+	}
+
+	status := http.StatusOK
+
+	start := time.Now()
+	out, contentType, err := h.serveCachedFile(r)
+	renderTime := time.Since(start)
+	if err != nil {
+		// a client gone, e.g. while long polling, is not an error of the page.
+		if errors.Is(err, context.Canceled) && r.Context().Err() != nil {
+			l.Debug("request canceled")
+			return
+		}
+
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			l := l.With("error", err, "fingerprint", h.countRenderError(err).ID)
+			var panicErr *panicError
+			if errors.As(err, &panicErr) {
+				l = l.With("stack", string(panicErr.stack))
+			}
+			l.Error("internal server error")
+			h.recordRenderError(r, err)
+			h.reportError(r, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		status = statusErr.Code
+		if status >= http.StatusInternalServerError {
+			l.With("error", err, "fingerprint", h.countRenderError(err).ID).
+				Error("data callback failed")
+			h.reportError(r, err)
+		} else {
+			l.With("error", err).
+				Debug("data callback reported error status")
+		}
+	}
 	if out == nil {
-		l.Warn("not found")
-		w.WriteHeader(http.StatusNotFound)
+		if status == http.StatusOK {
+			l.Warn("not found")
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
 		return
 	}
+	defer out.Close()
 
 	w.Header().Set("Content-Type", contentType)
-	io.Copy(w, out)
+	w.WriteHeader(status)
+	size, _ := io.Copy(w, out)
+	h.checkBudget(r, l, size, renderTime)
 }
 
+// ServeFile resolves the request to a static file or rendered templates.
+// out is nil if nothing is found, and must otherwise be closed by the caller.
+// Static files are streamed, not read into memory.
+// If the data callback reports an error status, err is a *StatusError and out, if not nil,
+// is the rendered status template.
 func (h *Handler[D]) ServeFile(r *http.Request) (
-	out io.Reader,
+	out io.ReadCloser,
 	contentType string,
 	err error,
 ) {
 
+	r = h.classify(withNFCPath(r))
 	urlPath := r.URL.Path
 
-	l := h.log.With("path", urlPath)
+	l := h.requestLog(r)
 
-	var pathParts []string
-	if cleanPath := strings.Trim(urlPath, "/"); cleanPath != "" {
-		pathParts = strings.Split(cleanPath, "/")
+	if err := checkPath(r.URL); err != nil {
+		return nil, "", err
 	}
 
-	l = l.With("pathArray", pathParts)
+	l = l.With("pathArray", splitPath(urlPath))
 
-	rh := requestHandler{
-		fs:  h.fs,
-		log: l,
-	}
+	rh := h.newRequestHandler(l)
+	rh.fs = h.faultFS(r)
+	rh.assets = h.requestAssets(r)
+	rh.location = h.resolveTimezone(r)
+	rh.fragments = h.newFragmentRenderer(r)
+	rh.overrideHost = h.overrideHost(r)
 
 	// explicit filenames with file extension should result in a simple file lookup.
 	if ext := path.Ext(urlPath); ext != "" {
-		if ext == ".tmpl" {
+		if rh.isHiddenFile(urlPath) {
 			// templates are not visible
 			return nil, "", nil
 		}
 
 		l.Debug("attempting to serve file")
 
-		return rh.readFileAndContentType(strings.TrimPrefix(urlPath, "/"))
+		filename := strings.Join(splitPath(urlPath), "/")
+		if original, ok := rh.resolveAsset(filename); ok {
+			filename = original
+		}
+
+		out, contentType, err := rh.readFileAndContentType(filename)
+		if out == nil && err == nil {
+			return h.renderTextTemplate(r, rh, filename)
+		}
+		return out, contentType, err
 	}
 
-	// load and compile templates
+	l.Debug("resolving route")
 
-	layout := template.New("layout")
+	route, ok := RouteFromContext(r.Context())
+	if !ok || route.Path != urlPath {
+		if route, err = rh.resolveRoute(urlPath); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, "", nil
+			}
 
-	if h.funcs != nil {
-		layout = layout.Funcs(h.funcs(r))
+			l.With("error", err).
+				Error("internal server error")
+			return nil, "", err
+		}
+	}
+
+	rh.route = route
+
+	// an index document is served as is, in place of the directory's templates.
+	if out, contentType, err := h.readIndexFile(rh, route); err != nil || out != nil {
+		return out, contentType, err
+	}
+	if out, contentType, err := h.renderDirectoryListing(r, rh, route); err != nil || out != nil {
+		return out, contentType, err
+	}
+
+	if c, ok := captureFromContext(r.Context()); ok {
+		if c.replay {
+			rh.now = c.capture.Time
+		} else {
+			c.capture.Pattern = route.Pattern()
+		}
 	}
 
-	layout, err = layout.Parse(layoutTemplateString)
+	if rh.alternates, err = rh.routeAlternates(route); err != nil {
+		l.With("error", err).
+			Error("internal server error")
+		return nil, "", err
+	}
+
+	if view := r.URL.Query().Get("view"); view != "" {
+		if view == printView || slices.ContainsFunc(rh.alternates, func(a alternate) bool { return a.View == view }) {
+			l.Debug("rendering view: " + view)
+			rh.view = view
+		}
+	}
+
+	// load and compile templates
+
+	parseStart := time.Now()
+	layout, err := h.newLayout(r, rh)
 	if err != nil {
-		err = fmt.Errorf("failed to parse layout template: %w", err)
 		l.With("error", err).
 			Error("internal server error")
 		return nil, "", err
@@ -124,8 +434,31 @@ func (h *Handler[D]) ServeFile(r *http.Request) (
 
 	l.Debug("loading templates")
 
-	pathExpSubmatches, err := rh.loadTemplates(layout, pathParts)
+	// a route removed for good, marked by a 410 file, needs no body of its own.
+	gone, err := rh.doesStatusFileExist(route.Dirs, http.StatusGone)
 	if err != nil {
+		l.With("error", err).
+			Error("internal server error")
+		return nil, "", err
+	}
+	// nor does a route rendering a status page in place of its own, such as 405 for a method it is not served.
+	statusPage := statusPageFromContext(r.Context())
+
+	if !gone && statusPage == 0 {
+		rep, body, contentType, err := rh.negotiateBody(r, route)
+		if err != nil {
+			l.With("error", err).
+				Error("internal server error")
+			return nil, "", err
+		}
+		r = r.WithContext(contextWithRepresentation(r.Context(), rep))
+		if body != "" {
+			l.Debug("rendering " + body)
+			return h.executeTextTemplate(r, rh, body, contentType)
+		}
+	}
+
+	if err := rh.loadTemplates(layout, route); err != nil && !((gone || statusPage != 0) && errors.Is(err, fs.ErrNotExist)) {
 		if errors.Is(err, fs.ErrNotExist) {
 			return nil, "", nil
 		}
@@ -134,54 +467,374 @@ func (h *Handler[D]) ServeFile(r *http.Request) (
 			Error("internal server error")
 		return nil, "", err
 	}
+	if stats := statsFromContext(r.Context()); stats != nil {
+		stats.parse += time.Since(parseStart)
+	}
+
+	watch := h.longPoll.watch(r)
 
 	var data D
-	if h.data != nil {
-		data = h.data(r)
-		data.SetPathExpressionSubmatches(pathExpSubmatches)
+	var status int
+	switch {
+	case gone:
+		l.Debug("410 file found")
+		status = http.StatusGone
+	case statusPage != 0:
+		status = statusPage
+	default:
+		data, status, err = h.loadData(r)
+	}
+
+	// a long polling request waits for its data to change, then is rendered even if it has not.
+	polled := false
+	if watch != nil && status == 0 && unchangedSince(r, dataChangeToken(data)) {
+		l.Debug("waiting for changes")
+		if err := h.longPoll.waitForChange(r, watch); err != nil {
+			return nil, "", err
+		}
+		polled = true
+		data, status, err = h.loadData(r)
+	}
+	if status != 0 {
+		statusErr := &StatusError{Code: status, Err: err}
+		l = l.With("status", status, "error", err)
+		l.Debug("data callback reported error status")
+
+		found, err := rh.useStatusTemplate(layout, status)
+		if err != nil {
+			l.With("error", err).
+				Error("internal server error")
+			return nil, "", err
+		}
+		if !found {
+			return nil, "", statusErr
+		}
+
+		if err := rh.completeLayout(layout, route); err != nil {
+			l.With("error", err).
+				Error("internal server error")
+			return nil, "", err
+		}
+
+		if err := h.injectFault(r, FaultTemplate); err != nil {
+			return nil, "", fmt.Errorf("failed to execute status template: %w", err)
+		}
+
+		buf := getRenderBuffer()
+		if err := h.sandbox.execute(buf, func(w io.Writer) error {
+			return layout.Execute(w, data)
+		}); err != nil {
+			putRenderBuffer(buf)
+			l.With("error", err).
+				Error("failed to execute status template")
+			return nil, "", fmt.Errorf("failed to execute status template: %w", err)
+		}
+
+		return &renderedPage{buf}, "text/html", statusErr
+	}
+
+	if h.changeTokens {
+		*rh.changeToken = dataChangeToken(data)
+		if unchangedSince(r, *rh.changeToken) && !polled {
+			l.Debug("unchanged since " + *rh.changeToken)
+			return nil, "", &StatusError{Code: http.StatusNoContent}
+		}
+	}
+
+	if h.hasData(r) {
+		data.SetPathExpressionSubmatches(route.Submatches)
+	}
+
+	if err := rh.completeLayout(layout, route); err != nil {
+		l.With("error", err).
+			Error("internal server error")
+		return nil, "", err
 	}
 
-	var buf bytes.Buffer
+	if pageData, ok := any(data).(PageData); ok && h.hasData(r) {
+		pageData.SetPage(*rh.page)
+	}
+	if routeData, ok := any(data).(RouteData); ok && h.hasData(r) {
+		routeData.SetRoute(*route)
+	}
+
+	if err := h.injectFault(r, FaultTemplate); err != nil {
+		return nil, "", fmt.Errorf("failed to execute template: %w", err)
+	}
 
-	if err := layout.Execute(&buf, data); err != nil {
+	buf := getRenderBuffer()
+	if err := h.sandbox.execute(buf, func(w io.Writer) error {
+		return layout.Execute(w, data)
+	}); err != nil {
+		putRenderBuffer(buf)
 		l.With("error", err).
 			Error("failed to execute template")
 		return nil, "", fmt.Errorf("failed to execute template: %w", err)
 	}
+	if h.duplicateIDCheck {
+		checkDuplicateIDs(l, layout, buf.String())
+	}
+
+	return &renderedPage{buf}, "text/html", nil
+}
 
-	return &buf, "text/html", nil
+func (h *Handler[D]) newRequestHandler(l *slog.Logger) requestHandler {
+	return requestHandler{
+		fs:           h.tree.load(),
+		log:          l,
+		overrides:    &h.overrides,
+		assets:       &h.assets,
+		assetMode:    h.assetMode,
+		markdown:     h.markdown,
+		page:         &Page{},
+		liveReload:   h.liveReload != nil,
+		canonical:    h.canonical,
+		slashes:      h.slashes,
+		hiddenFiles:  h.hiddenFiles,
+		templateExts: h.templateExtensions(),
+		basePath:     h.basePath,
+		changeToken:  new(string),
+		now:          h.now(),
+		configs:      make(map[string]directoryConfig),
+		sandbox:      h.sandbox,
+		precedence:   h.precedence,
+		ignoreCase:   h.caseInsensitive,
+		metrics:      h.metrics,
+		editor:       h.editor,
+		reportError:  h.reportError,
+		precompiled:  h.precompiled,
+	}
 }
 
 type requestHandler struct {
-	fs  fs.FS
-	log *slog.Logger
+	fs        fs.FS
+	log       *slog.Logger
+	overrides *templateOverrides
+	assets    *assetFingerprints
+	assetMode AssetMode
+	markdown  MarkdownRenderer
+	// liveReload is set when pages include the live reload script.
+	liveReload bool
+	canonical  CanonicalMode
+	slashes    SlashPolicy
+	// hiddenFiles is the hidden file policy, the default if nil.
+	hiddenFiles *HiddenFilePolicy
+	// templateExts are the extensions of html templates.
+	templateExts []string
+	// basePath is the path the handler is mounted under, prefixing the urls it generates.
+	basePath string
+	// now is the time the request is rendered at.
+	now time.Time
+	// location is the timezone times are rendered in, if any.
+	location *time.Location
+	// fragments renders the template regions cached by the cache func.
+	fragments *fragmentRenderer
+	// configs caches the directory configs read while handling the request.
+	configs map[string]directoryConfig
+
+	// route is the route being rendered.
+	route *Route
+	// alternates are the alternate representations of the route.
+	alternates []alternate
+	// view is the alternate view being rendered, if any.
+	view string
+	// page is the front matter of the templates loaded.
+	page *Page
+	// changeToken is the hash of the page's data, when change tokens are enabled.
+	changeToken *string
+	// sandbox limits the templates rendered, if set.
+	sandbox *Sandbox
+	// precedence orders the regex directories matching a path segment, the default if nil.
+	precedence DirectoryPrecedence
+	// ignoreCase is set when path segments match directories regardless of case.
+	ignoreCase bool
+	// metrics records the lookups of cached fragments, if set.
+	metrics *Metrics
+	// editor is set when rendered elements are annotated with their source, see WithEditor.
+	editor *editor
+	// requestID is the id of the request being rendered, if request ids are enabled.
+	requestID string
+	// uncacheable is set once the page is unique to the request, if it is rendered for the response cache.
+	uncacheable *atomic.Bool
+	// form are the values of a failed form submission being re-rendered, if any.
+	form url.Values
+	// reportError reports the errors failing requests, see WithErrorReporter.
+	reportError func(*http.Request, error)
+	// precompiled holds the templates parsed ahead of time, if the tree is precompiled.
+	precompiled *Precompiled
+	// overrideHost is the host of the overrides applied, empty for the handler's own tree.
+	overrideHost string
 }
 
-func (h requestHandler) serveFile(w http.ResponseWriter, filename string) {
-	f, contentType, err := h.readFileAndContentType(filename)
+// isHiddenFile reports whether the file is one of htmplx's own, such as a template or the content of a scheduled one,
+// or hidden by the hidden file policy, which is never served.
+func (h requestHandler) isHiddenFile(filename string) bool {
+	ext := path.Ext(filename)
+	_, isTemplate := h.templateExt(filename)
+	return ext == textTemplateExt || isTemplate || isDirectoryConfigFile(filename) || h.markdown != nil && ext == markdownExt ||
+		h.hiddenFiles.hides(filename) || h.isScheduledFile(filename)
+}
+
+// isHiddenRequestFile reports whether the file requested is hidden in the request's tree, see isHiddenFile.
+func (h *Handler[D]) isHiddenRequestFile(r *http.Request, l *slog.Logger) bool {
+	rh := h.newRequestHandler(l)
+	rh.fs = h.requestFS(r)
+	return rh.isHiddenFile(r.URL.Path)
+}
+
+func (h requestHandler) serveFile(w http.ResponseWriter, r *http.Request, filename string) {
+	if isSourceMap(filename) && h.assetMode != DevAssets {
+		h.log.Debug("source maps are only served in development")
+		h.notFound(w)
+		return
+	}
+
+	f, contentEncoding, err := h.openPrecompressedFile(filename, r.Header.Get("Accept-Encoding"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			h.notFound(w)
+		} else {
+			h.internalServerError(w, r, err)
+		}
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
 	if err != nil {
-		h.internalServerError(w, err)
+		h.internalServerError(w, r, fmt.Errorf("failed to stat %s: %w", filename, err))
 		return
 	}
-	if f == nil {
+	if info.IsDir() {
 		h.notFound(w)
 		return
 	}
 
+	sniffBuf := getSniffBuffer()
+	defer putSniffBuffer(sniffBuf)
+
+	contentType, sniffed, err := h.staticContentType(filename, f, contentEncoding != "", *sniffBuf)
+	if err != nil {
+		h.internalServerError(w, r, err)
+		return
+	}
+
 	h.log = h.log.With("Content-Type", contentType)
 	h.log.Debug("setting Content-Type header")
 	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept-Encoding")
+	if contentEncoding != "" {
+		h.log.Debug("setting Content-Encoding header: " + contentEncoding)
+		w.Header().Set("Content-Encoding", contentEncoding)
+	}
+
+	// seekable files support range and conditional requests.
+	if rs, ok := f.(io.ReadSeeker); ok {
+		h.log.Debug("serving seekable file")
+		http.ServeContent(w, r, path.Base(filename), info.ModTime(), rs)
+		h.log.Debug("file served")
+		return
+	}
+
 	h.log.Debug("writing file to response body")
+	w.Header().Set("Accept-Ranges", "none")
 
-	io.Copy(w, f)
+	io.Copy(w, io.MultiReader(bytes.NewReader(sniffed), f))
 	h.log.Debug("file served")
 }
 
+// openPrecompressedFile opens a precompressed sidecar of filename (e.g. app.js.br)
+// when one exists and the client accepts its encoding, falling back to filename itself.
+func (h requestHandler) openPrecompressedFile(filename, acceptEncoding string) (f fs.File, contentEncoding string, err error) {
+	for _, enc := range precompressedEncodings {
+		if !acceptsEncoding(acceptEncoding, enc.coding) {
+			continue
+		}
+
+		f, err := h.fs.Open(filename + enc.ext)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, "", fmt.Errorf("failed to look up %s: %w", filename+enc.ext, err)
+		}
+
+		h.log.Debug("serving precompressed file: " + filename + enc.ext)
+
+		return f, enc.coding, nil
+	}
+
+	f, err = h.fs.Open(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "", err
+		}
+		return nil, "", fmt.Errorf("failed to look up %s: %w", filename, err)
+	}
+
+	return f, "", nil
+}
+
+// staticContentTypes are the content types of extensions mime does not know,
+// or whose type depends on the system's mime.types.
+var staticContentTypes = map[string]string{
+	".map":  "application/json",
+	".wasm": "application/wasm",
+}
+
+// staticContentType determines the Content-Type of filename, sniffing the content of f if needed.
+// The content type of a precompressed file is that of the uncompressed original.
+// Bytes consumed from an unseekable f while sniffing are returned in sniffed, a slice of buf.
+func (h requestHandler) staticContentType(filename string, f fs.File, precompressed bool, buf []byte) (contentType string, sniffed []byte, err error) {
+	h.log.Debug("sniffing content type")
+	if contentType = staticContentTypes[path.Ext(filename)]; contentType != "" {
+		h.log.Debug("content type by file extension: " + contentType)
+		return contentType, nil, nil
+	}
+	if contentType = mime.TypeByExtension(path.Ext(filename)); contentType != "" {
+		h.log.Debug("content type by file extension: " + contentType)
+		return contentType, nil, nil
+	}
+
+	if precompressed {
+		orig, err := h.fs.Open(filename)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return "application/octet-stream", nil, nil
+			}
+			return "", nil, fmt.Errorf("failed to look up %s: %w", filename, err)
+		}
+		defer orig.Close()
+
+		if contentType, _, err = h.sniffContentType(orig, buf); err != nil {
+			return "", nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+		}
+		return contentType, nil, nil
+	}
+
+	if contentType, sniffed, err = h.sniffContentType(f, buf); err != nil {
+		return "", nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+
+	if s, ok := f.(io.Seeker); ok {
+		if _, err := s.Seek(0, io.SeekStart); err != nil {
+			return "", nil, fmt.Errorf("failed to rewind file %s: %w", filename, err)
+		}
+		sniffed = nil
+	}
+
+	return contentType, sniffed, nil
+}
+
 func (h requestHandler) readFileAndContentType(filename string) (
-	out io.Reader,
+	out io.ReadCloser,
 	contentType string,
 	err error,
 ) {
+	if isSourceMap(filename) && h.assetMode != DevAssets {
+		return nil, "", nil
+	}
+
 	f, err := h.fs.Open(filename)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
@@ -189,44 +842,39 @@ func (h requestHandler) readFileAndContentType(filename string) (
 		} else {
 			return nil, "", fmt.Errorf("failed to look up %s: %w", filename, err)
 		}
-		return
 	}
-	defer f.Close()
-
-	h.log.Debug("sniffing content type")
-	contentType = mime.TypeByExtension(path.Ext(filename))
-	h.log.Debug("content type by file extension: " + contentType)
 
-	var bytesRead []byte
-
-	if contentType == "" {
-		contentType, bytesRead, err = h.sniffContentType(f)
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to read file %s: %w", filename, err)
-		}
+	if info, err := f.Stat(); err != nil {
+		f.Close()
+		return nil, "", fmt.Errorf("failed to stat %s: %w", filename, err)
+	} else if info.IsDir() {
+		f.Close()
+		return nil, "", nil
 	}
 
-	var buf bytes.Buffer
-
-	if len(bytesRead) > 0 {
-		if _, err := buf.Write(bytesRead); err != nil {
-			return nil, contentType, fmt.Errorf("unexpected error while writing buffer: %w", err)
-		}
-	}
+	buf := getSniffBuffer()
 
-	if _, err := io.Copy(&buf, f); err != nil {
-		return nil, contentType, fmt.Errorf("unexpected error while writing buffer: %w", err)
+	contentType, sniffed, err := h.staticContentType(filename, f, false, *buf)
+	if err != nil {
+		f.Close()
+		putSniffBuffer(buf)
+		return nil, "", err
 	}
 
-	return &buf, contentType, nil
+	return &streamedFile{
+		Reader: io.MultiReader(bytes.NewReader(sniffed), f),
+		f:      f,
+		buf:    buf,
+	}, contentType, nil
 }
 
-func (h requestHandler) sniffContentType(f fs.File) (contentType string, bytesRead []byte, err error) {
-	p := make([]byte, 512)
+// sniffContentType reads up to sniffLen bytes of f into p to detect its content type.
+func (h requestHandler) sniffContentType(f fs.File, p []byte) (contentType string, bytesRead []byte, err error) {
+	p = p[:sniffLen]
 	numBytesRead := 0
 
 	for {
-		h.log.Debug("reading bytes up to " + fmt.Sprint(512-numBytesRead) + " bytes")
+		h.log.Debug("reading bytes up to " + fmt.Sprint(len(p)-numBytesRead) + " bytes")
 		var n int
 		n, err = f.Read(p[numBytesRead:])
 		numBytesRead += n
@@ -248,161 +896,190 @@ func (h requestHandler) sniffContentType(f fs.File) (contentType string, bytesRe
 	return contentType, bytesRead, err
 }
 
-func (h requestHandler) loadTemplates(layout *template.Template, path []string) (pathExpSubmatches []DirEntryWithSubmatches, err error) {
+// rootTemplateNames are the templates loaded from the root directory, the layout's blocks.
+var rootTemplateNames = []string{"head", "body", "title", "meta"}
+
+func (h requestHandler) loadTemplates(layout *template.Template, route *Route) error {
 	var bodyFound bool
 
-	h.log.Debug("loading head.html.tmpl")
-	if _, err := h.loadTemplate(layout, "head", "head.html.tmpl"); err != nil {
-		if !errors.Is(err, fs.ErrNotExist) {
-			return nil, err
+	if err := h.loadComponents(layout); err != nil {
+		return err
+	}
+
+	for _, name := range rootTemplateNames {
+		h.log.Debug("loading " + name + " at root")
+		if found, err := h.loadRootTemplate(layout, name, name); err != nil {
+			return err
+		} else if found && name == "body" {
+			bodyFound = true
 		}
-		h.log.Debug("head.html.tmpl not found at root")
 	}
 
-	h.log.Debug("loading body.html.tmpl")
-	if _, err := h.loadTemplate(layout, "body", "body.html.tmpl"); err != nil {
-		if !errors.Is(err, fs.ErrNotExist) {
-			return nil, err
+	if h.view != "" {
+		for _, name := range rootTemplateNames {
+			h.log.Debug("loading " + name + "." + h.view + " at root")
+			if found, err := h.loadRootTemplate(layout, name, name+"."+h.view); err != nil {
+				return err
+			} else if found && name == "body" {
+				bodyFound = true
+			}
 		}
-		h.log.Debug("body.html.tmpl not found at root")
-	} else {
+	}
+
+	if scheduled, err := h.applySchedule(layout, ""); err != nil {
+		return err
+	} else if _, ok := scheduled["body"]; ok {
 		bodyFound = true
 	}
 
-	if len(path) > 0 {
+	if overridden, err := h.applyOverrides(layout, ""); err != nil {
+		return err
+	} else if _, ok := overridden["body"]; ok {
+		bodyFound = true
+	}
+
+	if len(route.Dirs) > 0 {
 		h.log.Debug("loading templates under path")
-		var err error
-		if bodyFound, pathExpSubmatches, err = h.loadTemplatesAlongPath(layout, path); err != nil {
-			return nil, err
+		subpathBodyFound, err := h.loadTemplatesAlongPath(layout, route.Dirs)
+		if err != nil {
+			return err
 		}
+		bodyFound = bodyFound || subpathBodyFound
 	}
 
 	if !bodyFound {
-		return nil, fmt.Errorf("%w: no body defined", fs.ErrNotExist)
+		return fmt.Errorf("%w: no body defined", fs.ErrNotExist)
 	}
 
-	return pathExpSubmatches, nil
+	return nil
 }
 
-func (h requestHandler) loadTemplate(t *template.Template, name, path string) (*template.Template, error) {
-	b, err := h.readFile(path)
-	if err != nil {
-		return nil, err
+// templateFuncs returns the funcs of the request's templates, in order of precedence, the last taking precedence:
+// the built-in funcs, DefaultFuncs, those of WithFuncs, those of the request's tenant and those the sandbox does not allow.
+func (h *Handler[D]) templateFuncs(r *http.Request, rh requestHandler) []template.FuncMap {
+	funcMaps := []template.FuncMap{rh.requestFuncs()}
+	if h.defaultFuncs {
+		funcMaps = append(funcMaps, DefaultFuncs())
 	}
-
-	return t.New(name).Parse(string(b))
+	if h.funcs != nil {
+		funcMaps = append(funcMaps, h.funcs(r))
+	}
+	if funcs := h.tenantFuncs(r); funcs != nil {
+		funcMaps = append(funcMaps, funcs)
+	}
+	if h.sandbox != nil {
+		funcMaps = append(funcMaps, h.sandbox.restrictFuncs(funcMaps...))
+	}
+	return funcMaps
 }
 
-func (h requestHandler) readFile(path string) ([]byte, error) {
-	f, err := h.fs.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to look up %s: %w", path, err)
+// newLayout returns the page layout, with the request's template funcs, for the request's templates to be loaded into.
+func (h *Handler[D]) newLayout(r *http.Request, rh requestHandler) (*template.Template, error) {
+	rh.form, _ = FormValuesFromContext(r.Context())
+	rh.requestID, _ = RequestIDFromContext(r.Context())
+	rh.uncacheable, _ = r.Context().Value(uncacheableContextKey{}).(*atomic.Bool)
+
+	layout := template.New("layout")
+	for _, funcs := range h.templateFuncs(r, rh) {
+		layout = layout.Funcs(funcs)
 	}
-	defer f.Close()
 
-	b, err := io.ReadAll(f)
+	layout, err := layout.Parse(layoutTemplateString)
 	if err != nil {
-		return nil, fmt.Errorf("failed to look up %s: %w", path, err)
+		return nil, fmt.Errorf("failed to parse layout template: %w", err)
+	}
+	if rh.fragments != nil {
+		rh.fragments.layout = layout
 	}
 
-	return b, nil
-}
-
-func (h requestHandler) loadTemplatesAlongPath(
-	layout *template.Template,
-	path []string,
-) (
-	bodyFound bool,
-	pathExpSubmatches []DirEntryWithSubmatches,
-	err error,
-) {
-	return h.loadTemplatesAlongPathStartingAtIndex(layout, path, 0)
+	return layout, nil
 }
 
-func (h requestHandler) loadTemplatesAlongPathStartingAtIndex(
-	layout *template.Template,
-	path []string,
-	pathIndex int,
-) (
-	bodyFound bool,
-	pathExpSubmatches []DirEntryWithSubmatches,
-	err error,
-) {
-	path = slices.Clone(path)
-	h.log = h.log.With("pathIndex", pathIndex)
-
-	if pathIndex >= len(path) {
-		// at the last directory in the path.
-		// handle special cases:
-		// - 404 file means return a 404 Not Found response
-
-		h.log.Debug("checking for 404 file")
-		exists, err := h.does404FileExist(path)
-		if err == nil && exists {
-			err = fmt.Errorf("404 file found: %w", fs.ErrNotExist)
+// loadRootTemplate loads the template named name from basename.html.tmpl, or another template extension, at the root,
+// or from basename.md if there is no such template and markdown is enabled.
+func (h requestHandler) loadRootTemplate(layout *template.Template, name, basename string) (found bool, err error) {
+	for _, ext := range h.templateExts {
+		if _, err := h.loadTemplate(layout, name, basename+ext); err == nil {
+			return true, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return false, err
 		}
+	}
 
-		return false, nil, err
+	if h.markdown == nil {
+		return false, nil
 	}
 
-	currentDir := slices.Clone(path[:pathIndex])
+	b, err := h.readFile(basename + markdownExt)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if b, err = h.stripFrontMatter(basename+markdownExt, b); err != nil {
+		return false, err
+	}
+	if b, err = h.markdownTemplate(b); err != nil {
+		return false, fmt.Errorf("failed to render %s: %w", basename+markdownExt, err)
+	}
+	if _, err := layout.New(name).Parse(string(b)); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", basename+markdownExt, err)
+	}
 
-	dir := path[pathIndex]
+	return true, nil
+}
 
-	// immediate fail urls with regex path parts so as to not expose regex paths directly
-	if isRegexPathPart(dir) {
-		h.log.Debug("path includes regex: " + dir)
-		return false, nil, fmt.Errorf("%w: path includes regex: %s", fs.ErrNotExist, dir)
+func (h requestHandler) loadTemplate(t *template.Template, name, path string) (*template.Template, error) {
+	b, err := h.readFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	// find a directory by exact name or one that is a regex matching
-	var dirExpSubmatches DirEntryWithSubmatches
-
-	if info, err := fs.Stat(h.fs, strings.Join(append(currentDir, dir), "/")); err != nil {
-		if !errors.Is(err, fs.ErrNotExist) {
-			return false, nil, fmt.Errorf("failed to check directory %s: %w", dir, err)
-		}
+	b = h.annotateSource(path, b)
+	if b, err = h.stripFrontMatter(path, b); err != nil {
+		return nil, err
+	}
 
-		h.log.Debug("looking up matching regex directories")
-		matchingDirs, err := h.findMatchingRegexDirs(strings.Join(currentDir, "/"), dir)
-		if err != nil {
-			return false, nil, err
-		}
-		if len(matchingDirs) == 0 {
-			return false, nil, fmt.Errorf("directory not found: %s: %w", dir, fs.ErrNotExist)
-		}
+	return h.parseTemplate(t, name, b)
+}
 
-		dirExpSubmatches = matchingDirs[0]
-		for _, d := range matchingDirs[1:] {
-			if len(d.Submatches) > len(dirExpSubmatches.Submatches) {
-				dirExpSubmatches = d
-			}
-		}
+func (h requestHandler) readFile(path string) ([]byte, error) {
+	f, err := h.fs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", path, err)
+	}
+	defer f.Close()
 
-		h.log.Debug("matching regex directory found: " + dirExpSubmatches.File.Name())
+	b, err := io.ReadAll(h.sandbox.readLimit(f, path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", path, err)
+	}
 
-		dir = dirExpSubmatches.File.Name()
-		path = slices.Clone(path)
-		path[pathIndex] = dir
+	return b, nil
+}
 
-		h.log = h.log.With("pathRegexMatch", dir)
-	} else if !info.IsDir() {
-		return false, nil, fmt.Errorf("%s is not a directory: %w", dir, fs.ErrNotExist)
-	} else {
-		dirExpSubmatches = DirEntryWithSubmatches{
-			File: info,
+// loadTemplatesAlongPath loads the templates in each of the resolved directories,
+// those in deeper directories overwriting those in their parents.
+func (h requestHandler) loadTemplatesAlongPath(layout *template.Template, dirs []string) (bodyFound bool, err error) {
+	for i := range dirs {
+		dirBodyFound, err := h.loadTemplatesInDir(layout, strings.Join(dirs[:i+1], "/"))
+		if err != nil {
+			return false, err
 		}
+		bodyFound = bodyFound || dirBodyFound
 	}
 
-	// gather and compile all template files in the directory
+	return bodyFound, nil
+}
 
-	const htmlTmplExt = ".html.tmpl"
+func (h requestHandler) loadTemplatesInDir(layout *template.Template, fullDirName string) (bodyFound bool, err error) {
+	// gather and compile all template files in the directory
 
-	h.log.Debug("walking directory " + dir)
+	h.log.Debug("walking directory " + fullDirName)
 
 	var templateFilesFound []string
 
-	fullDirName := strings.Join(append(currentDir, dir), "/")
 	if err := fs.WalkDir(h.fs, fullDirName, func(path string, e fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -417,36 +1094,59 @@ func (h requestHandler) loadTemplatesAlongPathStartingAtIndex(
 		}
 
 		name := e.Name()
-		if strings.HasSuffix(name, htmlTmplExt) {
+		if _, ok := h.templateExt(name); ok {
 			h.log.Debug("template file found: " + name)
 			templateFilesFound = append(templateFilesFound, name)
+		} else if h.markdown != nil && strings.HasSuffix(name, markdownExt) {
+			h.log.Debug("markdown file found: " + name)
+			templateFilesFound = append(templateFilesFound, name)
 		}
 
 		return nil
 	}); err != nil {
-		return false, []DirEntryWithSubmatches{dirExpSubmatches}, fmt.Errorf("failed to look up entries in %s: %w", dir, err)
+		return false, fmt.Errorf("failed to look up entries in %s: %w", fullDirName, err)
 	}
 
 	h.log.Debug("templates found: [" + strings.Join(templateFilesFound, ", ") + "]")
 
+	// files of lower precedence come first, so those of higher precedence replace them,
+	// e.g. body.html.tmpl replacing body.md.
+	slices.SortStableFunc(templateFilesFound, func(a, b string) int {
+		return h.templateRank(b) - h.templateRank(a)
+	})
+
 	rawTemplatesByName := make(map[string][]byte, len(templateFilesFound))
 	for _, filename := range templateFilesFound {
-		templateName := strings.TrimSuffix(filename, htmlTmplExt)
+		ext, ok := h.templateExt(filename)
+		if !ok {
+			ext = markdownExt
+		}
+
+		templateName := strings.TrimSuffix(filename, ext)
 		if templateName == "" {
-			return false, []DirEntryWithSubmatches{dirExpSubmatches}, fmt.Errorf("template file found without name: %s", htmlTmplExt)
+			return false, fmt.Errorf("template file found without name: %s", ext)
+		}
+		if isDirectoryLayout(templateName) {
+			// directory layouts wrap the body, see applyLayouts.
+			continue
 		}
 
-		relativeFilename := strings.Join(append(currentDir, dir, filename), "/")
+		relativeFilename := fullDirName + "/" + filename
 
-		f, err := h.fs.Open(relativeFilename)
+		b, err := h.readFile(relativeFilename)
 		if err != nil {
-			return false, []DirEntryWithSubmatches{dirExpSubmatches}, fmt.Errorf("failed to open %s: %w", relativeFilename, err)
+			return false, fmt.Errorf("failed to read %s: %w", relativeFilename, err)
 		}
-		defer f.Close()
-
-		b, err := io.ReadAll(f)
-		if err != nil {
-			return false, []DirEntryWithSubmatches{dirExpSubmatches}, fmt.Errorf("failed to read %s: %w", relativeFilename, err)
+		if ext != markdownExt {
+			b = h.annotateSource(relativeFilename, b)
+		}
+		if b, err = h.stripFrontMatter(relativeFilename, b); err != nil {
+			return false, err
+		}
+		if ext == markdownExt {
+			if b, err = h.markdownTemplate(b); err != nil {
+				return false, fmt.Errorf("failed to render %s: %w", relativeFilename, err)
+			}
 		}
 
 		rawTemplatesByName[templateName] = b
@@ -454,36 +1154,74 @@ func (h requestHandler) loadTemplatesAlongPathStartingAtIndex(
 
 	h.log.Debug("overwriting templates with templates in child directories")
 	for name, b := range rawTemplatesByName {
-		if _, err := layout.New(name).Parse(string(b)); err != nil {
-			return false, []DirEntryWithSubmatches{dirExpSubmatches}, fmt.Errorf("failed to parse template %s: %w", name, err)
+		if _, err := h.parseTemplate(layout, name, b); err != nil {
+			return false, fmt.Errorf("failed to parse template %s: %w", name, err)
 		}
 	}
 
-	// continue gather templates in the remaining path.
+	if h.view != "" {
+		// templates of the view being rendered replace their default counterparts.
+		viewSuffix := "." + h.view
+		for name, b := range rawTemplatesByName {
+			baseName, ok := strings.CutSuffix(name, viewSuffix)
+			if !ok || baseName == "" {
+				continue
+			}
+
+			h.log.Debug("using " + name + " in place of " + baseName)
+			if _, err := h.parseTemplate(layout, baseName, b); err != nil {
+				return false, fmt.Errorf("failed to parse template %s: %w", name, err)
+			}
+			rawTemplatesByName[baseName] = b
+		}
+	}
 
-	var subpathExpSubmatches []DirEntryWithSubmatches
-	bodyFound, subpathExpSubmatches, err = h.loadTemplatesAlongPathStartingAtIndex(layout, path, pathIndex+1)
-	pathExpSubmatches = append([]DirEntryWithSubmatches{dirExpSubmatches}, subpathExpSubmatches...)
+	scheduled, err := h.applySchedule(layout, fullDirName)
 	if err != nil {
-		return bodyFound, pathExpSubmatches, err
+		return false, err
+	}
+	for name, content := range scheduled {
+		rawTemplatesByName[name] = []byte(content)
 	}
 
-	if !bodyFound {
-		_, bodyFound = rawTemplatesByName["body"]
+	overridden, err := h.applyOverrides(layout, fullDirName)
+	if err != nil {
+		return false, err
+	}
+	for name, content := range overridden {
+		rawTemplatesByName[name] = []byte(content)
 	}
 
-	return bodyFound, pathExpSubmatches, nil
+	_, bodyFound = rawTemplatesByName["body"]
+
+	return bodyFound, nil
+}
+
+// applyOverrides parses any template overrides set for dir into layout.
+func (h requestHandler) applyOverrides(layout *template.Template, dir string) (map[string]string, error) {
+	overridden := h.overrides.forDir(h.overrideHost, dir)
+	for name, content := range overridden {
+		h.log.Debug("applying template override: " + name)
+		if _, err := layout.New(name).Parse(content); err != nil {
+			return nil, fmt.Errorf("failed to parse template override %s: %w", name, err)
+		}
+	}
+	return overridden, nil
 }
 
-func (h requestHandler) does404FileExist(dir []string) (bool, error) {
-	_, err := fs.Stat(h.fs, strings.Join(append(dir, "404"), "/"))
+// doesStatusFileExist reports whether the directory has a file named after the status, e.g. 404,
+// marking the route as responding with that status.
+func (h requestHandler) doesStatusFileExist(dir []string, status int) (bool, error) {
+	name := strconv.Itoa(status)
+
+	_, err := fs.Stat(h.fs, strings.Join(append(slices.Clip(dir), name), "/"))
 	if err == nil {
 		return true, nil
 	}
 	if errors.Is(err, fs.ErrNotExist) {
 		return false, nil
 	}
-	return false, fmt.Errorf("failed to look up 404 file: %w", err)
+	return false, fmt.Errorf("failed to look up %s file: %w", name, err)
 }
 
 func isRegexPathPart(part string) bool {
@@ -494,6 +1232,41 @@ func trimRegexPathPart(part string) string {
 	return part[1 : len(part)-1]
 }
 
+// catchAllPrefix begins the name of a catch-all directory, e.g. {...rest}.
+const catchAllPrefix = "{..."
+
+// isCatchAllPathPart reports whether part is the name of a catch-all directory, e.g. {...rest},
+// which matches the remainder of a path rather than a single segment.
+func isCatchAllPathPart(part string) bool {
+	return isRegexPathPart(part) && strings.HasPrefix(part, catchAllPrefix)
+}
+
+// regexPathParts caches the compiled expressions of regex directory names, by name.
+// An expression depends only on the name, so is compiled once whatever tree it is found in,
+// and names come from trees rather than requests, so the cache stays small.
+var regexPathParts sync.Map
+
+// compileRegexPathPart returns the expression of a regex directory name, matching a whole path segment,
+// or, for a catch-all directory, the remainder of the path, captured under its name.
+func compileRegexPathPart(name string) (*regexp.Regexp, error) {
+	if re, ok := regexPathParts.Load(name); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	exp := trimRegexPathPart(name)
+	if isCatchAllPathPart(name) {
+		exp = "(?P<" + strings.TrimPrefix(name[:len(name)-1], catchAllPrefix) + ">.+)"
+	}
+
+	re, err := regexp.Compile("^" + exp + "$")
+	if err != nil {
+		return nil, err
+	}
+	regexPathParts.Store(name, re)
+
+	return re, nil
+}
+
 func (h requestHandler) findMatchingRegexDirs(parentDir, exp string) ([]DirEntryWithSubmatches, error) {
 	h.log.Debug("listing directory entries under " + parentDir)
 	entries, err := h.listDirEntries(parentDir)
@@ -509,21 +1282,18 @@ func (h requestHandler) findMatchingRegexDirs(parentDir, exp string) ([]DirEntry
 	for _, e := range entries {
 		name := e.Name()
 
-		if !isRegexPathPart(name) || !e.IsDir() {
+		if !isRegexPathPart(name) || isCatchAllPathPart(name) || !e.IsDir() {
 			continue
 		}
 
 		l := h.log.With("entry", e.Name())
 		l.Debug("checking regex directory entry")
 
-		rawRegex := "^" + trimRegexPathPart(name) + "$"
-		l = l.With("expression", rawRegex)
-		l.Debug("compiling expression")
-
-		re, err := regexp.Compile(rawRegex)
+		re, err := compileRegexPathPart(name)
 		if err != nil {
 			return nil, fmt.Errorf("invalid regex directory name: %w", err)
 		}
+		l = l.With("expression", re.String())
 
 		matches := re.FindStringSubmatch(exp)
 		if matches != nil {
@@ -591,7 +1361,8 @@ func (h requestHandler) notFound(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNotFound)
 }
 
-func (h requestHandler) internalServerError(w http.ResponseWriter, err error) {
+func (h requestHandler) internalServerError(w http.ResponseWriter, r *http.Request, err error) {
 	h.log.With("error", err).Error("internal server error")
+	h.reportError(r, err)
 	w.WriteHeader(http.StatusInternalServerError)
 }
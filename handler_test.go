@@ -0,0 +1,69 @@
+package htmplx
+
+import (
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+// newTestHandler serves fsys without logging.
+func newTestHandler(fsys fs.FS) *Handler[RequestDataMap] {
+	return NewHandler[RequestDataMap](fsys).
+		WithLogHandlers(slog.NewTextHandler(io.Discard, nil))
+}
+
+// BenchmarkRegexPathParts measures matching regex directories with their expressions cached,
+// against compiling them for every lookup, as before regexPathParts.
+func BenchmarkRegexPathParts(b *testing.B) {
+	names := []string{
+		"{[a-z]+}",
+		"{(?P<id>[0-9]+)}",
+		"{(?P<slug>[a-z0-9-]+)}",
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			for _, name := range names {
+				if _, err := compileRegexPathPart(name); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			for _, name := range names {
+				if _, err := regexp.Compile("^" + trimRegexPathPart(name) + "$"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("request", func(b *testing.B) {
+		h := newTestHandler(htmplxtest.FS(map[string]string{
+			"body.html.tmpl":                                 `home`,
+			"posts/{(?P<id>[0-9]+)}/body.html.tmpl":          `post`,
+			"posts/{(?P<id>[0-9]+)}/{[a-z]+}/body.html.tmpl": `section`,
+		}))
+		r := httptest.NewRequest(http.MethodGet, "/posts/42/comments", nil)
+
+		b.ReportAllocs()
+		for range b.N {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				b.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+			}
+		}
+	})
+}
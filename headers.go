@@ -0,0 +1,51 @@
+package htmplx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// directoryHeaders returns the headers configured in the _config.json of each of dirs, from the root down,
+// deeper directories overriding their parents. For example, to enable cross-origin isolation,
+// required by WebAssembly threads:
+//
+//	{
+//		"headers": {
+//			"Cross-Origin-Opener-Policy": "same-origin",
+//			"Cross-Origin-Embedder-Policy": "require-corp"
+//		}
+//	}
+func (h requestHandler) directoryHeaders(dirs []string) (http.Header, error) {
+	header := make(http.Header)
+
+	for i := 0; i <= len(dirs); i++ {
+		cfg, err := h.readDirectoryConfig(strings.Join(dirs[:i], "/"))
+		if err != nil {
+			return nil, err
+		}
+
+		for name, value := range cfg.Headers {
+			if value == "" {
+				header.Del(name)
+			} else {
+				header.Set(name, value)
+			}
+		}
+	}
+
+	return header, nil
+}
+
+// setDirectoryHeaders sets the headers configured for dirs on the response.
+func (h requestHandler) setDirectoryHeaders(w http.ResponseWriter, dirs []string) error {
+	header, err := h.directoryHeaders(dirs)
+	if err != nil {
+		return err
+	}
+
+	for name, values := range header {
+		w.Header()[name] = values
+	}
+
+	return nil
+}
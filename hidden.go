@@ -0,0 +1,56 @@
+package htmplx
+
+import (
+	"path"
+)
+
+// HiddenFilePolicy decides which files are never served, nor routed to, beyond htmplx's own,
+// so that secrets such as .env or .git/config, or data files such as _data.json, cannot leak.
+// Patterns are as of path.Match, matched against each segment of a path.
+type HiddenFilePolicy struct {
+	// Deny are the patterns of hidden files and directories.
+	Deny []string
+	// Allow are the patterns of files and directories served despite matching Deny.
+	Allow []string
+}
+
+// DefaultHiddenFilePolicy hides dotfiles, other than those under .well-known, and files and directories starting with _.
+var DefaultHiddenFilePolicy = HiddenFilePolicy{
+	Deny:  []string{".*", "_*"},
+	Allow: []string{".well-known"},
+}
+
+// WithHiddenFilePolicy sets which files are never served, DefaultHiddenFilePolicy by default.
+func (h *Handler[D]) WithHiddenFilePolicy(policy HiddenFilePolicy) *Handler[D] {
+	h.hiddenFiles = &policy
+	return h
+}
+
+// hides reports whether the path has a segment the policy hides.
+func (p *HiddenFilePolicy) hides(name string) bool {
+	if p == nil {
+		p = &DefaultHiddenFilePolicy
+	}
+
+	for _, segment := range splitPath(name) {
+		if p.hidesSegment(segment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *HiddenFilePolicy) hidesSegment(segment string) bool {
+	for _, pattern := range p.Allow {
+		if ok, _ := path.Match(pattern, segment); ok {
+			return false
+		}
+	}
+	for _, pattern := range p.Deny {
+		if ok, _ := path.Match(pattern, segment); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package htmplx
+
+import (
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// WithHostFS serves a different tree per host, such as a skin per tenant of a SaaS, keyed by host name,
+// without the port, or by a wildcard for its subdomains, e.g. "*.example.com". The most specific key matching
+// the request's Host header wins, and requests for other hosts are served the handler's own tree.
+// Cache keys and cached fragments are kept per host. StageTree and PromoteTree only roll out the handler's own tree.
+//
+//	h.WithHostFS(map[string]fs.FS{
+//		"acme.example.com": os.DirFS("tenants/acme"),
+//		"*.example.com":    os.DirFS("tenants/default"),
+//	})
+func (h *Handler[D]) WithHostFS(hosts map[string]fs.FS) *Handler[D] {
+	h.hosts = make(map[string]fs.FS, len(hosts))
+	h.hostAssets = make(map[string]*assetFingerprints, len(hosts))
+	for host, fsys := range hosts {
+		h.hosts[strings.ToLower(host)] = fsys
+		h.hostAssets[strings.ToLower(host)] = &assetFingerprints{}
+	}
+	return h
+}
+
+// requestHost returns the host name of the request, without the port, in lower case.
+func requestHost(r *http.Request) string {
+	host := strings.ToLower(r.Host)
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	return host
+}
+
+// hostTree returns the tree of the request's host, and the key it is set under, if any.
+func (h *Handler[D]) hostTree(r *http.Request) (key string, fsys fs.FS, ok bool) {
+	if len(h.hosts) == 0 {
+		return "", nil, false
+	}
+
+	host := requestHost(r)
+	if fsys, ok := h.hosts[host]; ok {
+		return host, fsys, true
+	}
+
+	// a wildcard matches the subdomains of its domain, the nearest first.
+	for rest := host; ; {
+		_, parent, found := strings.Cut(rest, ".")
+		if !found {
+			return "", nil, false
+		}
+		if fsys, ok := h.hosts["*."+parent]; ok {
+			return "*." + parent, fsys, true
+		}
+		rest = parent
+	}
+}
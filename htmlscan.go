@@ -0,0 +1,176 @@
+package htmplx
+
+import "strings"
+
+// htmlTag is a start or end tag of an html document, as found by scanHTMLTags.
+type htmlTag struct {
+	// name is the tag name, in lower case.
+	name string
+	end  bool
+	// attrs are the attributes of a start tag, by lower case name.
+	attrs map[string]string
+	// line is the line of the document the tag starts on, from 1.
+	line int
+	// source is the tag as written.
+	source string
+}
+
+func (t htmlTag) has(attr string) bool {
+	_, ok := t.attrs[attr]
+	return ok
+}
+
+// rawTextElements are the elements whose content is text rather than markup.
+var rawTextElements = []string{"script", "style", "textarea", "title"}
+
+// scanHTMLTags returns the tags of an html document in order, skipping comments, doctypes and the content
+// of raw text elements such as scripts. It is a lenient scanner for checking rendered pages, not a parser:
+// malformed markup is skipped rather than reported.
+func scanHTMLTags(page string) []htmlTag {
+	var tags []htmlTag
+
+	line := 1
+	for i := 0; i < len(page); {
+		lt := strings.IndexByte(page[i:], '<')
+		if lt < 0 {
+			break
+		}
+		line += strings.Count(page[i:i+lt], "\n")
+		i += lt
+
+		rest := page[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest, "-->")
+			if end < 0 {
+				return tags
+			}
+			line += strings.Count(rest[:end], "\n")
+			i += end + len("-->")
+			continue
+		case strings.HasPrefix(rest, "<!"), strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return tags
+			}
+			line += strings.Count(rest[:end], "\n")
+			i += end + 1
+			continue
+		}
+
+		tag, n := scanHTMLTag(rest)
+		if n == 0 {
+			i++
+			continue
+		}
+		tag.line = line
+		tags = append(tags, tag)
+		line += strings.Count(rest[:n], "\n")
+		i += n
+
+		if !tag.end {
+			for _, name := range rawTextElements {
+				if tag.name != name {
+					continue
+				}
+				end := strings.Index(strings.ToLower(page[i:]), "</"+name)
+				if end < 0 {
+					return tags
+				}
+				line += strings.Count(page[i:i+end], "\n")
+				i += end
+			}
+		}
+	}
+
+	return tags
+}
+
+// scanHTMLTag scans the tag at the start of s, returning its length, or 0 if s does not start with one.
+func scanHTMLTag(s string) (htmlTag, int) {
+	var tag htmlTag
+
+	i := 1
+	if i < len(s) && s[i] == '/' {
+		tag.end = true
+		i++
+	}
+
+	// a tag name starts with a letter, so that text such as a < b is not taken for a tag.
+	if i >= len(s) || !('a' <= s[i] && s[i] <= 'z' || 'A' <= s[i] && s[i] <= 'Z') {
+		return tag, 0
+	}
+	start := i
+	for i < len(s) && isTagNameByte(s[i]) {
+		i++
+	}
+	tag.name = strings.ToLower(s[start:i])
+
+	for i < len(s) {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			return tag, 0
+		}
+		if s[i] == '>' {
+			i++
+			tag.source = s[:i]
+			return tag, i
+		}
+		if s[i] == '/' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(s[start:i])
+
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				end := strings.IndexByte(s[i+1:], s[i])
+				if end < 0 {
+					return tag, 0
+				}
+				value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+
+		if !tag.end && name != "" {
+			if tag.attrs == nil {
+				tag.attrs = make(map[string]string)
+			}
+			if _, ok := tag.attrs[name]; !ok {
+				tag.attrs[name] = value
+			}
+		}
+	}
+
+	return tag, 0
+}
+
+func isTagNameByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-'
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,46 @@
+package htmplxtest_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/angelbeltran/htmplx"
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+func ExampleFS() {
+	fsys := htmplxtest.FS(map[string]string{
+		"body.html.tmpl":               `<main>{{template "content" .}}</main>`,
+		"content.html.tmpl":            `Home`,
+		"about/content.html.tmpl":      `About us`,
+		"about/team/content.html.tmpl": `Our team`,
+	})
+	h := htmplx.NewHandler[htmplx.RequestDataMap](fsys).
+		WithLogHandlers(slog.NewTextHandler(io.Discard, nil))
+
+	get := func(path string) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		_, main, _ := strings.Cut(w.Body.String(), "<main>")
+		main, _, _ = strings.Cut(main, "</main>")
+		fmt.Println(path, w.Code, main)
+	}
+
+	get("/about")
+	get("/about/team")
+
+	// the tree may be changed while it is served.
+	fsys.WriteFile("about/content.html.tmpl", `About them`)
+	fsys.Remove("about/team")
+	get("/about")
+	get("/about/team")
+
+	// Output:
+	// /about 200 About us
+	// /about/team 200 Our team
+	// /about 200 About them
+	// /about/team 404
+}
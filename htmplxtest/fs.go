@@ -0,0 +1,106 @@
+// Package htmplxtest provides helpers for exercising htmplx handlers without
+// fixture directories on disk.
+package htmplxtest
+
+import (
+	"io/fs"
+	"path"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// FS returns a writable in-memory file system populated with the given files,
+// keyed by slash separated path.
+//
+//	fsys := htmplxtest.FS(map[string]string{
+//		"body.html.tmpl":          `{{template "content" .}}`,
+//		"about/content.html.tmpl": `About us`,
+//	})
+func FS(files map[string]string) *MapFS {
+	m := &MapFS{files: make(fstest.MapFS, len(files))}
+	for name, content := range files {
+		m.WriteFile(name, content)
+	}
+	return m
+}
+
+// MapFS is a fstest.MapFS that is safe to modify while being served.
+// Files are replaced, never mutated in place, so open files are unaffected by writes.
+type MapFS struct {
+	mu    sync.RWMutex
+	files fstest.MapFS
+}
+
+var (
+	_ fs.ReadFileFS = (*MapFS)(nil)
+	_ fs.ReadDirFS  = (*MapFS)(nil)
+	_ fs.StatFS     = (*MapFS)(nil)
+)
+
+func (m *MapFS) Open(name string) (fs.File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.Open(name)
+}
+
+func (m *MapFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.ReadFile(name)
+}
+
+func (m *MapFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.ReadDir(name)
+}
+
+func (m *MapFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.Stat(name)
+}
+
+// WriteFile creates or replaces the file at name.
+// The file's modification time is set to the current time.
+func (m *MapFS) WriteFile(name, content string) {
+	m.WriteFileBytes(name, []byte(content))
+}
+
+// WriteFileBytes is WriteFile for binary content.
+func (m *MapFS) WriteFileBytes(name string, content []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[path.Clean(name)] = &fstest.MapFile{
+		Data:    content,
+		Mode:    0o644,
+		ModTime: time.Now(),
+	}
+}
+
+// Remove deletes the file at name, along with anything beneath it if it is a directory.
+func (m *MapFS) Remove(name string) {
+	name = path.Clean(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k := range m.files {
+		if k == name || len(k) > len(name) && k[:len(name)] == name && k[len(name)] == '/' {
+			delete(m.files, k)
+		}
+	}
+}
+
+// Snapshot returns a copy of the current files.
+// The returned fstest.MapFS can be passed to fstest.TestFS.
+func (m *MapFS) Snapshot() fstest.MapFS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(fstest.MapFS, len(m.files))
+	for k, v := range m.files {
+		f := *v
+		out[k] = &f
+	}
+	return out
+}
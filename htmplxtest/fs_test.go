@@ -0,0 +1,63 @@
+package htmplxtest
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMapFS(t *testing.T) {
+	fsys := FS(map[string]string{
+		"body.html.tmpl":          `{{template "content" .}}`,
+		"about/content.html.tmpl": `About us`,
+		"./static/site.css":       `body { margin: 0; }`,
+	})
+
+	if err := fstest.TestFS(fsys, "body.html.tmpl", "about/content.html.tmpl", "static/site.css"); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys.WriteFile("about/team.html.tmpl", `Our team`)
+	if b, err := fs.ReadFile(fsys, "about/team.html.tmpl"); err != nil || string(b) != "Our team" {
+		t.Errorf("got %q, %v, want the written file", b, err)
+	}
+
+	fsys.WriteFile("about/content.html.tmpl", `About them`)
+	if b, err := fs.ReadFile(fsys, "about/content.html.tmpl"); err != nil || string(b) != "About them" {
+		t.Errorf("got %q, %v, want the replaced file", b, err)
+	}
+
+	snapshot := fsys.Snapshot()
+	fsys.Remove("about")
+	for _, name := range []string{"about", "about/content.html.tmpl", "about/team.html.tmpl"} {
+		if _, err := fs.Stat(fsys, name); err == nil {
+			t.Errorf("%s: got no error, want it removed", name)
+		}
+	}
+	if _, err := fs.Stat(fsys, "body.html.tmpl"); err != nil {
+		t.Errorf("body.html.tmpl: got %v, want it kept", err)
+	}
+
+	// snapshots are unaffected by later changes.
+	if err := fstest.TestFS(snapshot, "about/content.html.tmpl", "about/team.html.tmpl"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMapFSOpenFilesUnaffectedByWrites(t *testing.T) {
+	fsys := FS(map[string]string{"page.html": "before"})
+
+	f, err := fsys.Open("page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fsys.WriteFile("page.html", "after")
+
+	b := make([]byte, 16)
+	n, _ := f.Read(b)
+	if string(b[:n]) != "before" {
+		t.Errorf("got %q, want the content when opened", b[:n])
+	}
+}
@@ -0,0 +1,185 @@
+package htmplx
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"strings"
+)
+
+// xDefaultHreflang is the hreflang of the alternate for users whose language matches no locale.
+const xDefaultHreflang = "x-default"
+
+// localesConfig declares the locales of the tree, in the root directory's _config.json.
+// Each locale is served under a prefix of its name, its paths naming directories by their translations,
+// e.g. /de/produkte for the directory products, rendered with the same templates as /en/products:
+//
+//	{
+//		"locales": {
+//			"default": "en",
+//			"translations": {
+//				"en": {},
+//				"de": {"products": "produkte", "about": "ueber-uns"}
+//			}
+//		}
+//	}
+//
+// Names without a translation are used as they are. Paths without a locale prefix are served as before.
+type localesConfig struct {
+	// Default is the locale of users whose language matches none, the x-default hreflang alternate.
+	Default string `json:"default"`
+	// Translations are the translations of directory names, by locale.
+	Translations map[string]map[string]string `json:"translations"`
+}
+
+// locales returns the locales declared by the tree, if any.
+func (h requestHandler) locales() (*localesConfig, error) {
+	cfg, err := h.readDirectoryConfig("")
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Locales == nil || len(cfg.Locales.Translations) == 0 {
+		return nil, nil
+	}
+	return cfg.Locales, nil
+}
+
+// delocalize returns the locale of the prefix of the segments of a url path, if any, and the segments without it,
+// translated back to the names of the directories. A segment naming a directory that the locale translates
+// is not found, so that each page has a single url per locale.
+func (h requestHandler) delocalize(segments []string) (locale string, names []string, err error) {
+	if len(segments) == 0 {
+		return "", segments, nil
+	}
+	cfg, err := h.locales()
+	if err != nil || cfg == nil {
+		return "", segments, err
+	}
+	translations, ok := cfg.Translations[segments[0]]
+	if !ok {
+		return "", segments, nil
+	}
+
+	names = make([]string, 0, len(segments)-1)
+	for _, s := range segments[1:] {
+		name, ok := untranslate(translations, s)
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %s is translated in locale %s", fs.ErrNotExist, s, segments[0])
+		}
+		names = append(names, name)
+	}
+
+	return segments[0], names, nil
+}
+
+// untranslate returns the name of the directory translated to segment, itself if none is,
+// or false if segment names a directory that is translated to something else.
+func untranslate(translations map[string]string, segment string) (string, bool) {
+	for name, translated := range translations {
+		if translated == segment {
+			return name, true
+		}
+	}
+	if translated, ok := translations[segment]; ok && translated != segment {
+		return "", false
+	}
+	return segment, true
+}
+
+// localize returns the segments of the path of directory names, names, prefixed by locale and translated.
+func (h requestHandler) localize(locale string, names []string) ([]string, error) {
+	cfg, err := h.locales()
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("no locales declared")
+	}
+	translations, ok := cfg.Translations[locale]
+	if !ok {
+		return nil, fmt.Errorf("unknown locale %q", locale)
+	}
+
+	segments := []string{locale}
+	for _, name := range names {
+		if translated, ok := translations[name]; ok {
+			name = translated
+		}
+		segments = append(segments, name)
+	}
+	return segments, nil
+}
+
+// localeURL returns the url of the page being rendered in locale, e.g. to link to its translations.
+//
+//	<a href="{{localeURL "de"}}" hreflang="de">Deutsch</a>
+func (h requestHandler) localeURL(locale string) (string, error) {
+	p, err := h.localePath(locale)
+	if err != nil {
+		return "", fmt.Errorf("localeURL %s: %w", locale, err)
+	}
+	return h.withBasePath(p), nil
+}
+
+// localePath returns the path of the page being rendered in locale, without the base path.
+func (h requestHandler) localePath(locale string) (string, error) {
+	if h.route == nil {
+		return "", fmt.Errorf("no route rendered")
+	}
+
+	_, names, err := h.delocalize(splitPath(h.route.Path))
+	if err != nil {
+		return "", err
+	}
+	return h.localizedPath(locale, names)
+}
+
+// localizedPath returns the canonical url path, per the slash policy, of the page at the path of directory names
+// in the locale, as linked to by the page head and the sitemap alike.
+func (h requestHandler) localizedPath(locale string, names []string) (string, error) {
+	segments, err := h.localize(locale, names)
+	if err != nil {
+		return "", err
+	}
+	return h.slashes.canonical("/" + strings.Join(segments, "/")), nil
+}
+
+// localeAlternates are the alternates of the page being rendered in each locale, and that of the default locale
+// as the x-default alternate, if any, so that search engines serve users the page in their language.
+func (h requestHandler) localeAlternates() ([]alternate, error) {
+	cfg, err := h.locales()
+	if err != nil || cfg == nil || h.route == nil {
+		return nil, err
+	}
+
+	var alternates []alternate
+	for _, locale := range sortedKeys(cfg.Translations, strings.Compare) {
+		href, err := h.localePath(locale)
+		if err != nil {
+			return nil, err
+		}
+		alternates = append(alternates, alternate{Hreflang: locale, Href: href})
+		if locale == cfg.Default {
+			alternates = append(alternates, alternate{Hreflang: xDefaultHreflang, Href: href})
+		}
+	}
+
+	return alternates, nil
+}
+
+// hreflangLinks renders the <link> tags of the alternates of the page being rendered in each locale,
+// for layouts not rendering alternateLinks, which includes them.
+//
+//	<head>{{hreflangLinks}}</head>
+func (h requestHandler) hreflangLinks() (template.HTML, error) {
+	alternates, err := h.localeAlternates()
+	if err != nil {
+		return "", err
+	}
+
+	links := make([]string, len(alternates))
+	for i, a := range alternates {
+		links[i] = a.link(h.route, h.basePath)
+	}
+	return template.HTML(strings.Join(links, "\n")), nil
+}
@@ -0,0 +1,95 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// iconsDir is the directory of svg icons rendered by the icon template function.
+const iconsDir = "_icons"
+
+// iconSprite collects the icons used while rendering a page, so each is inlined once,
+// as a <symbol> in a sprite sheet at the end of the page, however many times it is used.
+type iconSprite struct {
+	rh      requestHandler
+	names   []string
+	symbols map[string]string
+}
+
+// icon renders a reference to the icon in _icons/NAME.svg, adding it to the page's sprite sheet.
+//
+//	{{icon "check"}}
+//	{{icon "check" "text-green"}}
+func (s *iconSprite) icon(name string, classes ...string) (template.HTML, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid icon name %q", name)
+	}
+
+	if _, ok := s.symbols[name]; !ok {
+		symbol, err := s.rh.readIconSymbol(name)
+		if err != nil {
+			return "", err
+		}
+		if s.symbols == nil {
+			s.symbols = make(map[string]string)
+		}
+		s.symbols[name] = symbol
+		s.names = append(s.names, name)
+	}
+
+	class := strings.Join(append([]string{"icon", "icon-" + name}, classes...), " ")
+
+	return template.HTML(`<svg class="` + html.EscapeString(class) + `" aria-hidden="true">` +
+		`<use href="#icon-` + html.EscapeString(name) + `"></use></svg>`), nil
+}
+
+// sheet renders the symbols of the icons used so far.
+func (s *iconSprite) sheet() template.HTML {
+	if len(s.names) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" style="display:none">`)
+	for _, name := range s.names {
+		b.WriteString(s.symbols[name])
+	}
+	b.WriteString(`</svg>`)
+
+	return template.HTML(b.String())
+}
+
+var (
+	svgOpenTagPattern = regexp.MustCompile(`(?s)<svg\b[^>]*>`)
+	viewBoxPattern    = regexp.MustCompile(`\bviewBox\s*=\s*"([^"]*)"`)
+)
+
+// readIconSymbol reads an icon's svg file as a <symbol>, keeping its viewBox and content.
+func (h requestHandler) readIconSymbol(name string) (string, error) {
+	b, err := h.readFile(iconsDir + "/" + name + ".svg")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("icon %s not found: %w", name, err)
+		}
+		return "", err
+	}
+	svg := string(b)
+
+	loc := svgOpenTagPattern.FindStringIndex(svg)
+	end := strings.LastIndex(svg, "</svg>")
+	if loc == nil || end < loc[1] {
+		return "", fmt.Errorf("icon %s is not an svg", name)
+	}
+
+	var viewBox string
+	if m := viewBoxPattern.FindStringSubmatch(svg[loc[0]:loc[1]]); m != nil {
+		viewBox = ` viewBox="` + m[1] + `"`
+	}
+
+	return `<symbol id="icon-` + html.EscapeString(name) + `"` + viewBox + `>` + svg[loc[1]:end] + `</symbol>`, nil
+}
@@ -0,0 +1,44 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// defaultIndexFiles are the index documents of a directory, unless set with WithIndexFiles.
+var defaultIndexFiles = []string{"index.html", "index.htm"}
+
+// WithIndexFiles sets the names of the index documents served for a directory's route, in order of preference,
+// index.html and index.htm by default, e.g. /docs serving docs/index.html. An index document is served
+// in place of the directory's templates. Index documents are disabled if no name is given.
+func (h *Handler[D]) WithIndexFiles(names ...string) *Handler[D] {
+	h.indexFiles = append([]string{}, names...)
+	return h
+}
+
+// readIndexFile opens the first index document of the route's directory. out is nil if there is none.
+func (h *Handler[D]) readIndexFile(rh requestHandler, route *Route) (out io.ReadCloser, contentType string, err error) {
+	names := h.indexFiles
+	if names == nil {
+		names = defaultIndexFiles
+	}
+
+	for _, name := range names {
+		filename := joinPath(route.Dir(), name)
+		if info, err := fs.Stat(rh.fs, filename); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, "", fmt.Errorf("failed to look up %s: %w", filename, err)
+		} else if info.IsDir() {
+			continue
+		}
+
+		rh.log.Debug("serving index file " + filename)
+		return rh.readFileAndContentType(filename)
+	}
+
+	return nil, "", nil
+}
@@ -2,27 +2,103 @@ package logging
 
 import (
 	"context"
+	"errors"
 	"log/slog"
-	"os"
+	"maps"
 )
 
+// LevelFunc returns the minimum level of the records of a logger, given its attributes by key,
+// those in groups qualified by the group names, e.g. request.path.
+type LevelFunc func(attrs map[string]slog.Value) slog.Level
+
+// LevelHandler filters records by a minimum level, which may depend on the attributes of the logger,
+// such as the path of the request logged, and passes them on to each of its handlers.
 type LevelHandler struct {
-	level slog.Level
-	slog.Handler
+	handlers []slog.Handler
+	level    LevelFunc
+	// attrs are the attributes of the logger, by qualified key.
+	attrs map[string]slog.Value
+	// group is the qualified name of the group attributes are added to, if any.
+	group string
 }
 
-func NewLevelHandler(level slog.Level, opts *slog.HandlerOptions) *LevelHandler {
+// NewLevelHandler returns a handler passing the records at or above the level returned by level on to handlers.
+// Handlers should handle records of every level, leaving it to level to filter them.
+func NewLevelHandler(level LevelFunc, handlers ...slog.Handler) *LevelHandler {
 	return &LevelHandler{
-		level:   level,
-		Handler: slog.NewTextHandler(os.Stdout),
+		handlers: handlers,
+		level:    level,
+	}
+}
+
+func (h *LevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level(h.attrs)
+}
+
+func (h *LevelHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, out := range h.handlers {
+		if out.Enabled(ctx, r.Level) {
+			errs = append(errs, out.Handle(ctx, r.Clone()))
+		}
 	}
+	return errors.Join(errs...)
 }
 
-//func (h *LevelHandler)
+func (h *LevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := *h
+	h2.handlers = make([]slog.Handler, len(h.handlers))
+	for i, out := range h.handlers {
+		h2.handlers[i] = out.WithAttrs(attrs)
+	}
 
-type Handler interface {
-	Enabled(context.Context, Level) bool
-	Handle(context.Context, Record) error
-	WithAttrs(attrs []Attr) Handler
-	WithGroup(name string) Handler
+	h2.attrs = maps.Clone(h.attrs)
+	if h2.attrs == nil {
+		h2.attrs = make(map[string]slog.Value, len(attrs))
+	}
+	addAttrs(h2.attrs, h.group, attrs)
+
+	return &h2
+}
+
+func (h *LevelHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.handlers = make([]slog.Handler, len(h.handlers))
+	for i, out := range h.handlers {
+		h2.handlers[i] = out.WithGroup(name)
+	}
+	h2.group = qualify(h.group, name)
+
+	return &h2
+}
+
+// addAttrs adds attrs to m by their key qualified by group, flattening the attributes of groups.
+func addAttrs(m map[string]slog.Value, group string, attrs []slog.Attr) {
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		if v.Kind() != slog.KindGroup {
+			m[qualify(group, a.Key)] = v
+			continue
+		}
+		// the attributes of a group without a key are inlined, as by the slog handlers.
+		addAttrs(m, qualify(group, a.Key), v.Group())
+	}
+}
+
+func qualify(group, key string) string {
+	switch {
+	case group == "":
+		return key
+	case key == "":
+		return group
+	}
+	return group + "." + key
 }
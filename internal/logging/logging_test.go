@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// debugPath logs the records of loggers whose request.path attribute is /debug at debug level, others at info.
+func debugPath(attrs map[string]slog.Value) slog.Level {
+	if v, ok := attrs["request.path"]; ok && v.Kind() == slog.KindString && v.String() == "/debug" {
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// pathValuer resolves to a path lazily, as a slog.LogValuer.
+type pathValuer string
+
+func (p pathValuer) LogValue() slog.Value {
+	return slog.StringValue(string(p))
+}
+
+func TestLevelHandlerAttrs(t *testing.T) {
+	tests := []struct {
+		name      string
+		logger    func(*slog.Logger) *slog.Logger
+		wantDebug bool
+	}{
+		{"no attrs", func(l *slog.Logger) *slog.Logger { return l }, false},
+		{"group attr", func(l *slog.Logger) *slog.Logger {
+			return l.With(slog.Group("request", "path", "/debug"))
+		}, true},
+		{"attr in group", func(l *slog.Logger) *slog.Logger {
+			return l.WithGroup("request").With("path", "/debug")
+		}, true},
+		{"attr added before group", func(l *slog.Logger) *slog.Logger {
+			return l.With("path", "/debug").WithGroup("request")
+		}, false},
+		{"top level attr", func(l *slog.Logger) *slog.Logger {
+			return l.With("path", "/debug")
+		}, false},
+		{"nested group", func(l *slog.Logger) *slog.Logger {
+			return l.WithGroup("outer").With(slog.Group("request", "path", "/debug"))
+		}, false},
+		{"inlined group", func(l *slog.Logger) *slog.Logger {
+			return l.WithGroup("request").With(slog.Group("", "path", "/debug"))
+		}, true},
+		{"empty group name", func(l *slog.Logger) *slog.Logger {
+			return l.WithGroup("").With(slog.Group("request", "path", "/debug"))
+		}, true},
+		{"log valuer", func(l *slog.Logger) *slog.Logger {
+			return l.With(slog.Group("request", "path", pathValuer("/debug")))
+		}, true},
+		{"other path", func(l *slog.Logger) *slog.Logger {
+			return l.With(slog.Group("request", "path", "/other"))
+		}, false},
+		{"later attr replaces", func(l *slog.Logger) *slog.Logger {
+			return l.With(slog.Group("request", "path", "/debug")).With(slog.Group("request", "path", "/other"))
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			out := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+			l := tt.logger(slog.New(NewLevelHandler(debugPath, out)))
+
+			if got := l.Enabled(context.Background(), slog.LevelDebug); got != tt.wantDebug {
+				t.Errorf("debug enabled: got %t, want %t", got, tt.wantDebug)
+			}
+			if !l.Enabled(context.Background(), slog.LevelInfo) {
+				t.Error("info not enabled")
+			}
+
+			l.Debug("debugging")
+			if got := strings.Contains(buf.String(), "debugging"); got != tt.wantDebug {
+				t.Errorf("debug logged: got %t, want %t, in %q", got, tt.wantDebug, buf.String())
+			}
+		})
+	}
+}
+
+func TestLevelHandlerTees(t *testing.T) {
+	var all, warnings bytes.Buffer
+	l := slog.New(NewLevelHandler(debugPath,
+		slog.NewTextHandler(&all, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewTextHandler(&warnings, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	)).WithGroup("request").With("path", "/debug")
+
+	l.Debug("debugging")
+	l.Warn("warning")
+
+	for _, want := range []string{"msg=debugging request.path=/debug", "msg=warning request.path=/debug"} {
+		if !strings.Contains(all.String(), want) {
+			t.Errorf("got %q, want it to contain %q", all.String(), want)
+		}
+	}
+	// each handler still filters the records it is given by its own level.
+	if strings.Contains(warnings.String(), "debugging") || !strings.Contains(warnings.String(), "msg=warning request.path=/debug") {
+		t.Errorf("got %q, want only the warning", warnings.String())
+	}
+}
@@ -0,0 +1,97 @@
+//go:build ignore
+
+// This program generates tables.go from golang.org/x/text/unicode/norm, which htmplx does not depend on,
+// so it is run from a module that does:
+//
+//	cd $(mktemp -d) && go mod init gen && go get golang.org/x/text@latest
+//	go run /path/to/htmplx/internal/nfc/gen.go > /path/to/htmplx/internal/nfc/tables.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func main() {
+	var excluded [][2]string
+	var cccs []struct {
+		lo, hi rune
+		ccc    uint8
+	}
+	var pairs [][3]rune
+
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		if !utf8.ValidRune(r) {
+			continue
+		}
+		s := string(r)
+
+		if ccc := norm.NFD.PropertiesString(s).CCC(); ccc != 0 {
+			if n := len(cccs); n > 0 && cccs[n-1].hi == r-1 && cccs[n-1].ccc == ccc {
+				cccs[n-1].hi = r
+			} else {
+				cccs = append(cccs, struct {
+					lo, hi rune
+					ccc    uint8
+				}{r, r, ccc})
+			}
+		}
+
+		// characters never found in NFC are replaced by their decomposition.
+		if norm.NFC.String(s) != s {
+			excluded = append(excluded, [2]string{fmt.Sprintf("0x%04X", r), fmt.Sprintf("%+q", norm.NFD.String(s))})
+			continue
+		}
+
+		// primary composites, but for Hangul syllables, which are composed algorithmically.
+		if r >= 0xAC00 && r <= 0xD7A3 {
+			continue
+		}
+		d := norm.NFD.String(s)
+		if d == s {
+			continue
+		}
+		second, size := utf8.DecodeLastRuneInString(d)
+		first := []rune(norm.NFC.String(d[:len(d)-size]))
+		if len(first) != 1 {
+			log.Fatalf("%U does not compose from a pair", r)
+		}
+		pairs = append(pairs, [3]rune{first[0], second, r})
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gen.go from golang.org/x/text/unicode/norm, Unicode %s. DO NOT EDIT.\n\n", norm.Version)
+	fmt.Fprintf(&b, "package nfc\n\n")
+	fmt.Fprintf(&b, "// excluded are the characters never found in NFC, whose NFC_Quick_Check property is No, by their full decomposition.\n")
+	fmt.Fprintf(&b, "var excluded = map[rune]string{\n")
+	for _, e := range excluded {
+		fmt.Fprintf(&b, "%s: %s,\n", e[0], e[1])
+	}
+	fmt.Fprintf(&b, "}\n\n")
+	fmt.Fprintf(&b, "// combiningClasses are the ranges of the characters of a canonical combining class other than 0.\n")
+	fmt.Fprintf(&b, "var combiningClasses = []combiningClassRange{\n")
+	for _, r := range cccs {
+		fmt.Fprintf(&b, "{0x%04X, 0x%04X, %d},\n", r.lo, r.hi, r.ccc)
+	}
+	fmt.Fprintf(&b, "}\n\n")
+	fmt.Fprintf(&b, "// compositions are the primary composites, by the pair of characters they are composed of,\n")
+	fmt.Fprintf(&b, "// but for Hangul syllables, which are composed algorithmically.\n")
+	fmt.Fprintf(&b, "var compositions = map[[2]rune]rune{\n")
+	for _, p := range pairs {
+		fmt.Fprintf(&b, "{0x%04X, 0x%04X}: 0x%04X,\n", p[0], p[1], p[2])
+	}
+	fmt.Fprintf(&b, "}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Stdout.Write(src)
+}
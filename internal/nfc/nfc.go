@@ -0,0 +1,167 @@
+// Package nfc normalizes strings to Unicode Normalization Form C, as golang.org/x/text/unicode/norm does,
+// from tables generated from it by gen.go.
+package nfc
+
+import (
+	"sort"
+	"sync"
+)
+
+// IsNormal reports whether s, valid UTF-8, is in Unicode Normalization Form C, that is, String leaves it unchanged.
+func IsNormal(s string) bool {
+	quick := true
+	for _, r := range s {
+		if r < 0x300 {
+			continue
+		}
+		if _, ok := excluded[r]; ok {
+			return false
+		}
+		// combining marks, and characters composing with the character before them, may not be in NFC.
+		if combiningClass(r) != 0 || composesBackward()[r] {
+			quick = false
+		}
+	}
+	return quick || string(normalize([]rune(s))) == s
+}
+
+// String returns s, valid UTF-8, normalized to Unicode Normalization Form C.
+func String(s string) string {
+	if IsNormal(s) {
+		return s
+	}
+	return string(normalize([]rune(s)))
+}
+
+// normalize returns rs normalized to NFC: the characters are decomposed, their combining marks put in canonical order,
+// then composed again.
+func normalize(rs []rune) []rune {
+	var decomposed []rune
+	for _, r := range rs {
+		decomposed = appendDecomposition(decomposed, r)
+	}
+	rs = decomposed
+
+	for i := 1; i < len(rs); i++ {
+		class := combiningClass(rs[i])
+		if class == 0 {
+			continue
+		}
+		for j := i; j > 0 && combiningClass(rs[j-1]) > class; j-- {
+			rs[j-1], rs[j] = rs[j], rs[j-1]
+		}
+	}
+
+	if len(rs) == 0 {
+		return rs
+	}
+	// a mark composes with the last starter unless a mark of the same or a higher class comes between,
+	// and a starter only with the starter right before it.
+	starter := 0
+	lastClass := int(combiningClass(rs[0]))
+	if lastClass != 0 {
+		// marks before the first starter compose with nothing.
+		lastClass = 256
+	}
+	n := 1
+	for _, r := range rs[1:] {
+		class := int(combiningClass(r))
+		if c, ok := compose(rs[starter], r); ok && (lastClass < class || lastClass == 0) {
+			rs[starter] = c
+			continue
+		}
+		if class == 0 {
+			starter = n
+		}
+		lastClass = class
+		rs[n] = r
+		n++
+	}
+	return rs[:n]
+}
+
+// appendDecomposition appends the canonical decomposition of r to rs.
+func appendDecomposition(rs []rune, r rune) []rune {
+	if r >= hangulBase && r < hangulLVLimit {
+		s := r - hangulBase
+		rs = append(rs, hangulLBase+s/hangulNCount, hangulVBase+s%hangulNCount/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			rs = append(rs, hangulTBase+t)
+		}
+		return rs
+	}
+	if d, ok := excluded[r]; ok {
+		return append(rs, []rune(d)...)
+	}
+	pair, ok := decompositions()[r]
+	if !ok {
+		return append(rs, r)
+	}
+	return appendDecomposition(appendDecomposition(rs, pair[0]), pair[1])
+}
+
+type combiningClassRange struct {
+	lo, hi rune
+	class  uint8
+}
+
+// combiningClass returns the canonical combining class of r, 0 for starters.
+func combiningClass(r rune) uint8 {
+	i := sort.Search(len(combiningClasses), func(i int) bool { return combiningClasses[i].hi >= r })
+	if i < len(combiningClasses) && combiningClasses[i].lo <= r {
+		return combiningClasses[i].class
+	}
+	return 0
+}
+
+// Hangul syllables are composed of a leading consonant, a vowel and an optional trailing consonant, algorithmically.
+const (
+	hangulBase    = 0xAC00
+	hangulLBase   = 0x1100
+	hangulVBase   = 0x1161
+	hangulTBase   = 0x11A7
+	hangulLCount  = 19
+	hangulVCount  = 21
+	hangulTCount  = 28
+	hangulNCount  = hangulVCount * hangulTCount
+	hangulSCount  = hangulLCount * hangulNCount
+	hangulLVLimit = hangulBase + hangulSCount
+)
+
+// compose returns the primary composite of the pair of characters, if any.
+func compose(a, b rune) (rune, bool) {
+	switch {
+	case a >= hangulLBase && a < hangulLBase+hangulLCount && b >= hangulVBase && b < hangulVBase+hangulVCount:
+		return hangulBase + ((a-hangulLBase)*hangulVCount+b-hangulVBase)*hangulTCount, true
+	case a >= hangulBase && a < hangulLVLimit && (a-hangulBase)%hangulTCount == 0 &&
+		b > hangulTBase && b < hangulTBase+hangulTCount:
+		return a + b - hangulTBase, true
+	}
+	c, ok := compositions[[2]rune{a, b}]
+	return c, ok
+}
+
+// decompositions are the pairs primary composites are composed of, but for Hangul syllables.
+var decompositions = sync.OnceValue(func() map[rune][2]rune {
+	m := make(map[rune][2]rune, len(compositions))
+	for pair, c := range compositions {
+		m[c] = pair
+	}
+	return m
+})
+
+// composesBackward are the characters composing with the character before them, Hangul vowels
+// and trailing consonants included.
+var composesBackward = sync.OnceValue(func() map[rune]bool {
+	m := make(map[rune]bool, len(compositions)+hangulVCount+hangulTCount)
+	for pair := range compositions {
+		m[pair[1]] = true
+	}
+	for v := range rune(hangulVCount) {
+		m[hangulVBase+v] = true
+	}
+	for t := rune(1); t < hangulTCount; t++ {
+		m[hangulTBase+t] = true
+	}
+	return m
+})
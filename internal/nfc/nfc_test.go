@@ -0,0 +1,85 @@
+package nfc
+
+import "testing"
+
+func TestIsNormal(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"", true},
+		{"dogs", true},
+		{"caf\u00E9", true},
+		{"cafe\u0301", false},
+		// the Angstrom sign is always replaced by Å.
+		{"\u212B", false},
+		{"\u00C5", true},
+		// marks composing with a starter are composed, whatever their order.
+		{"a\u0323\u0302", false},
+		{"\u1EA1\u0302", false},
+		{"\u1EAD", true},
+		// marks not composing with their starter are kept, in canonical order.
+		{"q\u0302\u0323", false},
+		{"q\u0323\u0302", true},
+		{"\u00E0\u0323", false},
+		{"\u1EA1\u0300", true},
+		// a mark blocked by another of its class is not composed.
+		{"a\u0301\u0301", false},
+		{"\u00E1\u0301", true},
+		// marks with no starter before them compose with nothing.
+		{"\u0301a", true},
+		{"\u0301", true},
+		// Hangul syllables are composed of their jamo.
+		{"\uD55C\uAD6D\uC5B4", true},
+		{"\u1112\u1161\u11AB", false},
+		{"\uD55C", true},
+		// scripts whose vowel signs are marks or starters that do not compose with the consonant before them.
+		{"\u09AC\u09BE\u0982\u09B2\u09BE", true},
+		{"\u0939\u093F\u0928\u094D\u0926\u0940", true},
+		{"\u0BA4\u0BAE\u0BBF\u0BB4\u0BCD", true},
+		{"\u0B92\u0BD7", false},
+		{"\u0B94", true},
+		{"\u65E5\u672C\u8A9E", true},
+		{"\u304B\u3099", false},
+		{"\u304C", true},
+		// CJK compatibility ideographs are replaced by their unified ideographs.
+		{"\uF900", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsNormal(tt.s); got != tt.want {
+			t.Errorf("IsNormal(%+q) = %t, want %t", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"", ""},
+		{"dogs", "dogs"},
+		{"caf\u00E9", "caf\u00E9"},
+		{"cafe\u0301", "caf\u00E9"},
+		{"\u212B", "\u00C5"},
+		{"a\u0323\u0302", "\u1EAD"},
+		{"q\u0302\u0323", "q\u0323\u0302"},
+		{"\u00E0\u0323", "\u1EA1\u0300"},
+		{"a\u0301\u0301", "\u00E1\u0301"},
+		{"\u0301a", "\u0301a"},
+		{"\u1112\u1161\u11AB", "\uD55C"},
+		{"\u0B92\u0BD7", "\u0B94"},
+		{"\u304B\u3099", "\u304C"},
+		{"\uF900", "\u8C48"},
+		// characters never found in NFC that decompose into marks are put in order with those around them.
+		{"a\u0344", "\u00E4\u0301"},
+		// a mark after a starter not composing with the character before it does not compose across it.
+		{"e\u0BD7\u0301", "e\u0BD7\u0301"},
+	}
+
+	for _, tt := range tests {
+		if got := String(tt.s); got != tt.want {
+			t.Errorf("String(%+q) = %+q, want %+q", tt.s, got, tt.want)
+		}
+	}
+}
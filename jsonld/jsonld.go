@@ -0,0 +1,271 @@
+// Package jsonld renders schema.org structured data as JSON-LD script tags from typed Go values.
+//
+// Add Funcs to a handler's template functions and render structured data in a head template:
+//
+//	{{jsonld .Article}}
+package jsonld
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"strconv"
+	"time"
+)
+
+// Schema is a schema.org type that can be rendered as JSON-LD.
+type Schema interface {
+	// SchemaType is the schema.org type name, e.g. Article.
+	SchemaType() string
+	// Validate reports missing or invalid properties.
+	Validate() error
+}
+
+// Funcs returns the template functions of this package:
+//
+//	jsonld: renders a Schema as a <script type="application/ld+json"> tag.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"jsonld": Script,
+	}
+}
+
+// Script validates v and renders it as a JSON-LD script tag.
+func Script(v Schema) (template.HTML, error) {
+	if v == nil {
+		return "", errors.New("jsonld: nil schema")
+	}
+	if err := v.Validate(); err != nil {
+		return "", fmt.Errorf("jsonld: invalid %s: %w", v.SchemaType(), err)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("jsonld: %w", err)
+	}
+
+	// json.Marshal escapes <, > and &, so the content cannot close the script tag.
+	b = append([]byte(`{"@context":"https://schema.org",`), b[1:]...)
+
+	return template.HTML(`<script type="application/ld+json">` + string(b) + `</script>`), nil
+}
+
+// Article is a https://schema.org/Article.
+type Article struct {
+	Headline      string        `json:"headline"`
+	Description   string        `json:"description,omitempty"`
+	Image         []string      `json:"image,omitempty"`
+	Author        []Person      `json:"author,omitempty"`
+	Publisher     *Organization `json:"publisher,omitempty"`
+	DatePublished *time.Time    `json:"datePublished,omitempty"`
+	DateModified  *time.Time    `json:"dateModified,omitempty"`
+	URL           string        `json:"url,omitempty"`
+}
+
+// articleHeadlineMaxLen is the longest headline search engines display.
+const articleHeadlineMaxLen = 110
+
+func (Article) SchemaType() string { return "Article" }
+
+func (a Article) Validate() error {
+	var errs []error
+	if a.Headline == "" {
+		errs = append(errs, errors.New("headline is required"))
+	} else if len([]rune(a.Headline)) > articleHeadlineMaxLen {
+		errs = append(errs, fmt.Errorf("headline exceeds %d characters", articleHeadlineMaxLen))
+	}
+	for i, p := range a.Author {
+		if err := p.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("author %d: %w", i, err))
+		}
+	}
+	if a.Publisher != nil {
+		if err := a.Publisher.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("publisher: %w", err))
+		}
+	}
+	if a.DatePublished != nil && a.DateModified != nil && a.DateModified.Before(*a.DatePublished) {
+		errs = append(errs, errors.New("dateModified is before datePublished"))
+	}
+	return errors.Join(errs...)
+}
+
+func (a Article) MarshalJSON() ([]byte, error) {
+	type article Article
+	return marshalTyped(a.SchemaType(), article(a))
+}
+
+// Person is a https://schema.org/Person.
+type Person struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+func (Person) SchemaType() string { return "Person" }
+
+func (p Person) Validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func (p Person) MarshalJSON() ([]byte, error) {
+	type person Person
+	return marshalTyped(p.SchemaType(), person(p))
+}
+
+// Organization is a https://schema.org/Organization.
+type Organization struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+	Logo string `json:"logo,omitempty"`
+}
+
+func (Organization) SchemaType() string { return "Organization" }
+
+func (o Organization) Validate() error {
+	if o.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func (o Organization) MarshalJSON() ([]byte, error) {
+	type organization Organization
+	return marshalTyped(o.SchemaType(), organization(o))
+}
+
+// Product is a https://schema.org/Product.
+type Product struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Image       []string      `json:"image,omitempty"`
+	SKU         string        `json:"sku,omitempty"`
+	Brand       *Organization `json:"brand,omitempty"`
+	Offers      []Offer       `json:"offers,omitempty"`
+}
+
+func (Product) SchemaType() string { return "Product" }
+
+func (p Product) Validate() error {
+	var errs []error
+	if p.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if p.Brand != nil {
+		if err := p.Brand.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("brand: %w", err))
+		}
+	}
+	for i, o := range p.Offers {
+		if err := o.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("offer %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+	return marshalTyped(p.SchemaType(), product(p))
+}
+
+// Offer is a https://schema.org/Offer.
+type Offer struct {
+	// Price is a decimal number without currency symbols, e.g. 19.99.
+	Price string `json:"price"`
+	// PriceCurrency is a ISO 4217 currency code, e.g. USD.
+	PriceCurrency string `json:"priceCurrency"`
+	// Availability is a https://schema.org/ItemAvailability url, e.g. https://schema.org/InStock.
+	Availability string `json:"availability,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+func (Offer) SchemaType() string { return "Offer" }
+
+func (o Offer) Validate() error {
+	var errs []error
+	if o.Price == "" {
+		errs = append(errs, errors.New("price is required"))
+	} else if _, err := strconv.ParseFloat(o.Price, 64); err != nil {
+		errs = append(errs, fmt.Errorf("price %q is not a number", o.Price))
+	}
+	if len(o.PriceCurrency) != 3 {
+		errs = append(errs, fmt.Errorf("priceCurrency %q is not a ISO 4217 code", o.PriceCurrency))
+	}
+	return errors.Join(errs...)
+}
+
+func (o Offer) MarshalJSON() ([]byte, error) {
+	type offer Offer
+	return marshalTyped(o.SchemaType(), offer(o))
+}
+
+// BreadcrumbList is a https://schema.org/BreadcrumbList.
+type BreadcrumbList struct {
+	ItemListElement []ListItem `json:"itemListElement"`
+}
+
+func (BreadcrumbList) SchemaType() string { return "BreadcrumbList" }
+
+func (b BreadcrumbList) Validate() error {
+	if len(b.ItemListElement) == 0 {
+		return errors.New("itemListElement is required")
+	}
+
+	var errs []error
+	for i, item := range b.ItemListElement {
+		if item.Name == "" {
+			errs = append(errs, fmt.Errorf("item %d: name is required", i))
+		}
+		// only the last item, the current page, may omit its url
+		if item.Item == "" && i < len(b.ItemListElement)-1 {
+			errs = append(errs, fmt.Errorf("item %d: item is required", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b BreadcrumbList) MarshalJSON() ([]byte, error) {
+	type breadcrumbList BreadcrumbList
+
+	// positions default to the item's place in the list
+	items := make([]ListItem, len(b.ItemListElement))
+	for i, item := range b.ItemListElement {
+		if item.Position == 0 {
+			item.Position = i + 1
+		}
+		items[i] = item
+	}
+
+	return marshalTyped(b.SchemaType(), breadcrumbList{ItemListElement: items})
+}
+
+// ListItem is a https://schema.org/ListItem within a BreadcrumbList.
+type ListItem struct {
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	// Item is the url of the breadcrumb.
+	Item string `json:"item,omitempty"`
+}
+
+func (l ListItem) MarshalJSON() ([]byte, error) {
+	type listItem ListItem
+	return marshalTyped("ListItem", listItem(l))
+}
+
+// marshalTyped marshals v, a JSON object, with a leading @type property.
+func marshalTyped(schemaType string, v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	typed := []byte(`{"@type":` + fmt.Sprintf("%q", schemaType))
+	if len(b) > 2 {
+		typed = append(typed, ',')
+	}
+	return append(typed, b[1:]...), nil
+}
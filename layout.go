@@ -1,23 +1,60 @@
 package htmplx
 
-import "html/template"
+import (
+	"fmt"
+	"html/template"
+	"text/template/parse"
+)
 
 const (
+	// titleElementName is the template rendering the <title> element, only when the title template is defined,
+	// so as not to duplicate a <title> in the head template.
+	titleElementName = "htmplx:title"
+
 	layoutTemplateString = `{{define "head"}}{{end}}
 {{define "body"}}{{end}}
+{{define "title"}}{{end}}
+{{define "meta"}}{{end}}
+{{define "` + titleElementName + `"}}{{end}}
 <!DOCTYPE html>
 <html>
 	<head>
+		{{ template "` + titleElementName + `" . }}
+		{{ template "meta" . }}
 		{{ template "head" . }}
+		{{ canonicalLink }}
+		{{ alternateLinks }}
 	</head>
 
 	<body>
 		{{ template "body" . }}
+		{{ iconSprite }}
+		{{ liveReload }}
 	</body>
 </html>`
 )
 
 var (
 	// ensure layout template is valid
-	_ = template.Must(template.New("layout").Parse(layoutTemplateString))
+	_ = template.Must(template.New("layout").Funcs(builtinFuncs).Parse(layoutTemplateString))
 )
+
+// applyTitle renders the title template in a <title> element, if the page defines it,
+// or else the title of the page's front matter, if any.
+func applyTitle(layout *template.Template, page *Page) error {
+	text := `<title>{{template "title" .}}</title>`
+
+	title := layout.Lookup("title")
+	if title == nil || title.Tree == nil || parse.IsEmptyTree(title.Tree.Root) {
+		if page == nil || page.Title == "" {
+			return nil
+		}
+		text = `<title>{{(page).Title}}</title>`
+	}
+
+	if _, err := layout.New(titleElementName).Parse(text); err != nil {
+		return fmt.Errorf("failed to render title: %w", err)
+	}
+
+	return nil
+}
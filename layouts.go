@@ -0,0 +1,167 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+const (
+	// directoryLayoutName is the name of the template wrapping the body of a directory and every directory under it.
+	directoryLayoutName = "layout"
+	// pageContentName is the template the body of the page is moved to when it is wrapped by directory layouts.
+	pageContentName = "htmplx:content"
+)
+
+// completeLayout applies what depends on every template being loaded, before the layout is executed.
+func (h requestHandler) completeLayout(layout *template.Template, route *Route) error {
+	if err := h.applyLayouts(layout, route); err != nil {
+		return err
+	}
+	return applyTitle(layout, h.page)
+}
+
+// applyLayouts wraps the body of the page in the layout.html.tmpl of each directory along the route,
+// the root's outermost. A directory layout renders what it wraps with {{template "body" .}}:
+//
+//	<div class="admin">{{template "admin-nav" .}}{{template "body" .}}</div>
+//
+// A layout.VIEW.html.tmpl replaces layout.html.tmpl when rendering the view.
+func (h requestHandler) applyLayouts(layout *template.Template, route *Route) error {
+	var layouts []string
+
+	for i := 0; i <= len(route.Dirs); i++ {
+		filename, err := h.directoryLayoutFile(strings.Join(route.Dirs[:i], "/"))
+		if err != nil {
+			return err
+		}
+		if filename != "" {
+			layouts = append(layouts, filename)
+		}
+	}
+
+	if len(layouts) == 0 {
+		return nil
+	}
+
+	body := layout.Lookup("body")
+	if body == nil || body.Tree == nil {
+		return nil
+	}
+	if _, err := layout.AddParseTree(pageContentName, body.Tree); err != nil {
+		return fmt.Errorf("failed to wrap body in directory layouts: %w", err)
+	}
+
+	// each layout's {{template "body" .}} renders the next layout in, and the innermost the page body.
+	for i, filename := range layouts {
+		h.log.Debug("wrapping body in " + filename)
+
+		name := "body"
+		if i > 0 {
+			name = directoryLayoutTemplateName(i)
+		}
+		wrapped := pageContentName
+		if i < len(layouts)-1 {
+			wrapped = directoryLayoutTemplateName(i + 1)
+		}
+
+		b, err := h.readFile(filename)
+		if err != nil {
+			return err
+		}
+		b = h.annotateSource(filename, b)
+		if b, err = h.stripFrontMatter(filename, b); err != nil {
+			return err
+		}
+
+		trees, ok := h.precompiledTrees(name, b)
+		if !ok {
+			t := parse.New(name)
+			t.Mode = parse.SkipFuncCheck
+			trees = make(map[string]*parse.Tree)
+			if _, err := t.Parse(string(b), "", "", trees); err != nil {
+				return fmt.Errorf("failed to parse %s: %w", filename, err)
+			}
+		}
+
+		for treeName, tree := range trees {
+			renameTemplateCalls(tree.Root, "body", wrapped)
+			if _, err := layout.AddParseTree(treeName, tree); err != nil {
+				return fmt.Errorf("failed to add %s: %w", filename, err)
+			}
+		}
+		if _, ok := trees[name]; !ok {
+			// an empty layout renders only what it wraps.
+			if _, err := layout.New(name).Parse(`{{template "` + wrapped + `" .}}`); err != nil {
+				return fmt.Errorf("failed to add %s: %w", filename, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// directoryLayoutFile returns the layout file of dir, if any.
+func (h requestHandler) directoryLayoutFile(dir string) (string, error) {
+	var candidates []string
+	if h.view != "" {
+		for _, ext := range h.templateExts {
+			candidates = append(candidates, joinPath(dir, directoryLayoutName+"."+h.view+ext))
+		}
+	}
+	for _, ext := range h.templateExts {
+		candidates = append(candidates, joinPath(dir, directoryLayoutName+ext))
+	}
+
+	for _, filename := range candidates {
+		if _, err := fs.Stat(h.fs, filename); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return "", fmt.Errorf("failed to look up %s: %w", filename, err)
+		}
+		return filename, nil
+	}
+
+	return "", nil
+}
+
+func directoryLayoutTemplateName(depth int) string {
+	return "htmplx:layout:" + strconv.Itoa(depth)
+}
+
+// isDirectoryLayout reports whether the template named name is a directory layout, or a view of one,
+// which wraps the body rather than replacing the page layout.
+func isDirectoryLayout(name string) bool {
+	return name == directoryLayoutName || strings.HasPrefix(name, directoryLayoutName+".")
+}
+
+// renameTemplateCalls points every {{template from ...}} under node at to instead.
+func renameTemplateCalls(node parse.Node, from, to string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			renameTemplateCalls(c, from, to)
+		}
+	case *parse.TemplateNode:
+		if n.Name == from {
+			n.Name = to
+		}
+	case *parse.IfNode:
+		renameTemplateCalls(n.List, from, to)
+		renameTemplateCalls(n.ElseList, from, to)
+	case *parse.RangeNode:
+		renameTemplateCalls(n.List, from, to)
+		renameTemplateCalls(n.ElseList, from, to)
+	case *parse.WithNode:
+		renameTemplateCalls(n.List, from, to)
+		renameTemplateCalls(n.ElseList, from, to)
+	}
+}
@@ -0,0 +1,290 @@
+package htmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// LintIssue is a problem found in a rendered page by Lint.
+type LintIssue struct {
+	// Route is the url path of the page, e.g. /contact.
+	Route string
+	// Rule is the name of the rule the page breaks, e.g. img-alt.
+	Rule string
+	// Line is the line of the page the offending element is on.
+	Line int
+	// Element is the offending tag, as rendered.
+	Element string
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s: %s", i.Route, i.Line, i.Rule, i.Message, i.Element)
+}
+
+// lintRule checks the tags of a rendered page, returning the issues found without their route.
+type lintRule func(tags []htmlTag) []LintIssue
+
+// lintRules are the rules pages are checked against by Lint.
+var lintRules = []lintRule{
+	lintImageAlt,
+	lintInputLabels,
+	lintDuplicateIDs,
+	lintHTMXTargets,
+}
+
+// Lint renders the routes, discarding the pages, and checks the html of each for basic accessibility issues:
+// images without alt text, form fields without a label, and duplicate ids, which may come from different
+// templates composed into the page. It checks the htmx wiring of each too: that the urls of hx-get, hx-post
+// and the like are routes of the tree served the method, and that hx-target ids are of elements of the page.
+// Every route not under a regex directory is checked if routes is nil.
+// Pages are rendered with the data callback, such as one serving fixtures, so run it against a fixture tree
+// to cover the pages' branches. The error joins the routes failing to render, if any.
+func (h *Handler[D]) Lint(ctx context.Context, routes []string) ([]LintIssue, error) {
+	explicit := routes != nil
+	if !explicit {
+		var err error
+		if routes, err = h.exportableRoutes(h.fs); err != nil {
+			return nil, err
+		}
+	}
+
+	jobs := make([]RenderJob[D], len(routes))
+	for i, route := range routes {
+		jobs[i] = RenderJob[D]{Route: route}
+	}
+
+	var issues []LintIssue
+	var errs []error
+	for i, res := range h.RenderAll(ctx, jobs, runtime.GOMAXPROCS(0)) {
+		if res.Err != nil {
+			if explicit || res.Status != http.StatusNotFound && res.Status != http.StatusGone {
+				errs = append(errs, res.Err)
+			}
+			continue
+		}
+		if mediaType, _, _ := mime.ParseMediaType(res.ContentType); mediaType != "text/html" {
+			continue
+		}
+		issues = append(issues, h.lintPage(routes[i], string(res.Body))...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return issues, fmt.Errorf("failed to lint:\n%w", err)
+	}
+
+	return issues, nil
+}
+
+// lintPage checks the html of the page rendered for route against every rule.
+func (h *Handler[D]) lintPage(route, page string) []LintIssue {
+	tags := scanHTMLTags(page)
+
+	var issues []LintIssue
+	for _, issue := range h.lintHTMXRequests(route, tags) {
+		issue.Route = route
+		issues = append(issues, issue)
+	}
+	for _, rule := range lintRules {
+		for _, issue := range rule(tags) {
+			issue.Route = route
+			issues = append(issues, issue)
+		}
+	}
+	slices.SortStableFunc(issues, func(a, b LintIssue) int { return a.Line - b.Line })
+	return issues
+}
+
+// lintIssue is an issue of rule with the tag.
+func lintIssue(rule string, tag htmlTag, message string) LintIssue {
+	element := tag.source
+	if len(element) > 120 {
+		element = element[:117] + "..."
+	}
+	return LintIssue{Rule: rule, Line: tag.line, Element: element, Message: message}
+}
+
+// lintImageAlt reports images without alt text. An empty alt marks a decorative image, so is allowed.
+func lintImageAlt(tags []htmlTag) []LintIssue {
+	var issues []LintIssue
+	for _, tag := range tags {
+		if tag.name == "img" && !tag.end && !tag.has("alt") {
+			issues = append(issues, lintIssue("img-alt", tag, "image without alt text"))
+		}
+	}
+	return issues
+}
+
+// unlabeledInputTypes are the input types labeled by their value, or not shown.
+var unlabeledInputTypes = []string{"hidden", "submit", "reset", "button", "image"}
+
+// lintInputLabels reports form fields without a label: neither inside a <label>, nor referenced by the for
+// attribute of one, nor with an aria-label, aria-labelledby or title.
+func lintInputLabels(tags []htmlTag) []LintIssue {
+	labeled := make(map[string]bool)
+	for _, tag := range tags {
+		if tag.name == "label" && !tag.end && tag.attrs["for"] != "" {
+			labeled[tag.attrs["for"]] = true
+		}
+	}
+
+	var issues []LintIssue
+	labels := 0
+	for _, tag := range tags {
+		switch tag.name {
+		case "label":
+			if tag.end {
+				labels = max(labels-1, 0)
+			} else {
+				labels++
+			}
+			continue
+		case "input", "select", "textarea":
+		default:
+			continue
+		}
+		if tag.end || labels > 0 {
+			continue
+		}
+		if tag.name == "input" && slices.Contains(unlabeledInputTypes, strings.ToLower(tag.attrs["type"])) {
+			continue
+		}
+		if tag.has("aria-label") || tag.has("aria-labelledby") || tag.has("title") || labeled[tag.attrs["id"]] {
+			continue
+		}
+		issues = append(issues, lintIssue("input-label", tag, "form field without a label"))
+	}
+	return issues
+}
+
+// lintDuplicateIDs reports elements with the id of an earlier element, which breaks labels, fragment links
+// and htmx targets.
+func lintDuplicateIDs(tags []htmlTag) []LintIssue {
+	var issues []LintIssue
+	for _, d := range findDuplicateIDs(tags) {
+		issues = append(issues, lintIssue("duplicate-id", d.tag, fmt.Sprintf("id %q already used on line %d", d.id, d.first)))
+	}
+	return issues
+}
+
+// htmxRequestAttrs are the htmx attributes issuing requests, by the method of the requests.
+var htmxRequestAttrs = []struct{ attr, method string }{
+	{"hx-get", http.MethodGet},
+	{"hx-post", http.MethodPost},
+	{"hx-put", http.MethodPut},
+	{"hx-patch", http.MethodPatch},
+	{"hx-delete", http.MethodDelete},
+}
+
+// lintHTMXRequests reports htmx requests to urls which are neither a route of the tree nor a static file,
+// or to routes not served their method, see HandleMethod. Relative urls are resolved against route.
+// Urls of other hosts, or outside the base path, are not checked.
+func (h *Handler[D]) lintHTMXRequests(route string, tags []htmlTag) []LintIssue {
+	rh := h.newRequestHandler(h.log)
+	base := &url.URL{Path: withBasePath(h.basePath, route)}
+
+	var issues []LintIssue
+	for _, tag := range tags {
+		for _, a := range htmxRequestAttrs {
+			value, ok := tag.attrs[a.attr]
+			if !ok || tag.end {
+				continue
+			}
+			if message := h.checkHTMXRequest(rh, base, a.method, strings.TrimSpace(value)); message != "" {
+				issues = append(issues, lintIssue("htmx-url", tag, message))
+			}
+		}
+	}
+	return issues
+}
+
+// checkHTMXRequest returns why a request with method to the url rawURL would fail, or "" if it would not.
+func (h *Handler[D]) checkHTMXRequest(rh requestHandler, base *url.URL, method, rawURL string) string {
+	// an empty url requests the current page.
+	if rawURL == "" {
+		rawURL = base.Path
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Sprintf("malformed url %q", rawURL)
+	}
+	if u.Scheme != "" || u.Host != "" {
+		return ""
+	}
+	u = base.ResolveReference(u)
+
+	urlPath := u.Path
+	if h.basePath != "" {
+		if urlPath != h.basePath && !strings.HasPrefix(urlPath, h.basePath+"/") {
+			return ""
+		}
+		urlPath = strings.TrimPrefix(urlPath, h.basePath)
+	}
+	if urlPath == "" {
+		urlPath = "/"
+	}
+
+	if path.Ext(urlPath) != "" {
+		if method != http.MethodGet {
+			return fmt.Sprintf("%s %s: static files are only served to GET requests", method, rawURL)
+		}
+		filename := strings.Join(splitPath(urlPath), "/")
+		if original, ok := rh.resolveAsset(filename); ok {
+			filename = original
+		}
+		if _, err := fs.Stat(rh.fs, filename+textTemplateExt); err == nil {
+			return ""
+		}
+		if info, err := fs.Stat(rh.fs, filename); err != nil || info.IsDir() || rh.isHiddenFile(filename) {
+			return fmt.Sprintf("%s %s: no such file", method, rawURL)
+		}
+		return ""
+	}
+
+	r, err := rh.resolveRoute(urlPath)
+	if err != nil {
+		return fmt.Sprintf("%s %s: no such route", method, rawURL)
+	}
+	if method == http.MethodGet {
+		return ""
+	}
+	if _, ok := h.methodHandlers[r.Pattern()][method]; !ok {
+		return fmt.Sprintf("%s %s: route %s is not served %s requests", method, rawURL, r.Pattern(), method)
+	}
+	return ""
+}
+
+// lintHTMXTargets reports hx-target selectors of an id no element of the page has, so htmx would find
+// nothing to swap. Only plain id selectors, e.g. #results, are checked; relative ones such as closest tr are not.
+func lintHTMXTargets(tags []htmlTag) []LintIssue {
+	ids := make(map[string]bool)
+	for _, tag := range tags {
+		if id, ok := tag.attrs["id"]; ok && !tag.end {
+			ids[id] = true
+		}
+	}
+
+	var issues []LintIssue
+	for _, tag := range tags {
+		target, ok := tag.attrs["hx-target"]
+		if !ok || tag.end {
+			continue
+		}
+		id, ok := strings.CutPrefix(strings.TrimSpace(target), "#")
+		if !ok || id == "" || strings.ContainsAny(id, " .:[>+~,#") {
+			continue
+		}
+		if !ids[id] {
+			issues = append(issues, lintIssue("htmx-target", tag, fmt.Sprintf("no element with id %q", id)))
+		}
+	}
+	return issues
+}
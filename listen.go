@@ -0,0 +1,98 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// firstSystemdFD is the first file descriptor passed by systemd socket activation.
+const firstSystemdFD = 3
+
+// Listen listens on addr, which is one of:
+//
+//	:8080, localhost:8080  a TCP address
+//	unix:/run/site.sock    a unix socket, e.g. behind nginx or caddy on the same host
+//	systemd                the first socket passed by systemd socket activation
+//	systemd:web            the socket passed by systemd with FileDescriptorName=web
+//
+// A stale unix socket file, left behind by a previous process, is replaced.
+//
+//	l, err := htmplx.Listen("unix:/run/site.sock")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	log.Fatal(http.Serve(l, h))
+func Listen(addr string) (net.Listener, error) {
+	switch {
+	case addr == "systemd":
+		return systemdListener("")
+	case strings.HasPrefix(addr, "systemd:"):
+		return systemdListener(strings.TrimPrefix(addr, "systemd:"))
+	case strings.HasPrefix(addr, "unix:"):
+		return listenUnix(strings.TrimPrefix(addr, "unix:"))
+	default:
+		return net.Listen("tcp", addr)
+	}
+}
+
+func listenUnix(name string) (net.Listener, error) {
+	if info, err := os.Stat(name); err == nil && info.Mode().Type() == fs.ModeSocket {
+		// a socket nothing is listening on is stale.
+		if conn, err := net.DialTimeout("unix", name, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("unix socket %s is in use", name)
+		}
+		if err := os.Remove(name); err != nil {
+			return nil, fmt.Errorf("failed to remove stale unix socket %s: %w", name, err)
+		}
+	}
+
+	l, err := net.Listen("unix", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// systemdListener returns the socket passed by systemd socket activation named name, or the first if name is empty.
+// See sd_listen_fds(3).
+func systemdListener(name string) (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.New("no sockets passed by systemd: LISTEN_PID is not this process")
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, errors.New("no sockets passed by systemd: LISTEN_FDS is not set")
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	for i := 0; i < n; i++ {
+		fdName := ""
+		if i < len(names) {
+			fdName = names[i]
+		}
+		if name != "" && fdName != name {
+			continue
+		}
+
+		f := os.NewFile(uintptr(firstSystemdFD+i), fdName)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("systemd socket %d is not a listener: %w", firstSystemdFD+i, err)
+		}
+
+		return l, nil
+	}
+
+	return nil, fmt.Errorf("no socket named %s passed by systemd", name)
+}
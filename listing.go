@@ -0,0 +1,185 @@
+package htmplx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// listingTemplateName is the template rendering a directory listing, defined by a listing.html.tmpl
+// looked up along the route and then at the root, or else defaultListingTemplate.
+const listingTemplateName = "listing"
+
+const defaultListingTemplate = `<h1>Index of {{.Path}}</h1>
+<table>
+	<thead><tr><th>Name</th><th>Size</th><th>Modified</th></tr></thead>
+	<tbody>
+		{{- if .Parent}}
+		<tr><td><a href="{{.Parent}}">../</a></td><td></td><td></td></tr>
+		{{- end}}
+		{{- range .Entries}}
+		<tr>
+			<td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td>
+			<td>{{if not .IsDir}}{{.Size}}{{end}}</td>
+			<td>{{if not .ModTime.IsZero}}{{.ModTime.UTC.Format "2006-01-02 15:04"}}{{end}}</td>
+		</tr>
+		{{- end}}
+	</tbody>
+</table>`
+
+// WithDirectoryListing enables listing the files and subdirectories of directories without a body,
+// or index document, of their own, such as directories of downloads.
+// A listing is rendered in the layout with the listing template, defined by a listing.html.tmpl along the route
+// or at the root, with a DirectoryListing as its data. Hidden files are not listed.
+func (h *Handler[D]) WithDirectoryListing(enabled bool) *Handler[D] {
+	h.directoryListing = enabled
+	return h
+}
+
+// DirectoryListing is the data of a directory listing.
+type DirectoryListing struct {
+	// Path is the url path of the directory.
+	Path string
+	// Parent is the url of the parent directory, empty at the root.
+	Parent  string
+	Entries []DirectoryListingEntry
+}
+
+// DirectoryListingEntry is a file or subdirectory of a listed directory.
+type DirectoryListingEntry struct {
+	Name    string
+	URL     string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+// renderDirectoryListing renders the listing of the route's directory, if listings are enabled and it has
+// no body of its own. out is nil otherwise.
+func (h *Handler[D]) renderDirectoryListing(r *http.Request, rh requestHandler, route *Route) (
+	out io.ReadCloser,
+	contentType string,
+	err error,
+) {
+	if !h.directoryListing {
+		return nil, "", nil
+	}
+	if found, err := rh.hasBodyFile(route.Dir()); err != nil || found {
+		return nil, "", err
+	}
+	if found, err := rh.hasTextBody(route); err != nil || found {
+		return nil, "", err
+	}
+
+	rh.log.Debug("listing directory")
+
+	listing, err := rh.listDirectory(route)
+	if err != nil {
+		return nil, "", err
+	}
+
+	layout, err := h.newLayout(r, rh)
+	if err != nil {
+		return nil, "", err
+	}
+	// the listing is the body, whether or not one is defined along the route.
+	if err := rh.loadTemplates(layout, route); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, "", err
+	}
+	if layout.Lookup(listingTemplateName) == nil {
+		found := false
+		for _, ext := range rh.templateExts {
+			if _, err := rh.loadTemplate(layout, listingTemplateName, listingTemplateName+ext); err == nil {
+				found = true
+				break
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, "", err
+			}
+		}
+		if !found {
+			if _, err := layout.New(listingTemplateName).Parse(defaultListingTemplate); err != nil {
+				return nil, "", fmt.Errorf("failed to parse listing template: %w", err)
+			}
+		}
+	}
+	if _, err := layout.New("body").Parse(`{{template "` + listingTemplateName + `" .}}`); err != nil {
+		return nil, "", fmt.Errorf("failed to use listing template: %w", err)
+	}
+	if err := rh.completeLayout(layout, route); err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	if err := h.sandbox.execute(&buf, func(w io.Writer) error {
+		return layout.Execute(w, listing)
+	}); err != nil {
+		return nil, "", fmt.Errorf("failed to execute listing template: %w", err)
+	}
+
+	return io.NopCloser(&buf), "text/html", nil
+}
+
+// listDirectory lists the visible files and subdirectories of the route's directory, directories first.
+func (h requestHandler) listDirectory(route *Route) (DirectoryListing, error) {
+	listing := DirectoryListing{Path: route.Path}
+	if route.Path != "/" {
+		listing.Parent = h.withBasePath(path.Dir(strings.TrimSuffix(route.Path, "/")))
+	}
+
+	dir := route.Dir()
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := fs.ReadDir(h.fs, dir)
+	if err != nil {
+		return listing, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if h.hiddenFiles.hides(name) || isRegexPathPart(name) || dir == "." && isReservedDir(name) {
+			continue
+		}
+		if !e.IsDir() && (h.isHiddenFile(joinPath(route.Dir(), name)) || path.Ext(name) == "") {
+			// templates, and files without an extension, such as status markers, are not served.
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			return listing, fmt.Errorf("failed to stat %s: %w", name, err)
+		}
+
+		entry := DirectoryListingEntry{
+			Name:    name,
+			URL:     h.withBasePath(path.Join(route.Path, name)),
+			IsDir:   e.IsDir(),
+			ModTime: info.ModTime(),
+		}
+		if !e.IsDir() {
+			entry.Size = info.Size()
+		}
+		listing.Entries = append(listing.Entries, entry)
+	}
+
+	slices.SortStableFunc(listing.Entries, func(a, b DirectoryListingEntry) int {
+		switch {
+		case a.IsDir == b.IsDir:
+			return strings.Compare(a.Name, b.Name)
+		case a.IsDir:
+			return -1
+		default:
+			return 1
+		}
+	})
+
+	return listing, nil
+}
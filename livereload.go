@@ -0,0 +1,262 @@
+package htmplx
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// liveReloadPath is the event stream telling pages to reload.
+	liveReloadPath = "/_htmplx/livereload"
+	// liveReloadInterval is how often the file system is checked for changes.
+	liveReloadInterval = 500 * time.Millisecond
+)
+
+// liveReloadScript reloads the page when the event stream reports a change.
+// Pages using htmx have their body swapped in place, keeping the scroll position.
+// A change breaking templates is reported instead with their errors, shown over the page until the next change.
+// The event stream's url is formatted in with the base path.
+const liveReloadScript = `<script>
+(() => {
+	const events = new EventSource("%s");
+	events.addEventListener("reload", () => {
+		document.getElementById("htmplx-errors")?.remove();
+		if (window.htmx) {
+			htmx.ajax("GET", location.href, { target: "body", swap: "outerHTML" });
+		} else {
+			location.reload();
+		}
+	});
+	events.addEventListener("template-errors", (e) => {
+		document.getElementById("htmplx-errors")?.remove();
+		const overlay = document.createElement("div");
+		overlay.id = "htmplx-errors";
+		overlay.style.cssText = "` + errorOverlayStyle + `";
+		overlay.title = "Click to dismiss";
+		overlay.onclick = () => overlay.remove();
+		const heading = document.createElement("h2");
+		heading.textContent = "Template errors";
+		overlay.append(heading);
+		for (const err of JSON.parse(e.data).errors) {
+			const pre = document.createElement("pre");
+			pre.style.cssText = "white-space:pre-wrap";
+			pre.textContent = err.message;
+			overlay.append(pre);
+		}
+		document.body.append(overlay);
+	});
+})();
+</script>`
+
+// WithLiveReload watches the file system for changes while enabled, for development.
+// Rendered pages include a script that reloads them whenever a template or static file changes,
+// and cached asset fingerprints are discarded. The tree is validated on every change, and while it is invalid
+// its errors are shown over open pages, and served in place of pages navigated to, rather than 500 responses.
+func (h *Handler[D]) WithLiveReload(enabled bool) *Handler[D] {
+	if !enabled {
+		h.liveReload = nil
+		return h
+	}
+	h.liveReload = &liveReload{changed: make(chan struct{})}
+	return h
+}
+
+// liveReload broadcasts changes of the file system to the pages connected to its event stream.
+type liveReload struct {
+	mu sync.Mutex
+	// changed is closed, and replaced, when the file system changes.
+	changed chan struct{}
+	// errors are the errors of the tree since its last change, if it is invalid.
+	errors []string
+	// pages is the number of pages connected.
+	pages int
+	// watching is set while the file system is watched, which it is while pages are connected.
+	watching bool
+	// snapshot is the last snapshot of the file system watched.
+	snapshot string
+}
+
+func (lr *liveReload) wait() <-chan struct{} {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.changed
+}
+
+// notify tells the pages of a change, with the errors of the tree, if any.
+func (lr *liveReload) notify(errors []string) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.errors = errors
+	close(lr.changed)
+	lr.changed = make(chan struct{})
+}
+
+// templateErrors are the errors of the tree since its last change.
+func (lr *liveReload) templateErrors() []string {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.errors
+}
+
+// connect counts a page connected, reporting whether the file system must start being watched.
+func (lr *liveReload) connect() bool {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.pages++
+	start := !lr.watching
+	lr.watching = true
+	return start
+}
+
+// disconnect counts a page disconnected.
+func (lr *liveReload) disconnect() {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.pages--
+}
+
+// stopIfIdle reports whether watching the file system stops, as no page is connected.
+func (lr *liveReload) stopIfIdle() bool {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	if lr.pages > 0 {
+		return false
+	}
+	lr.watching = false
+	return true
+}
+
+// watchFS polls the file system for changes while pages are connected, counting the page connecting,
+// which must call disconnect once gone. Changes made while no page was connected are picked up
+// once one connects.
+func (h *Handler[D]) watchFS() {
+	lr := h.liveReload
+	if !lr.connect() {
+		return
+	}
+
+	go func() {
+		l := h.log.With("interval", liveReloadInterval)
+		l.Debug("watching for changes")
+
+		lr.mu.Lock()
+		last := lr.snapshot
+		lr.mu.Unlock()
+		if last == "" {
+			var err error
+			if last, err = fsSnapshot(h.fs); err != nil {
+				l.With("error", err).
+					Error("failed to watch for changes")
+			}
+		}
+
+		t := time.NewTicker(liveReloadInterval)
+		defer t.Stop()
+
+		for range t.C {
+			snapshot, err := fsSnapshot(h.fs)
+			if err != nil {
+				l.With("error", err).
+					Error("failed to watch for changes")
+				continue
+			}
+			if snapshot != last {
+				last = snapshot
+				h.treeChanged()
+
+				errs := splitErrors(h.Validate())
+				if len(errs) > 0 {
+					l.With("errors", len(errs)).Warn("files changed, showing template errors on pages")
+				} else {
+					l.Info("files changed, reloading pages")
+				}
+				lr.notify(errs)
+			}
+
+			lr.mu.Lock()
+			lr.snapshot = last
+			lr.mu.Unlock()
+			if lr.stopIfIdle() {
+				l.Debug("no page connected, stopped watching for changes")
+				return
+			}
+		}
+	}()
+}
+
+// fsSnapshot summarizes the names, sizes and modification times of every file, to detect changes.
+func fsSnapshot(fsys fs.FS) (string, error) {
+	var snapshot []byte
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		snapshot = fmt.Appendf(snapshot, "%s %d %d\n", name, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(snapshot), nil
+}
+
+// serveLiveReload streams a reload event to the page whenever the file system changes.
+func (h *Handler[D]) serveLiveReload(w http.ResponseWriter, r *http.Request, l *slog.Logger) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	h.watchFS()
+	defer h.liveReload.disconnect()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	l.Debug("page connected for live reload")
+
+	// a page connecting while the tree is invalid was served before it broke.
+	if errs := h.liveReload.templateErrors(); len(errs) > 0 {
+		writeTemplateErrorsEvent(w, errs)
+		flusher.Flush()
+	}
+
+	for {
+		changed := h.liveReload.wait()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+			if errs := h.liveReload.templateErrors(); len(errs) > 0 {
+				writeTemplateErrorsEvent(w, errs)
+			} else {
+				fmt.Fprint(w, "event: reload\ndata: \n\n")
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// liveReloadScriptFunc returns the live reload script, if enabled, and the script of WithEditor, if enabled.
+func (h requestHandler) liveReloadScriptFunc() template.HTML {
+	script := h.editorScriptFunc()
+	if h.liveReload {
+		script = fmt.Sprintf(liveReloadScript, template.JSEscapeString(h.withBasePath(liveReloadPath))) + script
+	}
+	return template.HTML(script)
+}
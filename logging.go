@@ -3,23 +3,153 @@ package htmplx
 import (
 	"log/slog"
 	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/angelbeltran/htmplx/internal/logging"
+)
+
+var (
+	env_htmplx_loglevel  = os.Getenv("HTMPLX_LOGLEVEL")
+	env_htmplx_logformat = os.Getenv("HTMPLX_LOGFORMAT")
+)
+
+// LogFormat is the format of the logs written to stdout.
+type LogFormat int
+
+const (
+	// LogText writes each log as a line of key=value pairs.
+	LogText LogFormat = iota
+	// LogJSON writes each log as a line of JSON, for log collectors.
+	LogJSON
 )
 
-var env_htmplx_loglevel = os.Getenv("HTMPLX_LOGLEVEL")
+// defaultLogFormat is the format set by HTMPLX_LOGFORMAT, text or json, text by default.
+func defaultLogFormat() LogFormat {
+	if strings.EqualFold(env_htmplx_logformat, "json") {
+		return LogJSON
+	}
+	return LogText
+}
+
+func newLogger(levels *logLevels, format LogFormat) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		AddSource: true,
+		// records are filtered by the levels, which may be lowered for a route.
+		Level: slog.LevelDebug,
+	}
+
+	var out slog.Handler
+	if format == LogJSON {
+		out = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		out = slog.NewTextHandler(os.Stdout, opts)
+	}
 
-func newLogger() *slog.Logger {
+	return slog.New(logging.NewLevelHandler(levels.ofAttrs, out))
+}
+
+// WithLogFormat writes the logs to stdout in format, in place of that set by HTMPLX_LOGFORMAT, text or json,
+// or the handlers set by WithLogHandlers.
+func (h *Handler[D]) WithLogFormat(format LogFormat) *Handler[D] {
+	h.log = newLogger(h.logLevels, format)
+	return h
+}
+
+// SetLogLevel sets the minimum level logged, in place of that set by HTMPLX_LOGLEVEL, e.g. to debug an instance
+// without restarting it. Requests whose path matches a pattern of SetRouteLogLevel are logged at its level instead.
+// It may be called while serving requests.
+func (h *Handler[D]) SetLogLevel(level slog.Level) {
+	h.logLevels.base.Set(level)
+}
+
+// LogLevel is the minimum level logged, outside of the routes of SetRouteLogLevel.
+func (h *Handler[D]) LogLevel() slog.Level {
+	return h.logLevels.base.Level()
+}
+
+// WithLogHandlers tees the handler's logs to each of handlers, such as a text handler writing to stdout and
+// one exporting to a collector, in place of the default text handler writing to stdout.
+// The level of the logs is decided by the handler, from HTMPLX_LOGLEVEL and SetRouteLogLevel, so handlers
+// should be created to handle debug logs.
+func (h *Handler[D]) WithLogHandlers(handlers ...slog.Handler) *Handler[D] {
+	h.log = slog.New(logging.NewLevelHandler(h.logLevels.ofAttrs, handlers...))
+	return h
+}
+
+// SetRouteLogLevel logs requests whose path matches pattern at level, e.g. at debug for a route being
+// investigated while the rest are logged at info. The pattern is matched with path.Match, e.g. /blog/*.
+// It may be called while serving requests.
+func (h *Handler[D]) SetRouteLogLevel(pattern string, level slog.Level) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+
+	h.logLevels.mu.Lock()
+	defer h.logLevels.mu.Unlock()
+
+	if h.logLevels.routes == nil {
+		h.logLevels.routes = make(map[string]slog.Level)
+	}
+	h.logLevels.routes[pattern] = level
+
+	return nil
+}
+
+// ResetRouteLogLevel logs requests whose path matches pattern at the default level again.
+func (h *Handler[D]) ResetRouteLogLevel(pattern string) {
+	h.logLevels.mu.Lock()
+	defer h.logLevels.mu.Unlock()
+
+	delete(h.logLevels.routes, pattern)
+}
+
+// logLevels are the minimum levels logged, by default and for the paths matching a route's pattern.
+type logLevels struct {
+	base slog.LevelVar
+
+	mu     sync.RWMutex
+	routes map[string]slog.Level
+}
+
+func newLogLevels() *logLevels {
 	var lvl slog.Level
 	if err := lvl.UnmarshalText([]byte(env_htmplx_loglevel)); err != nil {
 		lvl = slog.LevelInfo
 	}
+	levels := &logLevels{}
+	levels.base.Set(lvl)
+	return levels
+}
+
+// level is the minimum level logged for urlPath, the lowest level of the patterns it matches, if any.
+func (l *logLevels) level(urlPath string) slog.Level {
+	if urlPath == "" {
+		return l.base.Level()
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 
-	return slog.New(
-		slog.NewTextHandler(
-			os.Stdout,
-			&slog.HandlerOptions{
-				AddSource: true,
-				Level:     lvl,
-			},
-		),
-	)
+	lvl, matched := l.base.Level(), false
+	for pattern, routeLvl := range l.routes {
+		if ok, _ := path.Match(pattern, urlPath); ok && (!matched || routeLvl < lvl) {
+			lvl, matched = routeLvl, true
+		}
+	}
+
+	return lvl
+}
+
+// requestLogGroup is the group of the attributes of the request logged, such as its path, see requestLog.
+const requestLogGroup = "request"
+
+// ofAttrs is the minimum level logged for a logger with attrs, that of the path of the request logged, if any.
+func (l *logLevels) ofAttrs(attrs map[string]slog.Value) slog.Level {
+	urlPath := attrs[requestLogGroup+".path"]
+	if urlPath.Kind() != slog.KindString {
+		return l.base.Level()
+	}
+	return l.level(urlPath.String())
 }
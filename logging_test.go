@@ -0,0 +1,46 @@
+package htmplx
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+func TestRouteLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler[RequestDataMap](htmplxtest.FS(map[string]string{
+		"body.html.tmpl":              `<main>home</main>`,
+		"checkout/pay/body.html.tmpl": `<main>pay</main>`,
+	})).WithLogHandlers(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})).
+		WithRequestIDs(true)
+	h.SetLogLevel(slog.LevelInfo)
+	if err := h.SetRouteLogLevel("/checkout/*", slog.LevelDebug); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/", "/checkout/pay"} {
+		if w := get(h, path); w.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d", path, w.Code)
+		}
+	}
+
+	var debug []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "level=DEBUG") {
+			debug = append(debug, line)
+		}
+	}
+	if len(debug) == 0 {
+		t.Fatalf("got no debug logs, want those of /checkout/pay in %q", buf.String())
+	}
+	// the request's attributes are in its group, by which the level of the route is resolved.
+	for _, line := range debug {
+		if !strings.Contains(line, "request.path=/checkout/pay request.id=") {
+			t.Errorf("got debug log %q, want only those of /checkout/pay, in the request group", line)
+		}
+	}
+}
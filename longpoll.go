@@ -0,0 +1,118 @@
+package htmplx
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Notifier tells long polling requests that the data of a page changed.
+// It is safe for concurrent use.
+type Notifier struct {
+	mu sync.Mutex
+	// changed holds a channel for each path being waited on, closed when it changes.
+	changed map[string]chan struct{}
+	// all is closed when every page changes.
+	all chan struct{}
+}
+
+func NewNotifier() *Notifier {
+	return &Notifier{
+		changed: make(map[string]chan struct{}),
+		all:     make(chan struct{}),
+	}
+}
+
+// Notify wakes the requests waiting on the url paths, e.g. /orders/42.
+func (n *Notifier) Notify(paths ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, p := range paths {
+		if ch, ok := n.changed[canonicalPath(p)]; ok {
+			close(ch)
+			delete(n.changed, canonicalPath(p))
+		}
+	}
+}
+
+// NotifyAll wakes every waiting request.
+func (n *Notifier) NotifyAll() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for p, ch := range n.changed {
+		close(ch)
+		delete(n.changed, p)
+	}
+	close(n.all)
+	n.all = make(chan struct{})
+}
+
+func (n *Notifier) wait(urlPath string) (changed, all <-chan struct{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	p := canonicalPath(urlPath)
+	ch, ok := n.changed[p]
+	if !ok {
+		ch = make(chan struct{})
+		n.changed[p] = ch
+	}
+
+	return ch, n.all
+}
+
+// longPoll is the configuration of WithLongPolling.
+type longPoll struct {
+	notifier *Notifier
+	timeout  time.Duration
+}
+
+// WithLongPolling holds requests for a page whose data is unchanged, per WithChangeTokens,
+// until the notifier is notified of a change to its path or the timeout passes, then renders it.
+// It gives near real time updates where server-sent events and websockets are not an option.
+// A fragment polls itself as soon as it is loaded:
+//
+//	<div hx-get="{{pollURL}}" hx-trigger="load" hx-swap="outerHTML">...</div>
+//
+// The fragment is rendered on timeout too, even if unchanged, so that it polls again.
+// Change tokens are enabled along with it.
+func (h *Handler[D]) WithLongPolling(notifier *Notifier, timeout time.Duration) *Handler[D] {
+	h.changeTokens = true
+	h.longPoll = &longPoll{notifier: notifier, timeout: timeout}
+	return h
+}
+
+// pollWatch holds the channels closed when the page of a long polling request changes.
+type pollWatch struct {
+	changed, all <-chan struct{}
+}
+
+// watch starts watching the page of the request, if it is long polling, before its data is loaded,
+// so that no change notified while it is loading is missed. It returns nil otherwise.
+func (p *longPoll) watch(r *http.Request) *pollWatch {
+	if p == nil || !r.URL.Query().Has(sinceParam) {
+		return nil
+	}
+
+	changed, all := p.notifier.wait(r.URL.Path)
+	return &pollWatch{changed: changed, all: all}
+}
+
+// waitForChange holds the request until its page changes, as watched since before its data was loaded,
+// or the timeout passes, returning an error if the request is canceled first.
+func (p *longPoll) waitForChange(r *http.Request, watch *pollWatch) error {
+	t := time.NewTimer(p.timeout)
+	defer t.Stop()
+
+	select {
+	case <-r.Context().Done():
+		return r.Context().Err()
+	case <-watch.changed:
+	case <-watch.all:
+	case <-t.C:
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package htmplx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+func TestLongPollNotifiedWhileLoading(t *testing.T) {
+	notifier := NewNotifier()
+	var version atomic.Int32
+	version.Store(1)
+
+	h := newTestHandler(htmplxtest.FS(map[string]string{
+		"orders/body.html.tmpl": `<main>version {{.version}}</main>`,
+	})).WithData(func(r *http.Request) RequestDataMap {
+		data := RequestDataMap{"version": int(version.Load())}
+		// the data changes, and the change is notified, just after it is loaded.
+		if version.CompareAndSwap(1, 2) {
+			notifier.Notify("/orders")
+		}
+		return data
+	}).WithLongPolling(notifier, 5*time.Second)
+
+	since := dataChangeToken(RequestDataMap{"version": 1})
+	start := time.Now()
+	w := get(h, "/orders?since="+since)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %s, want the change notified while loading not to be missed", elapsed)
+	}
+	if !strings.Contains(w.Body.String(), "version 2") {
+		t.Errorf("got %d %q, want version 2", w.Code, w.Body)
+	}
+}
+
+func TestLongPollCanceled(t *testing.T) {
+	var reported atomic.Int32
+	h := newTestHandler(htmplxtest.FS(map[string]string{
+		"orders/body.html.tmpl": `<main>version {{.version}}</main>`,
+	})).WithData(func(r *http.Request) RequestDataMap {
+		return RequestDataMap{"version": 1}
+	}).WithLongPolling(NewNotifier(), 5*time.Second).
+		WithErrorReporter(func(ctx context.Context, r *http.Request, err error) {
+			reported.Add(1)
+		})
+
+	// the client disconnects while the request waits.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	since := dataChangeToken(RequestDataMap{"version": 1})
+	r := httptest.NewRequest(http.MethodGet, "/orders?since="+since, nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code == http.StatusInternalServerError || w.Body.Len() != 0 {
+		t.Errorf("got %d %q, want nothing written to the client gone", w.Code, w.Body)
+	}
+	if reported.Load() != 0 {
+		t.Errorf("got %d errors reported, want none", reported.Load())
+	}
+}
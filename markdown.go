@@ -0,0 +1,46 @@
+package htmplx
+
+import (
+	"bytes"
+	"io"
+)
+
+// markdownExt is the extension of markdown files, rendered as templates when markdown is enabled.
+const markdownExt = ".md"
+
+// MarkdownRenderer converts Markdown to html.
+// See the markdown package for a dependency free implementation.
+type MarkdownRenderer interface {
+	// RenderMarkdown writes the html of the Markdown in src to dst.
+	RenderMarkdown(dst io.Writer, src []byte) error
+}
+
+// MarkdownRendererFunc adapts a function to a MarkdownRenderer, e.g. to use goldmark:
+//
+//	htmplx.MarkdownRendererFunc(func(dst io.Writer, src []byte) error {
+//		return goldmark.Convert(src, dst)
+//	})
+type MarkdownRendererFunc func(dst io.Writer, src []byte) error
+
+func (f MarkdownRendererFunc) RenderMarkdown(dst io.Writer, src []byte) error {
+	return f(dst, src)
+}
+
+// WithMarkdown renders .md files in route directories with renderer, as templates named after the file,
+// e.g. body.md as the body. A .html.tmpl file of the same name takes precedence.
+// Markdown is content, so template actions in it are not executed. Markdown files are not served.
+func (h *Handler[D]) WithMarkdown(renderer MarkdownRenderer) *Handler[D] {
+	h.markdown = renderer
+	return h
+}
+
+// markdownTemplate renders Markdown to the text of a template rendering its html as is.
+func (h requestHandler) markdownTemplate(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := h.markdown.RenderMarkdown(&buf, src); err != nil {
+		return nil, err
+	}
+
+	// escape template delimiters, such as those in code blocks.
+	return bytes.ReplaceAll(buf.Bytes(), []byte("{{"), []byte(`{{"{{"}}`)), nil
+}
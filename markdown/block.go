@@ -0,0 +1,327 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	atxHeadingRegexp    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextHeadingRegexp = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	thematicBreakRegexp = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fenceRegexp         = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*?)[ \t]*$")
+	blockQuoteRegexp    = regexp.MustCompile(`^ {0,3}> ?`)
+	listItemRegexp      = regexp.MustCompile(`^( {0,3})([-*+]|[0-9]{1,9}[.)])( +|$)`)
+	htmlBlockRegexp     = regexp.MustCompile(`^ {0,3}<(?:/?[A-Za-z][A-Za-z0-9-]*(?:[ \t/>]|$)|!--)`)
+)
+
+type converter struct {
+	b          strings.Builder
+	headingIDs bool
+	ids        map[string]int
+}
+
+// blocks renders lines as a sequence of blocks. Paragraphs of tight list items are not wrapped in <p>.
+func (c *converter) blocks(lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case isBlank(line):
+			i++
+		case fenceRegexp.MatchString(line):
+			i = c.fencedCode(lines, i)
+		case indentation(line) >= 4:
+			i = c.indentedCode(lines, i)
+		case atxHeadingRegexp.MatchString(line):
+			m := atxHeadingRegexp.FindStringSubmatch(line)
+			c.heading(len(m[1]), m[2])
+			i++
+		case thematicBreakRegexp.MatchString(line):
+			c.b.WriteString("<hr>\n")
+			i++
+		case blockQuoteRegexp.MatchString(line):
+			i = c.blockQuote(lines, i)
+		case listItemRegexp.MatchString(line):
+			i = c.list(lines, i)
+		case htmlBlockRegexp.MatchString(line):
+			for ; i < len(lines) && !isBlank(lines[i]); i++ {
+				c.b.WriteString(lines[i])
+				c.b.WriteByte('\n')
+			}
+		default:
+			i = c.paragraph(lines, i, tight)
+		}
+	}
+}
+
+// interruptsParagraph reports whether line starts a block that ends a paragraph.
+func interruptsParagraph(line string) bool {
+	if m := listItemRegexp.FindStringSubmatch(line); m != nil {
+		// only bullets and lists starting at 1 interrupt a paragraph, and not when empty.
+		marker := m[2]
+		return strings.TrimSpace(line[len(m[0]):]) != "" &&
+			(strings.ContainsAny(marker[len(marker)-1:], "-*+") || marker[:len(marker)-1] == "1")
+	}
+	return isBlank(line) ||
+		fenceRegexp.MatchString(line) ||
+		atxHeadingRegexp.MatchString(line) ||
+		thematicBreakRegexp.MatchString(line) ||
+		blockQuoteRegexp.MatchString(line) ||
+		htmlBlockRegexp.MatchString(line)
+}
+
+func (c *converter) paragraph(lines []string, i int, tight bool) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if len(text) > 0 {
+			if m := setextHeadingRegexp.FindStringSubmatch(line); m != nil {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				c.heading(level, strings.Join(text, "\n"))
+				return i + 1
+			}
+			if interruptsParagraph(line) {
+				break
+			}
+		}
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+
+	content := c.inline(strings.TrimRight(strings.Join(text, "\n"), " \t"))
+	if tight {
+		c.b.WriteString(content)
+		c.b.WriteByte('\n')
+	} else {
+		c.b.WriteString("<p>" + content + "</p>\n")
+	}
+
+	return i
+}
+
+func (c *converter) heading(level int, text string) {
+	tag := "h" + strconv.Itoa(level)
+	text = strings.TrimSpace(text)
+
+	c.b.WriteString("<" + tag)
+	if c.headingIDs {
+		c.b.WriteString(` id="` + html.EscapeString(c.headingID(text)) + `"`)
+	}
+	c.b.WriteString(">" + c.inline(text) + "</" + tag + ">\n")
+}
+
+// headingID derives a unique id from the text of a heading, e.g. "getting-started" for "Getting Started".
+func (c *converter) headingID(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			b.WriteByte('-')
+		}
+	}
+
+	id := strings.Trim(b.String(), "-")
+	if id == "" {
+		id = "section"
+	}
+
+	if c.ids == nil {
+		c.ids = make(map[string]int)
+	}
+	n := c.ids[id]
+	c.ids[id] = n + 1
+	if n > 0 {
+		id += "-" + strconv.Itoa(n)
+	}
+
+	return id
+}
+
+func (c *converter) fencedCode(lines []string, i int) int {
+	m := fenceRegexp.FindStringSubmatch(lines[i])
+	indent, fence, info := len(m[1]), m[2], m[3]
+
+	c.b.WriteString("<pre><code")
+	if lang, _, _ := strings.Cut(info, " "); lang != "" {
+		c.b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	c.b.WriteString(">")
+
+	for i++; i < len(lines); i++ {
+		line := lines[i]
+		if trimmed := strings.TrimSpace(line); indentation(line) < 4 &&
+			strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		c.b.WriteString(html.EscapeString(dedent(line, indent)) + "\n")
+	}
+
+	c.b.WriteString("</code></pre>\n")
+
+	return i
+}
+
+func (c *converter) indentedCode(lines []string, i int) int {
+	var code []string
+	for ; i < len(lines) && (isBlank(lines[i]) || indentation(lines[i]) >= 4); i++ {
+		code = append(code, dedent(lines[i], 4))
+	}
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+
+	c.b.WriteString("<pre><code>")
+	for _, line := range code {
+		c.b.WriteString(html.EscapeString(line) + "\n")
+	}
+	c.b.WriteString("</code></pre>\n")
+
+	return i
+}
+
+func (c *converter) blockQuote(lines []string, i int) int {
+	var quoted []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := blockQuoteRegexp.FindString(line); m != "" {
+			quoted = append(quoted, line[len(m):])
+			continue
+		}
+		// lazy continuation of a paragraph in the quote.
+		if len(quoted) > 0 && !isBlank(quoted[len(quoted)-1]) && !interruptsParagraph(line) && indentation(line) < 4 {
+			quoted = append(quoted, line)
+			continue
+		}
+		break
+	}
+
+	c.b.WriteString("<blockquote>\n")
+	c.blocks(quoted, false)
+	c.b.WriteString("</blockquote>\n")
+
+	return i
+}
+
+type listMarker struct {
+	ordered bool
+	// delimiter is the bullet character, or the character following the number of an ordered list.
+	delimiter byte
+	start     int
+}
+
+func parseListMarker(marker string) listMarker {
+	last := marker[len(marker)-1]
+	if n, err := strconv.Atoi(marker[:len(marker)-1]); err == nil {
+		return listMarker{ordered: true, delimiter: last, start: n}
+	}
+	return listMarker{delimiter: last}
+}
+
+func (c *converter) list(lines []string, i int) int {
+	var (
+		marker listMarker
+		items  [][]string
+		loose  bool
+		// contentIndent is the indentation of the content of the current item.
+		contentIndent int
+		blankBefore   bool
+	)
+
+	for ; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := listItemRegexp.FindStringSubmatch(line); m != nil &&
+			(len(items) == 0 || indentation(line) < contentIndent) && !thematicBreakRegexp.MatchString(line) {
+			mk := parseListMarker(m[2])
+			if len(items) > 0 && (mk.ordered != marker.ordered || mk.delimiter != marker.delimiter) {
+				break
+			}
+			if len(items) == 0 {
+				marker = mk
+			} else if blankBefore {
+				loose = true
+			}
+
+			spaces := len(m[3])
+			if spaces > 4 || spaces == 0 {
+				// content starting with more spaces is indented code, one space from the marker.
+				spaces = 1
+			}
+			contentIndent = len(m[1]) + len(m[2]) + spaces
+
+			items = append(items, []string{strings.Repeat(" ", max(len(m[3])-spaces, 0)) + line[len(m[0]):]})
+			blankBefore = false
+			continue
+		}
+
+		if isBlank(line) {
+			items[len(items)-1] = append(items[len(items)-1], "")
+			blankBefore = true
+			continue
+		}
+
+		if indentation(line) >= contentIndent {
+			if blankBefore {
+				loose = true
+			}
+			items[len(items)-1] = append(items[len(items)-1], line[contentIndent:])
+			blankBefore = false
+			continue
+		}
+
+		// lazy continuation of the item's paragraph.
+		if !blankBefore && !interruptsParagraph(line) {
+			items[len(items)-1] = append(items[len(items)-1], strings.TrimLeft(line, " "))
+			continue
+		}
+
+		break
+	}
+
+	// blank lines at the end of the list belong to what follows it.
+	for j := range items {
+		for len(items[j]) > 1 && isBlank(items[j][len(items[j])-1]) {
+			items[j] = items[j][:len(items[j])-1]
+		}
+	}
+
+	tag := "ul"
+	if marker.ordered {
+		tag = "ol"
+	}
+	c.b.WriteString("<" + tag)
+	if marker.ordered && marker.start != 1 {
+		c.b.WriteString(` start="` + strconv.Itoa(marker.start) + `"`)
+	}
+	c.b.WriteString(">\n")
+
+	for _, item := range items {
+		c.b.WriteString("<li>")
+		if loose {
+			c.b.WriteByte('\n')
+		}
+		if loose {
+			c.blocks(item, false)
+		} else {
+			// the content of a tight item ends on the line of its closing tag.
+			content := converter{headingIDs: c.headingIDs, ids: c.ids}
+			content.blocks(item, true)
+			c.ids = content.ids
+			c.b.WriteString(strings.TrimSuffix(content.b.String(), "\n"))
+		}
+		c.b.WriteString("</li>\n")
+	}
+
+	c.b.WriteString("</" + tag + ">\n")
+
+	return i
+}
@@ -0,0 +1,277 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	autolinkRegexp   = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^<>\s]*|[A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*)>`)
+	inlineHTMLRegexp = regexp.MustCompile(`^(?:</?[A-Za-z][A-Za-z0-9-]*(?:\s+[A-Za-z_:][A-Za-z0-9_.:-]*(?:\s*=\s*(?:[^\s"'=<>` + "`" + `]+|'[^']*'|"[^"]*"))?)*\s*/?>|<!--.*?-->)`)
+	entityRegexp     = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	linkTailRegexp   = regexp.MustCompile(`^\(\s*(<[^<>\n]*>|[^\s()]*(?:\([^\s()]*\)[^\s()]*)*)(?:\s+("[^"]*"|'[^']*'|\([^()]*\)))?\s*\)`)
+)
+
+const asciiPunctuation = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// inline renders the inline content of a block: emphasis, code spans, links, images and line breaks.
+func (c *converter) inline(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		ch := s[i]
+
+		switch ch {
+		case '\\':
+			if i+1 < len(s) && strings.IndexByte(asciiPunctuation, s[i+1]) >= 0 {
+				b.WriteString(html.EscapeString(s[i+1 : i+2]))
+				i += 2
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\n' {
+				b.WriteString("<br>\n")
+				i += 2
+				continue
+			}
+
+		case '`':
+			if n, code, ok := codeSpan(s[i:]); ok {
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += n
+				continue
+			}
+			// an unmatched run of backticks is literal.
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+
+		case '!':
+			if i+1 < len(s) && s[i+1] == '[' {
+				if n, text, dest, title, ok := link(s[i+1:]); ok {
+					b.WriteString(`<img src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(plainText(text)) + `"`)
+					if title != "" {
+						b.WriteString(` title="` + html.EscapeString(title) + `"`)
+					}
+					b.WriteString(">")
+					i += 1 + n
+					continue
+				}
+			}
+
+		case '[':
+			if n, text, dest, title, ok := link(s[i:]); ok {
+				b.WriteString(`<a href="` + html.EscapeString(dest) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">" + c.inline(text) + "</a>")
+				i += n
+				continue
+			}
+
+		case '<':
+			if m := autolinkRegexp.FindStringSubmatch(s[i:]); m != nil {
+				href := m[1]
+				if !strings.Contains(href, ":") {
+					href = "mailto:" + href
+				}
+				b.WriteString(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+				continue
+			}
+			if m := inlineHTMLRegexp.FindString(s[i:]); m != "" {
+				b.WriteString(m)
+				i += len(m)
+				continue
+			}
+
+		case '&':
+			if m := entityRegexp.FindString(s[i:]); m != "" {
+				b.WriteString(m)
+				i += len(m)
+				continue
+			}
+
+		case '*', '_', '~':
+			if n, out, ok := c.emphasis(s, i); ok {
+				b.WriteString(out)
+				i += n
+				continue
+			}
+			// an unmatched run of delimiters is literal.
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], string(ch)))
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+
+		case '\n':
+			if strings.HasSuffix(s[:i], "  ") {
+				trimmed := strings.TrimRight(b.String(), " ")
+				b.Reset()
+				b.WriteString(trimmed)
+				b.WriteString("<br>\n")
+			} else {
+				b.WriteByte('\n')
+			}
+			i++
+			continue
+		}
+
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+
+	return b.String()
+}
+
+// codeSpan parses a code span at the start of s, returning its length and content.
+func codeSpan(s string) (n int, code string, ok bool) {
+	ticks := len(s) - len(strings.TrimLeft(s, "`"))
+
+	for j := ticks; j < len(s); {
+		k := strings.IndexByte(s[j:], '`')
+		if k < 0 {
+			return 0, "", false
+		}
+		j += k
+		run := len(s[j:]) - len(strings.TrimLeft(s[j:], "`"))
+		if run == ticks {
+			code = strings.ReplaceAll(s[ticks:j], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			return j + run, code, true
+		}
+		j += run
+	}
+
+	return 0, "", false
+}
+
+// link parses a link, [text](destination "title"), at the start of s.
+func link(s string) (n int, text, dest, title string, ok bool) {
+	depth := 0
+	end := -1
+	for j := 0; j < len(s) && end < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			if m, _, ok := codeSpan(s[j:]); ok {
+				j += m - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = j
+			}
+		}
+	}
+	if end < 0 {
+		return 0, "", "", "", false
+	}
+
+	m := linkTailRegexp.FindStringSubmatch(s[end+1:])
+	if m == nil {
+		return 0, "", "", "", false
+	}
+
+	dest = strings.TrimSuffix(strings.TrimPrefix(m[1], "<"), ">")
+	if m[2] != "" {
+		title = m[2][1 : len(m[2])-1]
+	}
+
+	return end + 1 + len(m[0]), s[1:end], unescapePunctuation(dest), unescapePunctuation(title), true
+}
+
+// emphasis parses emphasis, strong emphasis or strikethrough starting with the delimiter run at s[i],
+// returning the length parsed and its html.
+func (c *converter) emphasis(s string, i int) (n int, out string, ok bool) {
+	ch := s[i]
+	run := len(s[i:]) - len(strings.TrimLeft(s[i:], string(ch)))
+
+	// an opening delimiter run must be followed by non-whitespace, and _ must not be inside a word.
+	if i+run >= len(s) || isSpace(s[i+run]) {
+		return 0, "", false
+	}
+	if ch == '_' && i > 0 && isWordChar(s[i-1]) {
+		return 0, "", false
+	}
+
+	var open, close string
+	switch {
+	case ch == '~' && run == 2:
+		open, close = "<del>", "</del>"
+	case ch == '~':
+		return 0, "", false
+	case run >= 3:
+		run = 3
+		open, close = "<em><strong>", "</strong></em>"
+	case run == 2:
+		open, close = "<strong>", "</strong>"
+	default:
+		open, close = "<em>", "</em>"
+	}
+
+	// find the closing delimiter run, preceded by non-whitespace, skipping code spans.
+	// A longer closing run closes with its last delimiters, e.g. **a *b*** is <strong>a <em>b</em></strong>.
+	for j := i + run; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+			continue
+		case '`':
+			if m, _, ok := codeSpan(s[j:]); ok {
+				j += m - 1
+			}
+			continue
+		case ch:
+		default:
+			continue
+		}
+
+		closing := len(s[j:]) - len(strings.TrimLeft(s[j:], string(ch)))
+		if isSpace(s[j-1]) || closing < run ||
+			ch == '_' && j+closing < len(s) && isWordChar(s[j+closing]) {
+			j += closing - 1
+			continue
+		}
+
+		end := j + closing - run
+		return end + run - i, open + c.inline(s[i+run:end]) + close, true
+	}
+
+	return 0, "", false
+}
+
+// plainText strips Markdown punctuation from text, for image alt text.
+func plainText(text string) string {
+	return strings.NewReplacer("*", "", "_", "", "`", "", "[", "", "]", "").Replace(text)
+}
+
+func unescapePunctuation(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(asciiPunctuation, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,102 @@
+// Package markdown implements htmplx.MarkdownRenderer with a dependency free converter
+// for the commonly used subset of Markdown: headings, paragraphs, emphasis, links, images,
+// code spans and blocks, block quotes, lists, thematic breaks, strikethrough and raw html.
+//
+// Use it to render body.md, and other .md files, in route directories:
+//
+//	h := htmplx.NewHandlerForDirectory[htmplx.RequestDataMap]("site").
+//		WithMarkdown(markdown.Renderer{HeadingIDs: true})
+//
+// Any other converter, such as goldmark, may be used with htmplx.MarkdownRendererFunc instead.
+package markdown
+
+import (
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Renderer converts Markdown to html.
+type Renderer struct {
+	// HeadingIDs adds an id derived from its text to each heading, so it may be linked to.
+	HeadingIDs bool
+}
+
+// RenderMarkdown writes the html of the Markdown in src to dst.
+func (r Renderer) RenderMarkdown(dst io.Writer, src []byte) error {
+	_, err := io.WriteString(dst, r.HTML(string(src)))
+	return err
+}
+
+// HTML converts the Markdown in src to html.
+func (r Renderer) HTML(src string) string {
+	c := converter{headingIDs: r.HeadingIDs}
+	c.blocks(splitLines(src), false)
+	return c.b.String()
+}
+
+// Funcs returns the template functions of this package:
+//
+//	markdown: renders a string of Markdown as html, e.g. {{markdown .Post.Body}}.
+//
+// The Markdown must be trusted, since raw html in it is rendered as is.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"markdown": func(src string) template.HTML {
+			return template.HTML(Renderer{}.HTML(src))
+		},
+	}
+}
+
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.TrimSuffix(src, "\n")
+
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = expandLeadingTabs(line)
+	}
+	return lines
+}
+
+// expandLeadingTabs replaces tabs in the indentation of line with spaces, to tab stops of 4.
+func expandLeadingTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+
+	var b strings.Builder
+	col := 0
+	for i, c := range line {
+		switch c {
+		case ' ':
+			b.WriteByte(' ')
+			col++
+		case '\t':
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		default:
+			b.WriteString(line[i:])
+			return b.String()
+		}
+	}
+	return b.String()
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// dedent removes up to n spaces of indentation from line.
+func dedent(line string, n int) string {
+	if i := indentation(line); i < n {
+		n = i
+	}
+	return line[n:]
+}
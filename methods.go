@@ -0,0 +1,172 @@
+package htmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// HandleMethod serves requests with method for the routes of the directory at pattern, e.g. a form's POST
+// with pattern /contact, or /blog/{(?P<id>[0-9]+)} for the routes of a regex directory.
+// Pages are served for GET and HEAD requests, and OPTIONS requests are answered with the methods of the route,
+// so method may be none of them. The route is in the request's context; see RouteFromContext.
+func (h *Handler[D]) HandleMethod(method, pattern string, handler http.Handler) *Handler[D] {
+	method = strings.ToUpper(method)
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+		panic(fmt.Sprintf("htmplx: HandleMethod: %s requests are served by the handler", method))
+	}
+
+	if h.methodHandlers == nil {
+		h.methodHandlers = make(map[string]map[string]http.Handler)
+	}
+	pattern = canonicalPath(pattern)
+	if h.methodHandlers[pattern] == nil {
+		h.methodHandlers[pattern] = make(map[string]http.Handler)
+	}
+	h.methodHandlers[pattern][method] = handler
+
+	return h
+}
+
+// RouteInfo describes a route of the tree, for documenting the handler's api.
+type RouteInfo struct {
+	// Pattern is the directory path of the route, e.g. /blog/{(?P<id>[0-9]+)}.
+	Pattern string `json:"pattern"`
+	// Methods are the methods the route is served, e.g. GET, HEAD, OPTIONS and POST.
+	Methods []string `json:"methods"`
+	// Params are the parameters of the route, one per regex directory of its pattern.
+	Params []RouteParam `json:"params,omitempty"`
+	// ContentTypes are the content types the route is rendered in, e.g. text/html and application/json.
+	ContentTypes []string `json:"contentTypes"`
+}
+
+// Routes lists the routes of the tree, every directory other than hidden and reserved ones,
+// with the methods each is served, its parameters and its content types.
+// See WriteRoutes for exporting them for other tools.
+func (h *Handler[D]) Routes() ([]RouteInfo, error) {
+	rh := h.newRequestHandler(h.log)
+
+	root, err := h.routeInfo(rh, "/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+	routes := []RouteInfo{root}
+
+	err = fs.WalkDir(h.fs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || name == "." {
+			return nil
+		}
+		if name == d.Name() && isReservedDir(name) || h.hiddenFiles.hides(d.Name()) {
+			return fs.SkipDir
+		}
+
+		route, err := h.routeInfo(rh, "/"+name)
+		if err != nil {
+			return err
+		}
+		routes = append(routes, route)
+
+		// the directories of a catch-all directory are never reached.
+		if isCatchAllPathPart(d.Name()) {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	return routes, nil
+}
+
+// allowedMethods are the methods the routes of the directory at pattern are served.
+func (h *Handler[D]) allowedMethods(pattern string) []string {
+	methods := []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	for method := range h.methodHandlers[pattern] {
+		methods = append(methods, method)
+	}
+	slices.Sort(methods[3:])
+	return methods
+}
+
+// serveMethod serves a request with a method other than GET and HEAD: OPTIONS with the methods of its route,
+// and others with the handler registered for the route, if any.
+func (h *Handler[D]) serveMethod(w http.ResponseWriter, r *http.Request, l *slog.Logger) {
+	l = l.With("method", r.Method)
+
+	// static files are only served to GET and HEAD requests.
+	if path.Ext(r.URL.Path) != "" {
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if route, ok := RouteFromContext(r.Context()); !ok || route.Path != r.URL.Path {
+		r = h.withResolvedRoute(r)
+	}
+	route, ok := RouteFromContext(r.Context())
+	if !ok {
+		l.Debug("not found")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	pattern := route.Pattern()
+	if handler, ok := h.methodHandlers[pattern][r.Method]; ok {
+		l.Debug("serving method handler for " + pattern)
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(h.allowedMethods(pattern), ", "))
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	l.Debug("method not allowed")
+	h.serveStatusPage(w, r, l, http.StatusMethodNotAllowed)
+}
+
+type statusPageContextKey struct{}
+
+// statusPageFromContext returns the status of the page to render in place of the route's body, if any.
+func statusPageFromContext(ctx context.Context) int {
+	status, _ := ctx.Value(statusPageContextKey{}).(int)
+	return status
+}
+
+// serveStatusPage responds with status, rendering the route's status template, e.g. 405.html.tmpl,
+// through its layout if there is one, so htmx requests get a styled page rather than a blank response.
+// The data callback is not called.
+func (h *Handler[D]) serveStatusPage(w http.ResponseWriter, r *http.Request, l *slog.Logger, status int) {
+	out, contentType, err := h.ServeFile(r.WithContext(context.WithValue(r.Context(), statusPageContextKey{}, status)))
+	if out == nil {
+		var statusErr *StatusError
+		if err != nil && !errors.As(err, &statusErr) {
+			l.With("error", err).
+				Error("failed to render status page")
+		}
+		w.WriteHeader(status)
+		return
+	}
+	defer out.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	io.Copy(w, out)
+}
@@ -0,0 +1,242 @@
+package htmplx
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// renderDurationBuckets are the upper bounds, in seconds, of the buckets of the render duration histogram.
+var renderDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics collects the metrics of the handlers it is set on with WithMetrics, and serves them
+// in the Prometheus text exposition format, to be scraped by Prometheus or any OpenMetrics collector:
+//
+//   - htmplx_requests_total, a counter of requests by route pattern, status and kind, static or template
+//   - htmplx_request_duration_seconds, a histogram of the time to serve requests by route pattern and kind
+//   - htmplx_cache_lookups_total, a counter of response and fragment cache lookups by cache and result, hit or miss
+//   - htmplx_render_errors_total, a counter of render errors by kind and template, see RenderErrors
+//   - htmplx_probes_total, a counter of the routes rendered by Probe by route pattern and result, ok or failed
+//
+// Mount it behind authentication, or on an internal listener:
+//
+//	metrics := htmplx.NewMetrics()
+//	h.WithMetrics(metrics)
+//	mux.Handle("/metrics", metrics)
+type Metrics struct {
+	mu        sync.Mutex
+	requests  map[requestLabels]int64
+	durations map[durationLabels]*histogram
+	lookups   map[lookupLabels]int64
+	probes    map[probeLabels]int64
+	// renderErrors are the render error counts of the handlers, at the time of a scrape.
+	renderErrors []func() []RenderErrorCount
+}
+
+type requestLabels struct {
+	route  string
+	status int
+	kind   string
+}
+
+type durationLabels struct {
+	route string
+	kind  string
+}
+
+type lookupLabels struct {
+	cache string
+	hit   bool
+}
+
+type probeLabels struct {
+	route  string
+	failed bool
+}
+
+type histogram struct {
+	// counts are the observations in each bucket, not cumulative, the last for those above every bound.
+	counts []int64
+	sum    float64
+	count  int64
+}
+
+// NewMetrics returns an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:  make(map[requestLabels]int64),
+		durations: make(map[durationLabels]*histogram),
+		lookups:   make(map[lookupLabels]int64),
+		probes:    make(map[probeLabels]int64),
+	}
+}
+
+// WithMetrics records the handler's requests, render durations, cache lookups and probes in metrics,
+// which may be shared by several handlers.
+func (h *Handler[D]) WithMetrics(metrics *Metrics) *Handler[D] {
+	h.metrics = metrics
+	metrics.mu.Lock()
+	metrics.renderErrors = append(metrics.renderErrors, h.RenderErrors)
+	metrics.mu.Unlock()
+	return h
+}
+
+// observeRequest records a request served.
+func (m *Metrics) observeRequest(route string, status int, static bool, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	kind := "template"
+	if static {
+		kind = "static"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestLabels{route: route, status: status, kind: kind}]++
+
+	key := durationLabels{route: route, kind: kind}
+	hist, ok := m.durations[key]
+	if !ok {
+		hist = &histogram{counts: make([]int64, len(renderDurationBuckets)+1)}
+		m.durations[key] = hist
+	}
+	seconds := duration.Seconds()
+	i, _ := slices.BinarySearch(renderDurationBuckets, seconds)
+	hist.counts[i]++
+	hist.sum += seconds
+	hist.count++
+}
+
+// observeLookup records a lookup of a cache, response or fragment.
+func (m *Metrics) observeLookup(cache string, hit bool) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lookups[lookupLabels{cache: cache, hit: hit}]++
+}
+
+// observeProbe records a route rendered by Probe.
+func (m *Metrics) observeProbe(route string, failed bool) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.probes[probeLabels{route: route, failed: failed}]++
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	m.mu.Lock()
+
+	b.WriteString("# HELP htmplx_requests_total Requests served, by route pattern, status and kind.\n")
+	b.WriteString("# TYPE htmplx_requests_total counter\n")
+	requests := sortedKeys(m.requests, func(a, b requestLabels) int {
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	})
+	for _, l := range requests {
+		fmt.Fprintf(&b, "htmplx_requests_total{route=%s,status=\"%d\",kind=\"%s\"} %d\n",
+			quoteLabel(l.route), l.status, l.kind, m.requests[l])
+	}
+
+	b.WriteString("# HELP htmplx_request_duration_seconds Time to serve requests, by route pattern and kind.\n")
+	b.WriteString("# TYPE htmplx_request_duration_seconds histogram\n")
+	durations := sortedKeys(m.durations, func(a, b durationLabels) int {
+		return strings.Compare(a.route+"\x00"+a.kind, b.route+"\x00"+b.kind)
+	})
+	for _, l := range durations {
+		hist := m.durations[l]
+		labels := fmt.Sprintf("route=%s,kind=\"%s\"", quoteLabel(l.route), l.kind)
+		var cumulative int64
+		for i, bound := range renderDurationBuckets {
+			cumulative += hist.counts[i]
+			fmt.Fprintf(&b, "htmplx_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "htmplx_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, hist.count)
+		fmt.Fprintf(&b, "htmplx_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(hist.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "htmplx_request_duration_seconds_count{%s} %d\n", labels, hist.count)
+	}
+
+	b.WriteString("# HELP htmplx_cache_lookups_total Cache lookups, by cache and result.\n")
+	b.WriteString("# TYPE htmplx_cache_lookups_total counter\n")
+	lookups := sortedKeys(m.lookups, func(a, b lookupLabels) int {
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	})
+	for _, l := range lookups {
+		result := "miss"
+		if l.hit {
+			result = "hit"
+		}
+		fmt.Fprintf(&b, "htmplx_cache_lookups_total{cache=\"%s\",result=\"%s\"} %d\n", l.cache, result, m.lookups[l])
+	}
+
+	b.WriteString("# HELP htmplx_probes_total Routes rendered by probes, by route pattern and result.\n")
+	b.WriteString("# TYPE htmplx_probes_total counter\n")
+	probes := sortedKeys(m.probes, func(a, b probeLabels) int {
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	})
+	for _, l := range probes {
+		result := "ok"
+		if l.failed {
+			result = "failed"
+		}
+		fmt.Fprintf(&b, "htmplx_probes_total{route=%s,result=\"%s\"} %d\n", quoteLabel(l.route), result, m.probes[l])
+	}
+
+	renderErrors := slices.Clone(m.renderErrors)
+	m.mu.Unlock()
+
+	// the counts of handlers sharing the metrics are summed.
+	errorCounts := make(map[[2]string]int)
+	for _, counts := range renderErrors {
+		for _, c := range counts() {
+			errorCounts[[2]string{string(c.Kind), c.Template}] += c.Count
+		}
+	}
+	b.WriteString("# HELP htmplx_render_errors_total Errors rendering pages, by kind and template.\n")
+	b.WriteString("# TYPE htmplx_render_errors_total counter\n")
+	for _, l := range sortedKeys(errorCounts, func(a, b [2]string) int { return slices.Compare(a[:], b[:]) }) {
+		fmt.Fprintf(&b, "htmplx_render_errors_total{kind=\"%s\",template=%s} %d\n", l[0], quoteLabel(l[1]), errorCounts[l])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// sortedKeys returns the keys of m sorted by cmp, for writing metrics in a stable order.
+func sortedKeys[K comparable, V any](m map[K]V, cmp func(a, b K) int) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, cmp)
+	return keys
+}
+
+// quoteLabel quotes a label value, escaping backslashes, quotes and newlines.
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
@@ -0,0 +1,45 @@
+package htmplx
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+)
+
+// Use adds middleware around the handler. The first middleware added is the outermost.
+// Requests passed to middleware already carry the resolved route, if there is one; see RouteFromContext.
+func (h *Handler[D]) Use(middleware ...func(http.Handler) http.Handler) *Handler[D] {
+	h.middleware = append(h.middleware, middleware...)
+
+	var chain http.Handler = http.HandlerFunc(h.serveHTTP)
+	for i := len(h.middleware) - 1; i >= 0; i-- {
+		chain = h.middleware[i](chain)
+	}
+	h.chain = chain
+
+	return h
+}
+
+// withResolvedRoute returns the request with its resolved route in its context, if it resolves to one.
+func (h *Handler[D]) withResolvedRoute(r *http.Request) *http.Request {
+	if path.Ext(r.URL.Path) != "" {
+		return r
+	}
+
+	l := h.requestLog(r)
+
+	rh := h.newRequestHandler(l)
+	rh.fs = h.requestFS(r)
+
+	route, err := rh.resolveRoute(r.URL.Path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			l.With("error", err).
+				Error("failed to resolve route")
+		}
+		return r
+	}
+
+	return r.WithContext(contextWithRoute(r.Context(), route))
+}
@@ -0,0 +1,189 @@
+package htmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Representation is the format a route is rendered in, chosen by the request's Accept header
+// among the bodies in the route's directory.
+type Representation string
+
+const (
+	// RepresentationHTML is the page, rendered from the html templates.
+	RepresentationHTML Representation = "html"
+	// RepresentationJSON is rendered from body.json.tmpl with text/template.
+	RepresentationJSON Representation = "json"
+	// RepresentationText is rendered from body.txt.tmpl with text/template.
+	RepresentationText Representation = "txt"
+)
+
+// textBodies are the representations rendered from a text template in place of the page,
+// in order of preference when equally acceptable.
+var textBodies = []struct {
+	representation Representation
+	mediaType      string
+	contentType    string
+}{
+	{representation: RepresentationJSON, mediaType: "application/json", contentType: "application/json"},
+	{representation: RepresentationText, mediaType: "text/plain", contentType: "text/plain; charset=utf-8"},
+}
+
+// bodyFilename is the name of the text template of the representation, e.g. body.json.tmpl.
+func (rep Representation) bodyFilename() string {
+	return "body." + string(rep) + textTemplateExt
+}
+
+// isTextBody reports whether the file is the text template of a route's representation,
+// which is only rendered as the route.
+func isTextBody(filename string) bool {
+	for _, b := range textBodies {
+		if path.Base(filename) == b.representation.bodyFilename() {
+			return true
+		}
+	}
+	return false
+}
+
+type representationContextKey struct{}
+
+func contextWithRepresentation(ctx context.Context, rep Representation) context.Context {
+	return context.WithValue(ctx, representationContextKey{}, rep)
+}
+
+// RepresentationFromContext returns the representation the route of the request is rendered in,
+// for the data callback to load what it needs, RepresentationHTML if it was not negotiated.
+func RepresentationFromContext(ctx context.Context) Representation {
+	if rep, ok := ctx.Value(representationContextKey{}).(Representation); ok {
+		return rep
+	}
+	return RepresentationHTML
+}
+
+// negotiateBody chooses the representation of the route best matching the request's Accept header,
+// the html page when equally acceptable. A text body is chosen regardless when the route has no html body.
+// filename and contentType are those of the text body chosen, if any.
+func (h requestHandler) negotiateBody(r *http.Request, route *Route) (
+	rep Representation,
+	filename string,
+	contentType string,
+	err error,
+) {
+	rep = RepresentationHTML
+
+	available := make([]int, 0, len(textBodies))
+	for i, b := range textBodies {
+		name := joinPath(route.Dir(), b.representation.bodyFilename())
+		if _, err := fs.Stat(h.fs, name); err == nil {
+			available = append(available, i)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return rep, "", "", fmt.Errorf("failed to look up %s: %w", name, err)
+		}
+	}
+	if len(available) == 0 {
+		return rep, "", "", nil
+	}
+
+	hasHTML, err := h.hasBodyFile(route.Dir())
+	if err != nil {
+		return rep, "", "", err
+	}
+
+	accept := r.Header.Get("Accept")
+
+	best, bestQuality := -1, 0.0
+	if hasHTML {
+		bestQuality = acceptQuality(accept, "text/html")
+	}
+	for _, i := range available {
+		if q := acceptQuality(accept, textBodies[i].mediaType); q > bestQuality {
+			best, bestQuality = i, q
+		}
+	}
+	if best < 0 {
+		if hasHTML {
+			return rep, "", "", nil
+		}
+		best = available[0]
+	}
+
+	b := textBodies[best]
+	return b.representation, joinPath(route.Dir(), b.representation.bodyFilename()), b.contentType, nil
+}
+
+// hasTextBody reports whether the route's directory has a text body, such as body.json.tmpl,
+// so its representation depends on the Accept header.
+func (h requestHandler) hasTextBody(route *Route) (bool, error) {
+	for _, b := range textBodies {
+		filename := joinPath(route.Dir(), b.representation.bodyFilename())
+		if _, err := fs.Stat(h.fs, filename); err == nil {
+			return true, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return false, fmt.Errorf("failed to look up %s: %w", filename, err)
+		}
+	}
+	return false, nil
+}
+
+// hasBodyFile reports whether dir has an html body template, or markdown body, of its own.
+func (h requestHandler) hasBodyFile(dir string) (bool, error) {
+	candidates := make([]string, 0, len(h.templateExts)+1)
+	for _, ext := range h.templateExts {
+		candidates = append(candidates, joinPath(dir, "body"+ext))
+	}
+	if h.markdown != nil {
+		candidates = append(candidates, joinPath(dir, "body"+markdownExt))
+	}
+
+	for _, filename := range candidates {
+		if _, err := fs.Stat(h.fs, filename); err == nil {
+			return true, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return false, fmt.Errorf("failed to look up %s: %w", filename, err)
+		}
+	}
+
+	return false, nil
+}
+
+// acceptQuality returns the quality the Accept header value gives the media type, 0 if it is not accepted.
+// The most specific media range matching the type applies, e.g. text/html over text/* over */*.
+func acceptQuality(accept, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	specificity, quality := -1, 0.0
+
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		s := -1
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s <= specificity {
+			continue
+		}
+
+		specificity, quality = s, 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					quality = f
+				}
+			}
+		}
+	}
+
+	return quality
+}
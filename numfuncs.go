@@ -0,0 +1,267 @@
+package htmplx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, the result of the arithmetic template funcs,
+// so that sums of money such as 0.1 + 0.2 are 0.3 rather than 0.30000000000000004.
+type Decimal struct {
+	r *big.Rat
+}
+
+// decimalPlaces is the most decimal places a Decimal prints, for those without a finite decimal expansion, like 1/3.
+const decimalPlaces = 10
+
+func (d Decimal) String() string {
+	if d.r == nil {
+		return "0"
+	}
+	s := d.r.FloatString(decimalPlaces)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	if d.r == nil {
+		return 0
+	}
+	f, _ := d.r.Float64()
+	return f
+}
+
+// toRat converts an integer, float, numeric string or Decimal to a rational number.
+// Floats are converted from their shortest decimal representation, so 0.1 is exactly 1/10.
+func toRat(v any) (*big.Rat, error) {
+	r := new(big.Rat)
+
+	switch v := v.(type) {
+	case Decimal:
+		if v.r == nil {
+			return r, nil
+		}
+		return r.Set(v.r), nil
+	case *big.Rat:
+		return r.Set(v), nil
+	case int:
+		return r.SetInt64(int64(v)), nil
+	case int8:
+		return r.SetInt64(int64(v)), nil
+	case int16:
+		return r.SetInt64(int64(v)), nil
+	case int32:
+		return r.SetInt64(int64(v)), nil
+	case int64:
+		return r.SetInt64(v), nil
+	case uint:
+		return r.SetUint64(uint64(v)), nil
+	case uint8:
+		return r.SetUint64(uint64(v)), nil
+	case uint16:
+		return r.SetUint64(uint64(v)), nil
+	case uint32:
+		return r.SetUint64(uint64(v)), nil
+	case uint64:
+		return r.SetUint64(v), nil
+	case float32:
+		return toRat(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("%v is not a number", v)
+		}
+		return toRat(strconv.FormatFloat(v, 'f', -1, 64))
+	case json.Number:
+		return toRat(string(v))
+	case string:
+		if _, ok := r.SetString(strings.TrimSpace(v)); !ok {
+			return nil, fmt.Errorf("%q is not a number", v)
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("%T is not a number", v)
+	}
+}
+
+func add(a any, bs ...any) (Decimal, error) {
+	sum, err := toRat(a)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("add: %w", err)
+	}
+	for _, b := range bs {
+		r, err := toRat(b)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("add: %w", err)
+		}
+		sum.Add(sum, r)
+	}
+	return Decimal{sum}, nil
+}
+
+func sub(a, b any) (Decimal, error) {
+	x, err := toRat(a)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("sub: %w", err)
+	}
+	y, err := toRat(b)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("sub: %w", err)
+	}
+	return Decimal{x.Sub(x, y)}, nil
+}
+
+func mul(a any, bs ...any) (Decimal, error) {
+	product, err := toRat(a)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("mul: %w", err)
+	}
+	for _, b := range bs {
+		r, err := toRat(b)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("mul: %w", err)
+		}
+		product.Mul(product, r)
+	}
+	return Decimal{product}, nil
+}
+
+func div(a, b any) (Decimal, error) {
+	x, err := toRat(a)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("div: %w", err)
+	}
+	y, err := toRat(b)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("div: %w", err)
+	}
+	if y.Sign() == 0 {
+		return Decimal{}, errors.New("div: division by zero")
+	}
+	return Decimal{x.Quo(x, y)}, nil
+}
+
+// roundRat rounds r to places decimal places, halves away from zero.
+func roundRat(r *big.Rat, places int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
+	half := big.NewRat(1, 2)
+	if scaled.Sign() < 0 {
+		half.Neg(half)
+	}
+	scaled.Add(scaled, half)
+
+	// Quo truncates towards zero.
+	n := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+
+	return new(big.Rat).SetFrac(n, scale)
+}
+
+func round(places int, v any) (Decimal, error) {
+	r, err := toRat(v)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("round: %w", err)
+	}
+	return Decimal{roundRat(r, places)}, nil
+}
+
+// currency formats v with symbol, two decimal places and thousands separators, e.g. $1,234.50 or -$0.99.
+func currency(symbol string, v any) (string, error) {
+	r, err := toRat(v)
+	if err != nil {
+		return "", fmt.Errorf("currency: %w", err)
+	}
+
+	s := roundRat(r, 2).FloatString(2)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+		if strings.Trim(s, "0.") != "" {
+			sign = "-"
+		}
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	return sign + symbol + groupThousands(whole) + "." + frac, nil
+}
+
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	first := len(digits) % 3
+	if first > 0 {
+		b.WriteString(digits[:first])
+	}
+	for i := first; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// humanizeBytes formats a number of bytes in binary units, e.g. 1.5 KiB or 3 MiB.
+func humanizeBytes(v any) (string, error) {
+	r, err := toRat(v)
+	if err != nil {
+		return "", fmt.Errorf("humanizeBytes: %w", err)
+	}
+	n, _ := r.Float64()
+
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	i := 0
+	for math.Abs(n) >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return strconv.FormatFloat(n, 'f', -1, 64) + " B", nil
+	}
+
+	return trimFloat(n, 1) + " " + units[i], nil
+}
+
+// compactNumber formats a number with a short suffix, e.g. 950, 1.2k, 3.4M or 1B.
+func compactNumber(v any) (string, error) {
+	r, err := toRat(v)
+	if err != nil {
+		return "", fmt.Errorf("compactNumber: %w", err)
+	}
+	n, _ := r.Float64()
+
+	suffixes := []string{"", "k", "M", "B", "T"}
+	i := 0
+	for math.Abs(n) >= 999.95 && i < len(suffixes)-1 {
+		n /= 1000
+		i++
+	}
+
+	return trimFloat(n, 1) + suffixes[i], nil
+}
+
+// trimFloat formats f with at most places decimal places, without trailing zeros.
+func trimFloat(f float64, places int) string {
+	s := strconv.FormatFloat(f, 'f', places, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
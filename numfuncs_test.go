@@ -0,0 +1,205 @@
+package htmplx
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func() (Decimal, error)
+		want string
+	}{
+		{"floats add exactly", func() (Decimal, error) { return add(0.1, 0.2) }, "0.3"},
+		{"float32", func() (Decimal, error) { return add(float32(0.1), float32(0.2)) }, "0.3"},
+		{"many terms", func() (Decimal, error) { return add(1, int64(2), uint8(3), "4.5", json.Number("0.5")) }, "11"},
+		{"numeric string with spaces", func() (Decimal, error) { return add(" 1.25 ", 0) }, "1.25"},
+		{"sub below zero", func() (Decimal, error) { return sub(0.3, 0.5) }, "-0.2"},
+		{"sub to zero", func() (Decimal, error) { return sub(0.3, 0.3) }, "0"},
+		{"mul", func() (Decimal, error) { return mul(19.99, 3) }, "59.97"},
+		{"mul by decimal", func() (Decimal, error) {
+			d, err := div(1, 4)
+			if err != nil {
+				return Decimal{}, err
+			}
+			return mul(d, 10)
+		}, "2.5"},
+		{"div repeating", func() (Decimal, error) { return div(1, 3) }, "0.3333333333"},
+		{"div negative repeating", func() (Decimal, error) { return div(-2, 3) }, "-0.6666666667"},
+		{"div tiny", func() (Decimal, error) { return div(1, 1e11) }, "0"},
+		{"div tiny negative", func() (Decimal, error) { return div(-1, 1e11) }, "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.f()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimalArithmeticErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func() (Decimal, error)
+	}{
+		{"division by zero", func() (Decimal, error) { return div(1, "0.0") }},
+		{"NaN", func() (Decimal, error) { return add(1, math.NaN()) }},
+		{"infinity", func() (Decimal, error) { return mul(1, math.Inf(-1)) }},
+		{"not a number", func() (Decimal, error) { return sub("1,000", 1) }},
+		{"unsupported type", func() (Decimal, error) { return add(1, true) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.f(); err == nil {
+				t.Errorf("got %s, want an error", got)
+			}
+		})
+	}
+}
+
+func TestDecimalZeroValue(t *testing.T) {
+	var d Decimal
+	if d.String() != "0" {
+		t.Errorf("got %s, want 0", d)
+	}
+	if d.Float64() != 0 {
+		t.Errorf("got %v, want 0", d.Float64())
+	}
+	if b, err := json.Marshal(map[string]Decimal{"d": d}); err != nil || string(b) != `{"d":0}` {
+		t.Errorf("got %s, %v, want {\"d\":0}", b, err)
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		places int
+		v      any
+		want   string
+	}{
+		{0, 2.5, "3"},
+		{0, -2.5, "-3"},
+		{0, 2.4999, "2"},
+		{0, -0.4, "0"},
+		{2, 1.005, "1.01"},
+		{2, -1.005, "-1.01"},
+		{2, "2.675", "2.68"},
+		{2, 1.234, "1.23"},
+		{1, 0.05, "0.1"},
+		{1, -0.04, "0"},
+		{3, 1, "1"},
+		{2, json.Number("99.995"), "100"},
+	}
+
+	for _, tt := range tests {
+		got, err := round(tt.places, tt.v)
+		if err != nil {
+			t.Fatalf("round %d %v: %v", tt.places, tt.v, err)
+		}
+		if got.String() != tt.want {
+			t.Errorf("round %d %v: got %s, want %s", tt.places, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	tests := []struct {
+		symbol string
+		v      any
+		want   string
+	}{
+		{"$", 1234.5, "$1,234.50"},
+		{"$", 0, "$0.00"},
+		{"$", 999.995, "$1,000.00"},
+		{"$", 100, "$100.00"},
+		{"$", 1234567.891, "$1,234,567.89"},
+		{"$", "-1234567.891", "-$1,234,567.89"},
+		{"$", -0.99, "-$0.99"},
+		// amounts rounding to zero are not negative.
+		{"$", -0.004, "$0.00"},
+		{"$", -0.005, "-$0.01"},
+		// the symbol is written as given, before the amount, whatever its script or width.
+		{"€", 0.1, "€0.10"},
+		{"£", 12345, "£12,345.00"},
+		{"¥", 1000000, "¥1,000,000.00"},
+		{"CHF ", 99.9, "CHF 99.90"},
+		{"", 1000, "1,000.00"},
+	}
+
+	for _, tt := range tests {
+		got, err := currency(tt.symbol, tt.v)
+		if err != nil {
+			t.Fatalf("currency %q %v: %v", tt.symbol, tt.v, err)
+		}
+		if got != tt.want {
+			t.Errorf("currency %q %v: got %s, want %s", tt.symbol, tt.v, got, tt.want)
+		}
+	}
+
+	if _, err := currency("$", "1.234,50"); err == nil {
+		t.Error("currency of a number with a decimal comma: want an error")
+	}
+}
+
+func TestHumanizeBytes(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1 KiB"},
+		{1536, "1.5 KiB"},
+		{-2048, "-2 KiB"},
+		{3 << 20, "3 MiB"},
+		{int64(1) << 62, "4 EiB"},
+		{uint64(math.MaxUint64), "16 EiB"},
+	}
+
+	for _, tt := range tests {
+		got, err := humanizeBytes(tt.v)
+		if err != nil {
+			t.Fatalf("humanizeBytes %v: %v", tt.v, err)
+		}
+		if got != tt.want {
+			t.Errorf("humanizeBytes %v: got %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestCompactNumber(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{0, "0"},
+		{950, "950"},
+		{999.94, "999.9"},
+		// rounding up to 1000 moves to the next suffix rather than printing 1000k.
+		{999.95, "1k"},
+		{1200, "1.2k"},
+		{-1200, "-1.2k"},
+		{999_950, "1M"},
+		{3_400_000, "3.4M"},
+		{1_000_000_000, "1B"},
+		{2.5e15, "2500T"},
+	}
+
+	for _, tt := range tests {
+		got, err := compactNumber(tt.v)
+		if err != nil {
+			t.Fatalf("compactNumber %v: %v", tt.v, err)
+		}
+		if got != tt.want {
+			t.Errorf("compactNumber %v: got %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package htmplx
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPStore is a RemoteStore of files served over http, such as by a CDN, listed by a JSON manifest:
+// an array of RemoteObjects, e.g. [{"name": "body.html.tmpl", "etag": "3f2a..."}].
+type HTTPStore struct {
+	// URL is the url of the root of the files.
+	URL string
+	// Manifest is the path of the manifest, relative to URL, manifest.json by default.
+	Manifest string
+	// Client is the client requests are made with, http.DefaultClient by default.
+	Client *http.Client
+}
+
+func (s HTTPStore) List(ctx context.Context) ([]RemoteObject, error) {
+	manifest := s.Manifest
+	if manifest == "" {
+		manifest = "manifest.json"
+	}
+
+	body, err := httpGet(ctx, s.Client, joinURL(s.URL, manifest))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var objects []RemoteObject
+	if err := json.NewDecoder(body).Decode(&objects); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", manifest, err)
+	}
+
+	return objects, nil
+}
+
+func (s HTTPStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return httpGet(ctx, s.Client, joinURL(s.URL, name))
+}
+
+// BucketStore is a RemoteStore of the objects of a bucket listed with the S3 ListObjectsV2 api,
+// which Google Cloud Storage, MinIO and Cloudflare R2 also serve, e.g. https://templates.s3.amazonaws.com.
+// Requests are not signed, so the bucket must be readable, or Client must sign requests itself.
+type BucketStore struct {
+	// URL is the url of the bucket.
+	URL string
+	// Prefix is the key prefix of the objects of the tree, such as "site/".
+	Prefix string
+	// Client is the client requests are made with, http.DefaultClient by default.
+	Client *http.Client
+}
+
+// listBucketResult is a page of the response of ListObjectsV2.
+type listBucketResult struct {
+	Contents []struct {
+		Key          string
+		LastModified time.Time
+		ETag         string
+		Size         int64
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+func (s BucketStore) List(ctx context.Context) ([]RemoteObject, error) {
+	var objects []RemoteObject
+
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.Prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		body, err := httpGet(ctx, s.Client, strings.TrimSuffix(s.URL, "/")+"/?"+query.Encode())
+		if err != nil {
+			return nil, err
+		}
+
+		var page listBucketResult
+		err = xml.NewDecoder(body).Decode(&page)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket listing: %w", err)
+		}
+
+		for _, c := range page.Contents {
+			objects = append(objects, RemoteObject{
+				Name:    strings.TrimPrefix(c.Key, s.Prefix),
+				Size:    c.Size,
+				ModTime: c.LastModified,
+				ETag:    strings.Trim(c.ETag, `"`),
+			})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s BucketStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return httpGet(ctx, s.Client, joinURL(s.URL, s.Prefix+name))
+}
+
+// httpGet returns the body of a successful GET request, or an error wrapping fs.ErrNotExist if there is none.
+func httpGet(ctx context.Context, client *http.Client, rawURL string) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		res.Body.Close()
+		return nil, fmt.Errorf("GET %s: %w", rawURL, fs.ErrNotExist)
+	case res.StatusCode != http.StatusOK:
+		res.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", rawURL, res.Status)
+	}
+
+	return res.Body, nil
+}
+
+// joinURL joins the slash separated path name to base, escaping it.
+func joinURL(base, name string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix((&url.URL{Path: name}).EscapedPath(), "/")
+}
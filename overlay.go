@@ -0,0 +1,142 @@
+package htmplx
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// OverlayFS layers file systems, each file looked up in the first layer that has it, falling through to the next,
+// so that a theme or a customer's overrides replace only the files they contain:
+//
+//	htmplx.NewHandler[htmplx.RequestDataMap](htmplx.OverlayFS(themeFS, baseFS))
+//
+// Directories are merged, listing the entries of every layer.
+func OverlayFS(layers ...fs.FS) fs.FS {
+	return overlayFS(layers)
+}
+
+type overlayFS []fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	for _, layer := range o {
+		f, err := layer.Open(name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if !info.IsDir() {
+			return f, nil
+		}
+
+		entries, err := o.ReadDir(name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &overlayDir{File: f, entries: entries}, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o overlayFS) Stat(name string) (fs.FileInfo, error) {
+	for _, layer := range o {
+		info, err := fs.Stat(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return info, err
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o overlayFS) ReadFile(name string) ([]byte, error) {
+	for _, layer := range o {
+		b, err := fs.ReadFile(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return b, err
+	}
+	return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir merges the entries of the directory in every layer, those of earlier layers shadowing later ones.
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	seen := make(map[string]bool)
+	found := false
+
+	for _, layer := range o {
+		// a file in an earlier layer shadows the directory.
+		if info, err := fs.Stat(layer, name); err == nil && !info.IsDir() {
+			if !found {
+				return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+			}
+			break
+		}
+
+		layerEntries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+
+		for _, e := range layerEntries {
+			if !seen[e.Name()] {
+				seen[e.Name()] = true
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries, nil
+}
+
+// overlayDir is a directory opened from an overlay, listing the merged entries of every layer.
+type overlayDir struct {
+	fs.File
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
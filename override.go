@@ -0,0 +1,153 @@
+package htmplx
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"text/template/parse"
+)
+
+// OverrideTemplate layers an in-memory template over the templates found in the directory route,
+// taking precedence over any template file of the same name in that directory.
+// route is the directory's path relative to the root of the file system, e.g. "/dogs/terrier",
+// or "/" for the root directory. Regex directories are referred to by their directory name,
+// e.g. "/dogs/{[a-z]+}".
+// Overrides are intended for emergency fixes and operator-driven content; each change is logged,
+// and drops the cached responses and fragments rendered before it.
+// The override applies to the handler's own tree only, see OverrideHostTemplate for the trees of WithHostFS.
+func (h *Handler[D]) OverrideTemplate(route, name, content string) error {
+	return h.OverrideHostTemplate("", route, name, content)
+}
+
+// OverrideHostTemplate is OverrideTemplate for the tree of a host set by WithHostFS or WithTenants,
+// by the key it is set under, e.g. "acme.example.com" or "*.example.com". An empty host is the handler's own tree.
+func (h *Handler[D]) OverrideHostTemplate(host, route, name, content string) error {
+	host = strings.ToLower(host)
+	dir := overrideDir(route)
+
+	if err := checkTemplateSyntax(name, content); err != nil {
+		return fmt.Errorf("invalid override of template %s in %s: %w", name, route, err)
+	}
+
+	h.overrides.set(host, dir, name, content)
+	h.log.Info("template override set",
+		"host", host,
+		"route", "/"+dir,
+		"template", name,
+		"bytes", len(content),
+	)
+	h.overridesChanged()
+
+	return nil
+}
+
+// RemoveTemplateOverride removes a template override previously set by OverrideTemplate.
+// It reports whether an override existed.
+func (h *Handler[D]) RemoveTemplateOverride(route, name string) bool {
+	return h.RemoveHostTemplateOverride("", route, name)
+}
+
+// RemoveHostTemplateOverride removes a template override previously set by OverrideHostTemplate.
+// It reports whether an override existed.
+func (h *Handler[D]) RemoveHostTemplateOverride(host, route, name string) bool {
+	host = strings.ToLower(host)
+	dir := overrideDir(route)
+
+	removed := h.overrides.remove(host, dir, name)
+	if removed {
+		h.log.Info("template override removed",
+			"host", host,
+			"route", "/"+dir,
+			"template", name,
+		)
+		h.overridesChanged()
+	}
+
+	return removed
+}
+
+// overridesChanged drops the responses and fragments rendered with the overrides before their change.
+func (h *Handler[D]) overridesChanged() {
+	h.PurgeFragments()
+	h.clearResponseCache()
+}
+
+// overrideHost returns the host the request's overrides are set for, empty for the handler's own tree.
+func (h *Handler[D]) overrideHost(r *http.Request) string {
+	if _, ok := r.Context().Value(treeContextKey{}).(fs.FS); ok {
+		return ""
+	}
+	key, _, _ := h.hostTree(r)
+	return key
+}
+
+func overrideDir(route string) string {
+	return strings.Trim(path.Clean("/"+route), "/")
+}
+
+// checkTemplateSyntax parses a template without checking that the functions it calls exist,
+// since those are only known per request.
+func checkTemplateSyntax(name, content string) error {
+	t := parse.New(name)
+	t.Mode = parse.SkipFuncCheck
+	_, err := t.Parse(content, "", "", make(map[string]*parse.Tree))
+	return err
+}
+
+type templateOverrides struct {
+	mu sync.RWMutex
+	// byHost are the overrides by host, then by directory, then by template name.
+	byHost map[string]map[string]map[string]string
+}
+
+func (o *templateOverrides) set(host, dir, name, content string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.byHost == nil {
+		o.byHost = make(map[string]map[string]map[string]string)
+	}
+	if o.byHost[host] == nil {
+		o.byHost[host] = make(map[string]map[string]string)
+	}
+	if o.byHost[host][dir] == nil {
+		o.byHost[host][dir] = make(map[string]string)
+	}
+	o.byHost[host][dir][name] = content
+}
+
+func (o *templateOverrides) remove(host, dir, name string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.byHost[host][dir][name]; !ok {
+		return false
+	}
+	delete(o.byHost[host][dir], name)
+	if len(o.byHost[host][dir]) == 0 {
+		delete(o.byHost[host], dir)
+	}
+	if len(o.byHost[host]) == 0 {
+		delete(o.byHost, host)
+	}
+	return true
+}
+
+// forDir returns a copy of the overrides of host in dir.
+func (o *templateOverrides) forDir(host, dir string) map[string]string {
+	if o == nil {
+		return nil
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	out := make(map[string]string, len(o.byHost[host][dir]))
+	for name, content := range o.byHost[host][dir] {
+		out[name] = content
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package htmplx
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/angelbeltran/htmplx/internal/nfc"
+)
+
+// checkPath rejects request paths whose segments are not matched as written once decoded:
+// those with an encoded slash, e.g. /a%2Fb, which would be matched as two segments,
+// NUL bytes, invalid UTF-8, and . and .. segments, which could escape the directory.
+func checkPath(u *url.URL) error {
+	for _, segment := range strings.Split(u.EscapedPath(), "/") {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return &StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf("invalid path segment %q: %w", segment, err)}
+		}
+
+		switch {
+		case strings.ContainsAny(decoded, "/\x00"):
+			return &StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf("invalid path segment %q: encoded slash or NUL", segment)}
+		case !utf8.ValidString(decoded):
+			return &StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf("invalid path segment %q: invalid UTF-8", segment)}
+		case decoded == "." || decoded == "..":
+			return &StatusError{Code: http.StatusBadRequest, Err: fmt.Errorf("invalid path segment %q", segment)}
+		}
+	}
+
+	return nil
+}
+
+// nfcPath returns u with its path segments normalized to Unicode Normalization Form C, if they are not already,
+// so that café spelled with a combining accent matches the directory spelled with é, and the same regexes as it.
+// Segments that are not valid are left for checkPath to reject.
+func nfcPath(u *url.URL) *url.URL {
+	segments := strings.Split(u.EscapedPath(), "/")
+	normalized := false
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil || !utf8.ValidString(decoded) || nfc.IsNormal(decoded) {
+			continue
+		}
+		segments[i] = url.PathEscape(nfc.String(decoded))
+		normalized = true
+	}
+	if !normalized {
+		return u
+	}
+
+	escaped := strings.Join(segments, "/")
+	p, err := url.PathUnescape(escaped)
+	if err != nil {
+		return u
+	}
+	v := *u
+	v.Path = p
+	v.RawPath = escaped
+	return &v
+}
+
+// withNFCPath returns r with its path normalized by nfcPath, a shallow copy if changed.
+func withNFCPath(r *http.Request) *http.Request {
+	u := nfcPath(r.URL)
+	if u == r.URL {
+		return r
+	}
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = u
+	return r2
+}
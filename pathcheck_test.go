@@ -0,0 +1,92 @@
+package htmplx
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+func TestCheckPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"/", false},
+		{"/dogs/terrier", false},
+		{"/caf%C3%A9", false},
+		{"/café", false},
+		// paths are normalized to NFC before they are checked.
+		{"/cafe%CC%81", false},
+		{"/%E2%84%AB", false},
+		{"/a%2Fb", true},
+		{"/a%00b", true},
+		{"/%FF", true},
+		{"/a/%2E%2E/b", true},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := checkPath(u); (err != nil) != tt.wantErr {
+			t.Errorf("checkPath(%s) = %v, want error %t", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNFCPath(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"/", "/"},
+		{"/caf%C3%A9", "/caf%C3%A9"},
+		{"/cafe%CC%81/menu", "/caf%C3%A9/menu"},
+		{"/%E2%84%AB", "/%C3%85"},
+		{"/%E1%84%92%E1%85%A1%E1%86%AB", "/%ED%95%9C"},
+		// segments that are not valid are left as they are.
+		{"/cafe%CC%81/a%2Fb", "/caf%C3%A9/a%2Fb"},
+		{"/%FF", "/%FF"},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := nfcPath(u).EscapedPath(); got != tt.want {
+			t.Errorf("nfcPath(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNFCPathsMatchDirectories(t *testing.T) {
+	h := newTestHandler(htmplxtest.FS(map[string]string{
+		"body.html.tmpl":                   `home`,
+		"café/body.html.tmpl":              `café`,
+		"{(?P<word>\\pL+)}/body.html.tmpl": `{{.word}}`,
+	})).WithData(func(*http.Request) RequestDataMap {
+		return RequestDataMap{}
+	})
+
+	tests := []struct {
+		path     string
+		wantBody string
+	}{
+		{"/caf%C3%A9", "caf\u00E9"},
+		{"/cafe%CC%81", "caf\u00E9"},
+		{"/%ED%95%9C%EA%B5%AD%EC%96%B4", "\uD55C\uAD6D\uC5B4"},
+		// the regex matches the segment, and its submatch is, in NFC.
+		{"/%E1%84%92%E1%85%A1%E1%86%AB", "\uD55C"},
+	}
+
+	for _, tt := range tests {
+		w := get(h, tt.path)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("%s: got %d %+q, want %+q", tt.path, w.Code, w.Body, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package htmplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// PDFRenderer converts a rendered html page to a pdf document.
+// See the pdf package for an implementation backed by an external command, such as wkhtmltopdf.
+type PDFRenderer interface {
+	// RenderPDF writes the pdf rendering of html to dst.
+	// Relative urls in html are relative to baseURL, the url of the page, if not empty.
+	RenderPDF(ctx context.Context, dst io.Writer, html io.Reader, baseURL string) error
+}
+
+// PDFRendererFunc adapts a function to a PDFRenderer.
+type PDFRendererFunc func(ctx context.Context, dst io.Writer, html io.Reader, baseURL string) error
+
+func (f PDFRendererFunc) RenderPDF(ctx context.Context, dst io.Writer, html io.Reader, baseURL string) error {
+	return f(ctx, dst, html, baseURL)
+}
+
+// WithPDF serves pdf renderings of routes at the route's path with a .pdf extension,
+// e.g. /invoice/42.pdf for /invoice/42, rendering the route's print view with renderer.
+// Only routes in directories with "pdf": true in their _config.json, or in a parent's, are served as pdfs.
+// Static .pdf files take precedence.
+//
+// baseURL is the scheme and host the renderer fetches the page's relative urls from, e.g. http://localhost:8080,
+// typically the address the handler listens on, and never taken from the request, whose Host header the client chooses.
+// If empty, relative urls are not resolved.
+func (h *Handler[D]) WithPDF(renderer PDFRenderer, baseURL string) *Handler[D] {
+	h.pdf = renderer
+	h.pdfBaseURL = strings.TrimSuffix(baseURL, "/")
+	return h
+}
+
+// servePDF serves the pdf rendering of the route at the request's path, less its .pdf extension.
+// It reports false if the request is not for one, e.g. if it is for a static .pdf file.
+func (h *Handler[D]) servePDF(w http.ResponseWriter, r *http.Request, l *slog.Logger) bool {
+	fsys := h.requestFS(r)
+
+	filename := strings.TrimPrefix(r.URL.Path, "/")
+	if _, err := fs.Stat(fsys, filename); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+
+	routePath := strings.TrimSuffix(r.URL.Path, ".pdf")
+	l = l.With("route", routePath)
+
+	rh := h.newRequestHandler(l)
+	rh.fs = fsys
+
+	route, err := rh.resolveRoute(routePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false
+		}
+		rh.internalServerError(w, r, err)
+		return true
+	}
+
+	enabled, err := rh.pdfEnabled(route)
+	if err != nil {
+		rh.internalServerError(w, r, err)
+		return true
+	}
+	if !enabled {
+		l.Debug("pdf rendering not enabled for route")
+		return false
+	}
+
+	l.Debug("rendering route as pdf")
+
+	pageReq := r.Clone(contextWithRoute(r.Context(), route))
+	pageReq.URL.Path = routePath
+	query := pageReq.URL.Query()
+	query.Set("view", printView)
+	pageReq.URL.RawQuery = query.Encode()
+
+	page, _, err := h.ServeFile(pageReq)
+	if err != nil {
+		if page != nil {
+			page.Close()
+		}
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			w.WriteHeader(statusErr.Code)
+			return true
+		}
+		rh.internalServerError(w, r, err)
+		return true
+	}
+	if page == nil {
+		rh.notFound(w)
+		return true
+	}
+	defer page.Close()
+
+	baseURL := ""
+	if h.pdfBaseURL != "" {
+		baseURL = h.pdfBaseURL + withBasePath(h.basePath, routePath)
+	}
+
+	var buf bytes.Buffer
+	if err := h.pdf.RenderPDF(r.Context(), &buf, page, baseURL); err != nil {
+		rh.internalServerError(w, r, err)
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="`+path.Base(r.URL.Path)+`"`)
+	io.Copy(w, &buf)
+	l.Debug("pdf served")
+
+	return true
+}
+
+// pdfEnabled reports whether the route is served as a pdf, per the nearest "pdf" setting along its path.
+func (h requestHandler) pdfEnabled(route *Route) (bool, error) {
+	enabled := false
+
+	for i := 0; i <= len(route.Dirs); i++ {
+		cfg, err := h.readDirectoryConfig(strings.Join(route.Dirs[:i], "/"))
+		if err != nil {
+			return false, err
+		}
+		if cfg.PDF != nil {
+			enabled = *cfg.PDF
+		}
+	}
+
+	return enabled, nil
+}
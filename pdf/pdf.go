@@ -0,0 +1,69 @@
+// Package pdf implements htmplx.PDFRenderer with external html to pdf converters.
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os/exec"
+)
+
+// Command renders pdfs with a command that reads html from stdin and writes a pdf to stdout.
+type Command struct {
+	// Path is the command to run.
+	Path string
+	// Args are the command's arguments.
+	Args []string
+}
+
+// WkHTMLToPDF renders pdfs with wkhtmltopdf, found in the PATH, passing it any extra arguments.
+func WkHTMLToPDF(args ...string) Command {
+	return Command{
+		Path: "wkhtmltopdf",
+		Args: append(append([]string{"--quiet"}, args...), "-", "-"),
+	}
+}
+
+// RenderPDF runs the command. A <base> tag with baseURL is added to the html's head
+// since the command cannot know where the html came from.
+func (c Command) RenderPDF(ctx context.Context, dst io.Writer, page io.Reader, baseURL string) error {
+	b, err := io.ReadAll(page)
+	if err != nil {
+		return fmt.Errorf("failed to read html: %w", err)
+	}
+
+	if baseURL != "" {
+		b = withBase(b, baseURL)
+	}
+
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, c.Path, c.Args...)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout = dst
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", c.Path, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return nil
+}
+
+// withBase inserts a <base> tag at the start of the html's head, if it has one.
+func withBase(page []byte, baseURL string) []byte {
+	i := bytes.Index(bytes.ToLower(page), []byte("<head>"))
+	if i < 0 {
+		return page
+	}
+	i += len("<head>")
+
+	base := []byte(`<base href="` + html.EscapeString(baseURL) + `">`)
+
+	out := make([]byte, 0, len(page)+len(base))
+	out = append(out, page[:i]...)
+	out = append(out, base...)
+	return append(out, page[i:]...)
+}
@@ -0,0 +1,53 @@
+package htmplx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+func TestPDFBaseURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseURL     string
+		basePath    string
+		wantBaseURL string
+	}{
+		{"configured", "http://localhost:8080", "", "http://localhost:8080/invoice/42"},
+		{"trailing slash", "http://localhost:8080/", "", "http://localhost:8080/invoice/42"},
+		{"base path", "http://localhost:8080", "/app", "http://localhost:8080/app/invoice/42"},
+		{"not configured", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBaseURL string
+			renderer := PDFRendererFunc(func(ctx context.Context, dst io.Writer, html io.Reader, baseURL string) error {
+				gotBaseURL = baseURL
+				_, err := io.WriteString(dst, "%PDF-1.7")
+				return err
+			})
+			h := newTestHandler(htmplxtest.FS(map[string]string{
+				"invoice/_config.json":            `{"pdf": true}`,
+				"invoice/{[0-9]+}/body.html.tmpl": `<main>invoice</main>`,
+			})).WithPDF(renderer, tt.baseURL).WithBasePath(tt.basePath)
+
+			r := httptest.NewRequest(http.MethodGet, "/invoice/42.pdf", nil)
+			// the Host header is chosen by the client, so is never where the renderer fetches from.
+			r.Host = "attacker.test"
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" {
+				t.Fatalf("got %d %s, want a pdf", w.Code, w.Header().Get("Content-Type"))
+			}
+			if gotBaseURL != tt.wantBaseURL {
+				t.Errorf("got base url %q, want %q", gotBaseURL, tt.wantBaseURL)
+			}
+		})
+	}
+}
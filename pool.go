@@ -0,0 +1,80 @@
+package htmplx
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// sniffLen is the number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+var sniffBufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, sniffLen)
+		return &b
+	},
+}
+
+func getSniffBuffer() *[]byte {
+	return sniffBufferPool.Get().(*[]byte)
+}
+
+func putSniffBuffer(b *[]byte) {
+	sniffBufferPool.Put(b)
+}
+
+// maxPooledBufferSize is the capacity beyond which render buffers are not reused,
+// so that one unusually large page does not hold on to its memory.
+const maxPooledBufferSize = 1 << 20
+
+var renderBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// getRenderBuffer returns an empty buffer to render a page into, reused from earlier requests.
+func getRenderBuffer() *bytes.Buffer {
+	buf := renderBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putRenderBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferSize {
+		renderBufferPool.Put(buf)
+	}
+}
+
+// renderedPage reads a page rendered into a pooled buffer. Closing it releases the buffer,
+// so its content must not be used after.
+type renderedPage struct {
+	*bytes.Buffer
+}
+
+func (p *renderedPage) Close() error {
+	if p.Buffer != nil {
+		putRenderBuffer(p.Buffer)
+		p.Buffer = nil
+	}
+	return nil
+}
+
+// streamedFile streams a file, including any bytes already consumed while sniffing its content type.
+// Closing it closes the file and releases the sniff buffer.
+type streamedFile struct {
+	io.Reader
+	f   fs.File
+	buf *[]byte
+}
+
+func (s *streamedFile) Close() error {
+	err := s.f.Close()
+	if s.buf != nil {
+		putSniffBuffer(s.buf)
+		s.buf = nil
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package htmplx
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+var benchmarkPage = strings.Repeat("<p>Lorem ipsum dolor sit amet, consectetur adipiscing elit.</p>\n", 256)
+
+// BenchmarkRenderBuffer measures rendering into a pooled buffer, against allocating one per page.
+func BenchmarkRenderBuffer(b *testing.B) {
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			buf := getRenderBuffer()
+			buf.WriteString(benchmarkPage)
+			putRenderBuffer(buf)
+		}
+	})
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			buf := new(bytes.Buffer)
+			buf.WriteString(benchmarkPage)
+		}
+	})
+}
+
+// BenchmarkServePooled measures the allocations of serving a page, rendered into a pooled buffer,
+// and a static file of unknown extension, its content type sniffed into a pooled buffer.
+func BenchmarkServePooled(b *testing.B) {
+	h := newTestHandler(htmplxtest.FS(map[string]string{
+		"body.html.tmpl": benchmarkPage,
+		"notes.xyz":      benchmarkPage,
+	}))
+
+	for name, target := range map[string]string{"page": "/", "static": "/notes.xyz"} {
+		b.Run(name, func(b *testing.B) {
+			r := httptest.NewRequest(http.MethodGet, target, nil)
+
+			b.ReportAllocs()
+			for range b.N {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if w.Code != http.StatusOK {
+					b.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// priorityFilename is the name of the optional file of a regex directory holding its priority, an integer.
+// It is never served.
+const priorityFilename = "_priority"
+
+// RegexDirectory is a regex directory matching a path segment, for ordering by a DirectoryPrecedence.
+type RegexDirectory struct {
+	// Name is the name of the directory, e.g. {(?P<id>[0-9]+)}.
+	Name string
+	// Priority is the integer in the directory's _priority file, or 0 if it has none.
+	Priority int
+	// Params is the number of named groups of the expression, the parameters of the route.
+	Params int
+}
+
+// DirectoryPrecedence orders the regex directories matching the same path segment, the first of them winning.
+// A directory named exactly as the segment always wins over regex directories.
+type DirectoryPrecedence func(a, b RegexDirectory) int
+
+// DefaultDirectoryPrecedence prefers the directory of highest priority, then one with named parameters over a
+// plain regex, then the first by name.
+func DefaultDirectoryPrecedence(a, b RegexDirectory) int {
+	switch {
+	case a.Priority != b.Priority:
+		return b.Priority - a.Priority
+	case (a.Params > 0) != (b.Params > 0):
+		if a.Params > 0 {
+			return -1
+		}
+		return 1
+	default:
+		return strings.Compare(a.Name, b.Name)
+	}
+}
+
+// WithDirectoryPrecedence sets which of the regex directories matching a path segment wins,
+// DefaultDirectoryPrecedence by default.
+func (h *Handler[D]) WithDirectoryPrecedence(precedence DirectoryPrecedence) *Handler[D] {
+	h.precedence = precedence
+	return h
+}
+
+// preferredRegexDir returns the regex directory in dir that wins of those matching a path segment.
+func (h requestHandler) preferredRegexDir(dir string, matching []DirEntryWithSubmatches) (DirEntryWithSubmatches, error) {
+	if len(matching) == 1 {
+		return matching[0], nil
+	}
+
+	precedence := h.precedence
+	if precedence == nil {
+		precedence = DefaultDirectoryPrecedence
+	}
+
+	best, bestDir := 0, RegexDirectory{}
+	for i, m := range matching {
+		d, err := h.regexDirectory(dir, m.File.Name())
+		if err != nil {
+			return DirEntryWithSubmatches{}, err
+		}
+		if i == 0 || precedence(d, bestDir) < 0 {
+			best, bestDir = i, d
+		}
+	}
+
+	return matching[best], nil
+}
+
+// regexDirectory describes the regex directory name in dir, for ordering it by precedence.
+func (h requestHandler) regexDirectory(dir, name string) (RegexDirectory, error) {
+	d := RegexDirectory{Name: name}
+
+	re, err := compileRegexPathPart(name)
+	if err != nil {
+		return d, fmt.Errorf("invalid regex directory name: %w", err)
+	}
+	for _, sub := range re.SubexpNames()[1:] {
+		if sub != "" {
+			d.Params++
+		}
+	}
+
+	filename := path.Join(dir, name, priorityFilename)
+	b, err := h.readFile(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return d, nil
+		}
+		return d, err
+	}
+	if d.Priority, err = strconv.Atoi(strings.TrimSpace(string(b))); err != nil {
+		return d, fmt.Errorf("invalid priority %s: %w", filename, err)
+	}
+
+	return d, nil
+}
+
+// warnAmbiguousDirectories logs the regex directories that may match the same path segment with the same
+// priority, which the directory precedence then decides between, usually by name.
+func (h *Handler[D]) warnAmbiguousDirectories(fsys fs.FS) {
+	rh := h.newRequestHandler(h.log)
+	rh.fs = fsys
+
+	fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if name != "." && name == d.Name() && isReservedDir(name) {
+			return fs.SkipDir
+		}
+
+		entries, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			return nil
+		}
+
+		byPriority := make(map[int][]string)
+		for _, e := range entries {
+			if !e.IsDir() || !isRegexPathPart(e.Name()) || isCatchAllPathPart(e.Name()) {
+				continue
+			}
+			rd, err := rh.regexDirectory(name, e.Name())
+			if err != nil {
+				// an invalid expression or priority is reported by Validate.
+				continue
+			}
+			byPriority[rd.Priority] = append(byPriority[rd.Priority], e.Name())
+		}
+
+		for _, dirs := range byPriority {
+			if len(dirs) > 1 {
+				slices.Sort(dirs)
+				h.log.With("dir", canonicalPath(name), "regexDirs", dirs).
+					Warn("regex directories may match the same path segment; set a " + priorityFilename + " to choose between them")
+			}
+		}
+
+		return nil
+	})
+}
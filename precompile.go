@@ -0,0 +1,201 @@
+package htmplx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"testing/fstest"
+	"text/template/parse"
+)
+
+// Precompiled is a tree compiled into Go source by GenerateGo, its files held in memory and its templates
+// parsed when the program starts, rather than read and parsed for every request.
+type Precompiled struct {
+	// FS is the tree.
+	FS fs.FS
+	// Routes is the index of the tree's routes.
+	Routes []RouteInfo
+
+	// templates are the trees of the tree's templates, by their source without front matter, then by the name
+	// they are loaded as, which their errors are reported under.
+	templates map[string]map[string]map[string]*parse.Tree
+}
+
+// Precompile returns the tree of files, by name, with the template files among them parsed as each of the names
+// they are loaded as, for the source generated by GenerateGo, which calls it.
+// Templates failing to parse are left to fail when rendered.
+func Precompile(files map[string]string, templates map[string][]string, routes []RouteInfo) *Precompiled {
+	fsys := make(fstest.MapFS, len(files))
+	for name, content := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(content), Mode: 0o444}
+	}
+
+	p := &Precompiled{
+		FS:        fsys,
+		Routes:    routes,
+		templates: make(map[string]map[string]map[string]*parse.Tree, len(templates)),
+	}
+	for filename, names := range templates {
+		_, content, err := parseFrontMatter([]byte(files[filename]))
+		if err != nil {
+			continue
+		}
+
+		byName := make(map[string]map[string]*parse.Tree, len(names))
+		for _, name := range names {
+			// the functions templates call are only known per request.
+			t := parse.New(name)
+			t.Mode = parse.SkipFuncCheck
+			trees := make(map[string]*parse.Tree)
+			if _, err := t.Parse(string(content), "", "", trees); err == nil {
+				byName[name] = trees
+			}
+		}
+		p.templates[string(content)] = byName
+	}
+
+	return p
+}
+
+// NewHandlerForPrecompiled serves a tree precompiled by GenerateGo.
+func NewHandlerForPrecompiled[D RequestData](p *Precompiled) *Handler[D] {
+	h := NewHandler[D](p.FS)
+	h.precompiled = p
+	return h
+}
+
+// precompiledTrees returns copies of the trees of template source b loaded as the template named name,
+// if it was precompiled. Copies are returned since executing templates escapes them in place.
+func (h requestHandler) precompiledTrees(name string, b []byte) (map[string]*parse.Tree, bool) {
+	if h.precompiled == nil {
+		return nil, false
+	}
+	parsed, ok := h.precompiled.templates[string(b)][name]
+	if !ok {
+		return nil, false
+	}
+
+	trees := make(map[string]*parse.Tree, len(parsed))
+	for treeName, tree := range parsed {
+		trees[treeName] = tree.Copy()
+	}
+	return trees, true
+}
+
+// parseTemplate parses the template source b into t as the template named name, along with the templates it defines,
+// from the trees parsed ahead of time if it was precompiled.
+func (h requestHandler) parseTemplate(t *template.Template, name string, b []byte) (*template.Template, error) {
+	trees, ok := h.precompiledTrees(name, b)
+	if !ok {
+		return t.New(name).Parse(string(b))
+	}
+
+	for treeName, tree := range trees {
+		// as when parsing, empty source does not replace a template of the same name.
+		if parse.IsEmptyTree(tree.Root) && t.Lookup(treeName) != nil {
+			continue
+		}
+		if _, err := t.AddParseTree(treeName, tree); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.Lookup(name), nil
+}
+
+// GenerateGo writes Go source declaring the tree precompiled, as the variable named name of package pkg,
+// to serve with NewHandlerForPrecompiled:
+//
+//	//go:generate htmplx generate ./public -o site_gen.go
+//
+//	h := htmplx.NewHandlerForPrecompiled[htmplx.RequestDataMap](site)
+//
+// The files, those a bundle holds, see ExportBundle, are embedded in the source, and the templates among them
+// parsed when the program starts, so that no file is read from disk nor parsed to serve a request.
+// Markdown and text templates, and templates annotated by WithEditor, are still parsed when rendered.
+// It is meant for production, serving the directory being the mode for development.
+func (h *Handler[D]) GenerateGo(ctx context.Context, w io.Writer, pkg, name string) error {
+	l := h.log.With("generate", true)
+	l.Info("precompiling tree")
+
+	if h.validate {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+	}
+
+	routes, err := h.Routes()
+	if err != nil {
+		return err
+	}
+
+	var files, templates, index bytes.Buffer
+	rh := h.newRequestHandler(l)
+	err = h.walkBundledFiles(ctx, func(filename string, _ fs.FileInfo, b []byte) error {
+		l.Debug("precompiling " + filename)
+		fmt.Fprintf(&files, "%q: %s,\n", filename, strconv.Quote(string(b)))
+		if names := rh.templateNames(filename); len(names) > 0 {
+			fmt.Fprintf(&templates, "%q: %s,\n", filename, strings.TrimPrefix(fmt.Sprintf("%#v", names), "[]string"))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to precompile files: %w", err)
+	}
+	for _, route := range routes {
+		fmt.Fprintf(&index, "%s,\n", strings.TrimPrefix(fmt.Sprintf("%#v", route), "htmplx.RouteInfo"))
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by htmplx generate. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(&src, "import \"github.com/angelbeltran/htmplx\"\n\n")
+	fmt.Fprintf(&src, "// %s is the precompiled tree, to serve with htmplx.NewHandlerForPrecompiled.\n", name)
+	fmt.Fprintf(&src, "var %s = htmplx.Precompile(\nmap[string]string{\n%s},\nmap[string][]string{\n%s},\n[]htmplx.RouteInfo{\n%s},\n)\n",
+		name, files.Bytes(), templates.Bytes(), index.Bytes())
+
+	b, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format generated source: %w", err)
+	}
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("failed to write generated source: %w", err)
+	}
+	return nil
+}
+
+// templateNames returns the names the template file is loaded as, which it is parsed as ahead of time,
+// or none if it is not an html template.
+func (h requestHandler) templateNames(filename string) []string {
+	ext, ok := h.templateExt(filename)
+	if !ok {
+		return nil
+	}
+
+	if name, ok := strings.CutPrefix(filename, componentsDir+"/"); ok {
+		return []string{strings.TrimSuffix(name, ext)}
+	}
+
+	name := strings.TrimSuffix(path.Base(filename), ext)
+	if isDirectoryLayout(name) {
+		// directory layouts are named by how many wrap them, at most one per directory above theirs.
+		names := []string{"body"}
+		for i := range strings.Count(filename, "/") {
+			names = append(names, directoryLayoutTemplateName(i+1))
+		}
+		return names
+	}
+
+	names := []string{name}
+	// the templates of a view are also loaded in place of their default counterparts.
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		names = append(names, name[:i])
+	}
+	return names
+}
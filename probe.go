@@ -0,0 +1,150 @@
+package htmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ProbeResult is the outcome of rendering a route by Probe.
+type ProbeResult struct {
+	// Route is the url path rendered, e.g. /blog/42.
+	Route string
+	// Pattern is the pattern of the route, e.g. /blog/{(?P<id>[0-9]+)}.
+	Pattern string
+	// Status is the status of the page rendered, as it would be served.
+	Status int
+	Err    error
+}
+
+// Failed reports whether the route failed to render. Routes not found are not failures,
+// since directories such as those of static files have no page.
+func (res ProbeResult) Failed() bool {
+	return res.Err != nil && res.Status != http.StatusNotFound && res.Status != http.StatusGone
+}
+
+// Probe renders a sample of every route of the tree, discarding the pages, to catch template and data
+// regressions before users do. Routes with parameters are rendered with the values of sampleParams
+// by parameter name, e.g. {"id": "42"} for /blog/{(?P<id>[0-9]+)}, and skipped if one is missing; see Routes.
+// Each route is recorded in the metrics of WithMetrics, and each failure reported to the error reporter
+// of WithErrorReporter with a GET request for the route. The error joins the failures, if any.
+func (h *Handler[D]) Probe(ctx context.Context, sampleParams map[string]string) ([]ProbeResult, error) {
+	routes, err := h.Routes()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ProbeResult
+	var jobs []RenderJob[D]
+	for _, route := range routes {
+		path, ok := sampleRoutePath(route, sampleParams)
+		if !ok {
+			h.log.Debug("no sample parameters to probe " + route.Pattern)
+			continue
+		}
+		results = append(results, ProbeResult{Route: path, Pattern: route.Pattern})
+		jobs = append(jobs, RenderJob[D]{Route: path})
+	}
+
+	var errs []error
+	for i, res := range h.RenderAll(ctx, jobs, runtime.GOMAXPROCS(0)) {
+		results[i].Status = res.Status
+		results[i].Err = res.Err
+		failed := results[i].Failed()
+		if failed {
+			errs = append(errs, res.Err)
+		}
+		// routes not rendered before ctx is done did not fail.
+		if ctx.Err() != nil && errors.Is(res.Err, ctx.Err()) {
+			continue
+		}
+		h.metrics.observeProbe(results[i].Pattern, failed)
+		if failed {
+			h.reportProbeFailure(ctx, results[i])
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return results, fmt.Errorf("probe failed:\n%w", err)
+	}
+
+	return results, nil
+}
+
+// reportProbeFailure reports the failure of a route to the error reporter, with a request for the route.
+func (h *Handler[D]) reportProbeFailure(ctx context.Context, res ProbeResult) {
+	if h.errorReporter == nil {
+		return
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, res.Route, nil)
+	if err != nil {
+		h.log.With("route", res.Route, "error", err).
+			Error("failed to report probe failure")
+		return
+	}
+	h.reportError(r, res.Err)
+}
+
+// sampleRoutePath is the path of the route with its parameters filled in from params,
+// or false if one is missing.
+func sampleRoutePath(route RouteInfo, params map[string]string) (string, bool) {
+	segments := splitPath(route.Pattern)
+	next := 0
+	for i, dir := range segments {
+		if !isRegexPathPart(dir) {
+			continue
+		}
+		value, ok := params[route.Params[next].Name]
+		if !ok {
+			return "", false
+		}
+		segments[i] = value
+		next++
+	}
+	return "/" + strings.Join(segments, "/"), true
+}
+
+// StartProbe runs Probe every interval until ctx is done, such as an uptime check of a production deploy.
+// Each failure is logged, with the fingerprint of its error, and passed to onFailure, if not nil,
+// besides being recorded and reported as by Probe.
+func (h *Handler[D]) StartProbe(ctx context.Context, interval time.Duration, sampleParams map[string]string, onFailure func(ProbeResult)) {
+	l := h.log.With("interval", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			l.Debug("probing routes")
+			results, err := h.Probe(ctx, sampleParams)
+			if err != nil && ctx.Err() != nil {
+				return
+			}
+			if err != nil && results == nil {
+				l.With("error", err).
+					Error("failed to probe routes")
+				continue
+			}
+
+			for _, res := range results {
+				if !res.Failed() {
+					continue
+				}
+				l.With("route", res.Route, "status", res.Status, "error", res.Err, "fingerprint", FingerprintError(res.Err).ID).
+					Error("probe failed")
+				if onFailure != nil {
+					onFailure(res)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,107 @@
+package htmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+)
+
+// purgeTimeout bounds purging the edge caches when the tree changes.
+const purgeTimeout = time.Minute
+
+// Purger removes URLs from an edge cache, such as a CDN, so that the next request for them reaches the handler.
+// See the purge package for Cloudflare and Fastly implementations.
+type Purger interface {
+	// Purge purges the given url paths, e.g. "/blog/42".
+	Purge(ctx context.Context, paths []string) error
+}
+
+// SitePurger is a Purger that can also purge every url of the site at once, which is done when the tree changes.
+type SitePurger interface {
+	Purger
+	// PurgeAll purges every url.
+	PurgeAll(ctx context.Context) error
+}
+
+// WithPurgers sets the edge caches purged when routes are invalidated, by InvalidateResponses or Purge,
+// and when the tree changes: SitePurgers are purged entirely, and other purgers of the routes without parameters.
+func (h *Handler[D]) WithPurgers(purgers ...Purger) *Handler[D] {
+	h.purgers = purgers
+	return h
+}
+
+// Purge purges the given url paths from all configured edge caches.
+// Every purger is called, even if one fails.
+func (h *Handler[D]) Purge(ctx context.Context, paths ...string) error {
+	if len(h.purgers) == 0 || len(paths) == 0 {
+		return nil
+	}
+
+	cleanPaths := make([]string, len(paths))
+	for i, p := range paths {
+		cleanPaths[i] = path.Clean("/" + p)
+	}
+
+	l := h.log.With("paths", cleanPaths)
+	l.Debug("purging edge caches")
+
+	var errs []error
+	for i, p := range h.purgers {
+		if err := p.Purge(ctx, cleanPaths); err != nil {
+			l.With("error", err).
+				Error("failed to purge edge cache")
+			errs = append(errs, fmt.Errorf("purger %d: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// purgeTree purges the edge caches of the pages of the previous tree, in the background, as purging takes
+// requests to them. Purgers that cannot purge everything, or fail to, purge the paths of the routes
+// without parameters, the only ones known.
+func (h *Handler[D]) purgeTree() {
+	if len(h.purgers) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), purgeTimeout)
+		defer cancel()
+
+		l := h.log.With("purge", "tree")
+		l.Debug("purging edge caches of the tree")
+
+		var paths []string
+		for i, p := range h.purgers {
+			if sp, ok := p.(SitePurger); ok {
+				err := sp.PurgeAll(ctx)
+				if err == nil {
+					continue
+				}
+				l.With("error", err, "purger", i).
+					Warn("failed to purge edge cache entirely, purging routes")
+			}
+
+			if paths == nil {
+				routes, err := h.Routes()
+				if err != nil {
+					l.With("error", err).
+						Error("failed to list routes to purge")
+					return
+				}
+				for _, route := range routes {
+					if len(route.Params) == 0 {
+						paths = append(paths, route.Pattern)
+					}
+				}
+			}
+			if err := p.Purge(ctx, paths); err != nil {
+				l.With("error", err, "purger", i).
+					Error("failed to purge edge cache")
+			}
+		}
+	}()
+}
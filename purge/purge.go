@@ -0,0 +1,189 @@
+// Package purge implements htmplx.Purger for common CDNs.
+package purge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Cloudflare purges URLs from a Cloudflare zone's cache.
+type Cloudflare struct {
+	// ZoneID identifies the zone to purge.
+	ZoneID string
+	// APIToken is a token with the Cache Purge permission.
+	APIToken string
+	// SiteURL is the scheme and host paths are purged under, e.g. https://example.com.
+	SiteURL string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// Endpoint defaults to https://api.cloudflare.com/client/v4.
+	Endpoint string
+}
+
+// cloudflareMaxFilesPerRequest is the most urls Cloudflare accepts in a single purge request.
+const cloudflareMaxFilesPerRequest = 30
+
+func (c Cloudflare) Purge(ctx context.Context, paths []string) error {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.cloudflare.com/client/v4"
+	}
+
+	urls := absoluteURLs(c.SiteURL, paths)
+
+	for len(urls) > 0 {
+		n := min(len(urls), cloudflareMaxFilesPerRequest)
+		batch := urls[:n]
+		urls = urls[n:]
+
+		body, err := json.Marshal(map[string][]string{"files": batch})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+			endpoint+"/zones/"+url.PathEscape(c.ZoneID)+"/purge_cache",
+			bytes.NewReader(body),
+		)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.APIToken)
+		req.Header.Set("Content-Type", "application/json")
+
+		if err := do(client(c.Client), req); err != nil {
+			return fmt.Errorf("cloudflare purge failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PurgeAll purges everything cached for the zone.
+func (c Cloudflare) PurgeAll(ctx context.Context) error {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.cloudflare.com/client/v4"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		endpoint+"/zones/"+url.PathEscape(c.ZoneID)+"/purge_cache",
+		strings.NewReader(`{"purge_everything":true}`),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := do(client(c.Client), req); err != nil {
+		return fmt.Errorf("cloudflare purge failed: %w", err)
+	}
+	return nil
+}
+
+// Fastly purges URLs from a Fastly service's cache, one request per URL.
+type Fastly struct {
+	// APIToken is a token with the purge_select scope.
+	APIToken string
+	// SiteURL is the scheme and host paths are purged under, e.g. https://example.com.
+	SiteURL string
+	// Soft marks content as stale rather than removing it.
+	Soft bool
+	// ServiceID identifies the service purged by PurgeAll, which requires a token with the purge_all scope.
+	ServiceID string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// Endpoint defaults to https://api.fastly.com.
+	Endpoint string
+}
+
+func (f Fastly) Purge(ctx context.Context, paths []string) error {
+	endpoint := f.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.fastly.com"
+	}
+
+	for _, u := range absoluteURLs(f.SiteURL, paths) {
+		// the purge endpoint takes the url without its scheme.
+		_, hostAndPath, _ := strings.Cut(u, "://")
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/purge/"+hostAndPath, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Fastly-Key", f.APIToken)
+		if f.Soft {
+			req.Header.Set("Fastly-Soft-Purge", "1")
+		}
+
+		if err := do(client(f.Client), req); err != nil {
+			return fmt.Errorf("fastly purge of %s failed: %w", u, err)
+		}
+	}
+
+	return nil
+}
+
+// PurgeAll purges everything cached by the service. Soft purges do not apply to it.
+func (f Fastly) PurgeAll(ctx context.Context) error {
+	if f.ServiceID == "" {
+		return errors.New("fastly purge of everything requires a ServiceID")
+	}
+
+	endpoint := f.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.fastly.com"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/service/"+url.PathEscape(f.ServiceID)+"/purge_all", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Fastly-Key", f.APIToken)
+
+	if err := do(client(f.Client), req); err != nil {
+		return fmt.Errorf("fastly purge of everything failed: %w", err)
+	}
+	return nil
+}
+
+func absoluteURLs(siteURL string, paths []string) []string {
+	siteURL = strings.TrimSuffix(siteURL, "/")
+
+	urls := make([]string, len(paths))
+	for i, p := range paths {
+		urls[i] = siteURL + "/" + strings.TrimPrefix(p, "/")
+	}
+	return urls
+}
+
+func client(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
+
+func do(c *http.Client, req *http.Request) error {
+	res, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", res.Status, bytes.TrimSpace(b))
+	}
+
+	io.Copy(io.Discard, res.Body)
+	return nil
+}
@@ -0,0 +1,263 @@
+package htmplx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteObject is a file of a RemoteStore.
+type RemoteObject struct {
+	// Name is the slash separated path of the file, relative to the root of the store.
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// ETag identifies the content of the file, so an unchanged file is not fetched again.
+	ETag string `json:"etag"`
+}
+
+// RemoteStore is a tree of files held remotely, such as in an object store bucket, served with a RemoteTree.
+type RemoteStore interface {
+	// List lists every file of the store.
+	List(ctx context.Context) ([]RemoteObject, error)
+	// Open reads a file of the store.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// RemoteTree fetches the files of a RemoteStore as a tree to serve, caching them locally so only files changed
+// since the last fetch are downloaded. New versions are rolled out with StageTree and PromoteTree:
+//
+//	remote := htmplx.NewRemoteTree(htmplx.BucketStore{URL: "https://templates.s3.amazonaws.com"}, "/var/cache/templates")
+//	fsys, version, err := remote.Fetch(ctx)
+//	...
+//	if err := h.StageTree(ctx, version, fsys); err == nil {
+//		h.PromoteTree()
+//	}
+type RemoteTree struct {
+	store RemoteStore
+	// cacheDir, if set, keeps fetched files on disk, across restarts.
+	cacheDir string
+
+	mu sync.Mutex
+	// cache holds the content of the files of the last fetch, by remoteCacheKey.
+	cache map[string][]byte
+}
+
+// NewRemoteTree fetches the files of store, keeping them in cacheDir, or only in memory if cacheDir is empty.
+func NewRemoteTree(store RemoteStore, cacheDir string) *RemoteTree {
+	return &RemoteTree{
+		store:    store,
+		cacheDir: cacheDir,
+		cache:    make(map[string][]byte),
+	}
+}
+
+// Fetch returns the files of the store as a file system, held in memory, and its version, a hash of the
+// listing of the store that changes whenever a file does. Files unchanged since they were last fetched are
+// not downloaded again.
+func (t *RemoteTree) Fetch(ctx context.Context) (fs.FS, string, error) {
+	objects, err := t.store.List(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list remote files: %w", err)
+	}
+	slices.SortFunc(objects, func(a, b RemoteObject) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := newMemFS()
+	cache := make(map[string][]byte, len(objects))
+	version := sha256.New()
+
+	for _, obj := range objects {
+		name := strings.TrimPrefix(path.Clean("/"+obj.Name), "/")
+		if name == "" || strings.HasSuffix(obj.Name, "/") {
+			// a directory placeholder, as some object stores list.
+			continue
+		}
+
+		key := remoteCacheKey(obj)
+		b, err := t.read(ctx, obj, key)
+		if err != nil {
+			return nil, "", err
+		}
+		if key != "" {
+			cache[key] = b
+		}
+
+		snapshot.add(name, b, obj.ModTime)
+		fmt.Fprintf(version, "%s\x00%s\x00", name, key)
+		if key == "" {
+			version.Write(b)
+		}
+	}
+
+	// files no longer in the store are forgotten.
+	t.cache = cache
+
+	return snapshot, hex.EncodeToString(version.Sum(nil))[:assetHashLen], nil
+}
+
+// read returns the content of obj, from the cache if it is unchanged since it was fetched.
+func (t *RemoteTree) read(ctx context.Context, obj RemoteObject, key string) ([]byte, error) {
+	if key != "" {
+		if b, ok := t.cache[key]; ok {
+			return b, nil
+		}
+		if t.cacheDir != "" {
+			if b, err := os.ReadFile(filepath.Join(t.cacheDir, key)); err == nil {
+				return b, nil
+			}
+		}
+	}
+
+	rc, err := t.store.Open(ctx, obj.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remote file %s: %w", obj.Name, err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remote file %s: %w", obj.Name, err)
+	}
+
+	if key != "" && t.cacheDir != "" {
+		if err := os.MkdirAll(t.cacheDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to cache remote file %s: %w", obj.Name, err)
+		}
+		if err := os.WriteFile(filepath.Join(t.cacheDir, key), b, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to cache remote file %s: %w", obj.Name, err)
+		}
+	}
+
+	return b, nil
+}
+
+// remoteCacheKey identifies the content of obj, by its ETag, or else its size and modification time.
+// It is empty if the content cannot be told apart from other versions of the file, which is then always fetched.
+func remoteCacheKey(obj RemoteObject) string {
+	version := obj.ETag
+	if version == "" {
+		if obj.ModTime.IsZero() {
+			return ""
+		}
+		version = strconv.FormatInt(obj.Size, 10) + "-" + strconv.FormatInt(obj.ModTime.UnixNano(), 10)
+	}
+
+	sum := sha256.Sum256([]byte(obj.Name + "\x00" + version))
+	return hex.EncodeToString(sum[:])
+}
+
+// memFS is a read only file system held in memory, with directories implied by the paths of its files.
+type memFS struct {
+	files map[string]*memFileInfo
+	// dirs are the entries of each directory, by path.
+	dirs map[string][]fs.DirEntry
+}
+
+func newMemFS() *memFS {
+	return &memFS{
+		files: make(map[string]*memFileInfo),
+		dirs:  map[string][]fs.DirEntry{".": nil},
+	}
+}
+
+// add adds a file, and the directories it is in.
+func (m *memFS) add(name string, data []byte, modTime time.Time) {
+	if _, ok := m.files[name]; ok {
+		return
+	}
+
+	info := &memFileInfo{name: path.Base(name), data: data, modTime: modTime}
+	m.files[name] = info
+
+	entry := fs.FileInfoToDirEntry(info)
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		_, exists := m.dirs[dir]
+		m.dirs[dir] = append(m.dirs[dir], entry)
+		if exists || dir == "." {
+			break
+		}
+		entry = fs.FileInfoToDirEntry(&memFileInfo{name: path.Base(dir), dir: true})
+	}
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if info, ok := m.files[name]; ok {
+		return &memFile{info: info, Reader: bytes.NewReader(info.data)}, nil
+	}
+	if entries, ok := m.dirs[name]; ok {
+		info := &memFileInfo{name: path.Base(name), dir: true}
+		return &overlayDir{File: &memFile{info: info, Reader: bytes.NewReader(nil)}, entries: sortedEntries(entries)}, nil
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, ok := m.dirs[name]
+	if !ok {
+		if _, isFile := m.files[name]; isFile {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+		}
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return sortedEntries(entries), nil
+}
+
+func sortedEntries(entries []fs.DirEntry) []fs.DirEntry {
+	entries = slices.Clone(entries)
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries
+}
+
+type memFileInfo struct {
+	name    string
+	data    []byte
+	modTime time.Time
+	dir     bool
+}
+
+func (fi *memFileInfo) Name() string       { return fi.name }
+func (fi *memFileInfo) Size() int64        { return int64(len(fi.data)) }
+func (fi *memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *memFileInfo) IsDir() bool        { return fi.dir }
+func (fi *memFileInfo) Sys() any           { return nil }
+
+func (fi *memFileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// memFile is an open file of a memFS. It is seekable, so may be served with range requests.
+type memFile struct {
+	info *memFileInfo
+	*bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
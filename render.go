@@ -0,0 +1,188 @@
+package htmplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+)
+
+// RenderTemplate renders the template named name with data, as defined along the route,
+// so that jobs outside of an http request, such as emails, exports or push notification previews,
+// reuse the fragments of pages:
+//
+//	receipt, err := h.RenderTemplate(ctx, "/orders/42", "receipt", data)
+//
+// Components, and the templates along the route, are available whether or not the route has a body,
+// and the funcs of WithFuncs are given a GET request for the route, with ctx as its context.
+// The data callback is not called.
+func (h *Handler[D]) RenderTemplate(ctx context.Context, route, name string, data D) (template.HTML, error) {
+	l := h.routeLog(route).With("template", name)
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, route, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid route %s: %w", route, err)
+	}
+	r.URL = nfcPath(r.URL)
+	if err := checkPath(r.URL); err != nil {
+		return "", fmt.Errorf("invalid route %s: %w", route, err)
+	}
+
+	rh := h.newRequestHandler(l)
+	rh.fs = h.requestFS(r)
+	rh.assets = h.requestAssets(r)
+	rh.location = h.resolveTimezone(r)
+	rh.fragments = h.newFragmentRenderer(r)
+
+	resolved, err := rh.resolveRoute(r.URL.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve route %s: %w", route, err)
+	}
+	rh.route = resolved
+	r = r.WithContext(contextWithRoute(r.Context(), resolved))
+
+	layout, err := h.newLayout(r, rh)
+	if err != nil {
+		return "", err
+	}
+
+	// a route without a body of its own still has templates to render.
+	if err := rh.loadTemplates(layout, resolved); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if err := rh.completeLayout(layout, resolved); err != nil {
+		return "", err
+	}
+
+	if layout.Lookup(name) == nil {
+		return "", fmt.Errorf("template %s not found along %s: %w", name, route, fs.ErrNotExist)
+	}
+
+	var buf bytes.Buffer
+	if err := h.sandbox.execute(&buf, func(w io.Writer) error {
+		return layout.ExecuteTemplate(w, name, data)
+	}); err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+
+	return template.HTML(buf.String()), nil
+}
+
+// RenderJob is a page or template rendered by RenderAll.
+type RenderJob[D RequestData] struct {
+	// Route is the url path of the page rendered, e.g. /blog/42, or along which Template is defined.
+	Route string
+	// Header is the header of the request the page is rendered for, e.g. an Accept header.
+	Header http.Header
+	// Template, if set, is rendered with Data, as by RenderTemplate, rather than the page.
+	Template string
+	Data     D
+	// Done, if set, is called with the result once the job is rendered, e.g. to report progress.
+	// It may be called concurrently with other jobs' Done.
+	Done func(RenderResult)
+}
+
+// RenderResult is the outcome of a RenderJob.
+type RenderResult struct {
+	Body        []byte
+	ContentType string
+	// Status is the status of the page rendered, as it would be served.
+	Status int
+	Err    error
+}
+
+// RenderAll renders the jobs with up to concurrency workers, every job if concurrency is less than 1,
+// returning their results in the order of the jobs, for static exports, cache warming or batches of emails.
+// A job failing does not stop the others. Jobs not started before ctx is done fail with its error.
+func (h *Handler[D]) RenderAll(ctx context.Context, jobs []RenderJob[D], concurrency int) []RenderResult {
+	if concurrency < 1 || concurrency > len(jobs) {
+		concurrency = len(jobs)
+	}
+
+	results := make([]RenderResult, len(jobs))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = h.renderJob(ctx, jobs[i])
+				if jobs[i].Done != nil {
+					jobs[i].Done(results[i])
+				}
+			}
+		}()
+	}
+
+	for i := range jobs {
+		if err := ctx.Err(); err != nil {
+			results[i] = RenderResult{Err: err}
+			if jobs[i].Done != nil {
+				jobs[i].Done(results[i])
+			}
+			continue
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return results
+}
+
+func (h *Handler[D]) renderJob(ctx context.Context, job RenderJob[D]) RenderResult {
+	if job.Template != "" {
+		out, err := h.RenderTemplate(ctx, job.Route, job.Template, job.Data)
+		if err != nil {
+			return RenderResult{Err: err}
+		}
+		return RenderResult{Body: []byte(out), ContentType: "text/html; charset=utf-8", Status: http.StatusOK}
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, job.Route, nil)
+	if err != nil {
+		return RenderResult{Err: fmt.Errorf("invalid route %s: %w", job.Route, err)}
+	}
+	if job.Header != nil {
+		r.Header = job.Header.Clone()
+	}
+
+	res := RenderResult{Status: http.StatusOK}
+
+	out, contentType, err := h.ServeFile(r)
+	if err != nil {
+		res.Err = fmt.Errorf("failed to render %s: %w", job.Route, err)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			if out != nil {
+				out.Close()
+			}
+			res.Status = http.StatusInternalServerError
+			return res
+		}
+		res.Status = statusErr.Code
+	}
+	if out == nil {
+		if res.Err == nil {
+			res.Status = http.StatusNotFound
+			res.Err = fmt.Errorf("failed to render %s: %w", job.Route, fs.ErrNotExist)
+		}
+		return res
+	}
+	defer out.Close()
+
+	res.ContentType = contentType
+	if res.Body, err = io.ReadAll(out); err != nil {
+		res.Status = http.StatusInternalServerError
+		res.Err = fmt.Errorf("failed to render %s: %w", job.Route, err)
+	}
+
+	return res
+}
@@ -0,0 +1,93 @@
+package htmplx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+)
+
+// requestIDHeader is the header a request's id is read from, and the response's is written to.
+const requestIDHeader = "X-Request-Id"
+
+// maxRequestIDLen is the longest request id read from a request.
+const maxRequestIDLen = 128
+
+type requestIDContextKey struct{}
+
+// WithRequestIDs gives every request an id while enabled, to correlate its logs and errors with what a user reports:
+// that of its X-Request-Id header, as set by a proxy in front, or a random one. The id is logged with every message
+// about the request, set as the X-Request-Id header of the response, and available to the data callback and
+// middleware with RequestIDFromContext, and to templates with the requestID func, e.g. for an error page:
+//
+//	<p>Something went wrong. Quote {{requestID}} when reporting it.</p>
+func (h *Handler[D]) WithRequestIDs(enabled bool) *Handler[D] {
+	h.requestIDs = enabled
+	return h
+}
+
+// RequestIDFromContext returns the id of the request of ctx, if request ids are enabled.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok
+}
+
+// withRequestID returns the request with its id in its context, reading it from the request or generating it,
+// and sets it on the response.
+func (h *Handler[D]) withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(requestIDHeader)
+	if !isValidRequestID(id) {
+		id = newRequestID()
+	}
+
+	w.Header().Set(requestIDHeader, id)
+	return r.WithContext(context.WithValue(r.Context(), requestIDContextKey{}, id))
+}
+
+// isValidRequestID reports whether id, read from a request, is safe to log and echo in a header.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := range len(id) {
+		c := id[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' ||
+			c == '.' || c == ':' || c == '+' || c == '/' || c == '=') {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random request id, 32 hex digits.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestLog returns the handler's logger, with the path of the request, and its id, if any,
+// in the request group, e.g. request.path=/blog/42 request.id=4bf92f3577b34da6a3ce929d0e0e4736.
+func (h *Handler[D]) requestLog(r *http.Request) *slog.Logger {
+	attrs := []any{slog.String("path", r.URL.Path)}
+	if id, ok := RequestIDFromContext(r.Context()); ok {
+		attrs = append(attrs, slog.String("id", id))
+	}
+	return h.log.With(slog.Group(requestLogGroup, attrs...))
+}
+
+// routeLog returns the handler's logger, with the url path of the route rendered outside of a request,
+// in the request group, as by requestLog.
+func (h *Handler[D]) routeLog(urlPath string) *slog.Logger {
+	return h.log.With(slog.Group(requestLogGroup, slog.String("path", urlPath)))
+}
+
+// requestIDFunc returns the id of the request being rendered, or "" if request ids are disabled.
+// A page rendering the id is unique to the request, so is not cached by the response cache.
+func (h requestHandler) requestIDFunc() string {
+	if h.requestID != "" && h.uncacheable != nil {
+		h.uncacheable.Store(true)
+	}
+	return h.requestID
+}
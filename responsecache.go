@@ -0,0 +1,269 @@
+package htmplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CachedResponse is a rendered page held by a ResponseStore.
+type CachedResponse struct {
+	// Path is the url path of the page, by which it is invalidated.
+	Path        string
+	ContentType string
+	Body        []byte
+}
+
+// ResponseStore holds the pages cached by WithResponseCache, such as in memory or in a shared cache.
+// It must be safe for concurrent use.
+type ResponseStore interface {
+	// Get returns the response stored under key, or nil if there is none or it expired.
+	Get(ctx context.Context, key string) (*CachedResponse, error)
+	// Set stores res under key for ttl.
+	Set(ctx context.Context, key string, res *CachedResponse, ttl time.Duration) error
+	// DeletePaths deletes the responses of the url paths, whatever else their keys vary by.
+	DeletePaths(ctx context.Context, paths []string) error
+	// Clear deletes every response.
+	Clear(ctx context.Context) error
+}
+
+// responseCache is the configuration of WithResponseCache.
+type responseCache struct {
+	store ResponseStore
+	ttl   time.Duration
+}
+
+// WithResponseCache caches rendered pages in store for ttl, so pages with expensive data callbacks
+// are rendered once per ttl rather than once per request. The data callback is not called for cached pages.
+// Pages are cached by CacheKey, varying by the HX-Request and Accept headers, so htmx requests and
+// alternate representations are cached apart; WithCacheKey varies them by more, such as a subset of cookies.
+// Only successful responses to requests for pages are cached, not static files, error statuses or long polls,
+// nor pages rendering the request's id, see WithRequestIDs. Pages are rendered in each timezone apart,
+// see WithTimezoneResolver.
+// InvalidateResponses drops pages whose data changed, and changes to the tree drop every page.
+func (h *Handler[D]) WithResponseCache(store ResponseStore, ttl time.Duration) *Handler[D] {
+	h.responseCache = &responseCache{store: store, ttl: ttl}
+	return h
+}
+
+// InvalidateResponses drops the cached pages of the url paths, e.g. /blog/42, for every variant of them,
+// and purges them from the edge caches set by WithPurgers.
+func (h *Handler[D]) InvalidateResponses(ctx context.Context, paths ...string) error {
+	if h.responseCache == nil && len(h.purgers) == 0 || len(paths) == 0 {
+		return nil
+	}
+
+	cleanPaths := make([]string, len(paths))
+	for i, p := range paths {
+		cleanPaths[i] = canonicalPath(p)
+	}
+
+	h.log.With("paths", cleanPaths).
+		Debug("invalidating cached responses")
+
+	var err error
+	if h.responseCache != nil {
+		err = h.responseCache.store.DeletePaths(ctx, cleanPaths)
+	}
+	return errors.Join(err, h.Purge(ctx, cleanPaths...))
+}
+
+// clearResponseCache drops every cached page, for a new tree.
+func (h *Handler[D]) clearResponseCache() {
+	if h.responseCache == nil {
+		return
+	}
+	if err := h.responseCache.store.Clear(context.Background()); err != nil {
+		h.log.With("error", err).
+			Error("failed to clear response cache")
+	}
+}
+
+// responseCacheKey is the key the request's page is cached under, or empty if it must not be cached.
+func (h *Handler[D]) responseCacheKey(r *http.Request) string {
+	if h.responseCache == nil || r.Method != http.MethodGet {
+		return ""
+	}
+	if r.URL.Query().Has(sinceParam) {
+		return ""
+	}
+	if _, captured := captureFromContext(r.Context()); captured {
+		return ""
+	}
+	if _, ok := FormValuesFromContext(r.Context()); ok {
+		return ""
+	}
+
+	key := h.CacheKey(r) + "\x00" + r.Header.Get("HX-Request") + "\x00" + r.Header.Get("Accept")
+	if client, _ := ClientFromContext(r.Context()); client.Bot {
+		key += "\x00bot"
+	}
+	return key
+}
+
+// uncacheableContextKey is the context key of the *atomic.Bool set while rendering a page for the response cache
+// if the page is unique to the request, such as when it renders the request's id, so must not be cached.
+type uncacheableContextKey struct{}
+
+// serveCachedFile is serveFileRecovered, serving the page from the response cache, if enabled,
+// and caching it when it is rendered.
+func (h *Handler[D]) serveCachedFile(r *http.Request) (out io.ReadCloser, contentType string, err error) {
+	key := h.responseCacheKey(r)
+	if key == "" {
+		return h.serveFileRecovered(r)
+	}
+
+	l := h.requestLog(r)
+	store := h.responseCache.store
+
+	cached, err := store.Get(r.Context(), key)
+	if err != nil {
+		l.With("error", err).
+			Error("failed to get cached response")
+	} else if cached != nil {
+		l.Debug("serving cached response")
+		h.metrics.observeLookup("response", true)
+		return io.NopCloser(bytes.NewReader(cached.Body)), cached.ContentType, nil
+	}
+	h.metrics.observeLookup("response", false)
+
+	uncacheable := new(atomic.Bool)
+	out, contentType, err = h.serveFileRecovered(r.WithContext(context.WithValue(r.Context(), uncacheableContextKey{}, uncacheable)))
+	if err != nil || out == nil {
+		return out, contentType, err
+	}
+	if uncacheable.Load() {
+		l.Debug("not caching response unique to the request")
+		return out, contentType, nil
+	}
+	defer out.Close()
+
+	b, err := io.ReadAll(out)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res := &CachedResponse{Path: canonicalPath(r.URL.Path), ContentType: contentType, Body: b}
+	if err := store.Set(r.Context(), key, res, h.responseCache.ttl); err != nil {
+		l.With("error", err).
+			Error("failed to cache response")
+	}
+
+	return io.NopCloser(bytes.NewReader(b)), contentType, nil
+}
+
+// MemoryResponseStore is a ResponseStore held in memory, for a single instance.
+type MemoryResponseStore struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*memoryResponse
+	// paths are the keys of the responses of each url path.
+	paths map[string]map[string]bool
+}
+
+type memoryResponse struct {
+	res     *CachedResponse
+	expires time.Time
+}
+
+// NewMemoryResponseStore holds up to maxEntries responses, those closest to expiring evicted first,
+// or any number if maxEntries is 0.
+func NewMemoryResponseStore(maxEntries int) *MemoryResponseStore {
+	return &MemoryResponseStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*memoryResponse),
+		paths:      make(map[string]map[string]bool),
+	}
+}
+
+func (s *MemoryResponseStore) Get(ctx context.Context, key string) (*CachedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(e.expires) {
+		s.delete(key)
+		return nil, nil
+	}
+
+	return e.res, nil
+}
+
+func (s *MemoryResponseStore) Set(ctx context.Context, key string, res *CachedResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.delete(key)
+
+	if s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				s.delete(k)
+			}
+		}
+	}
+	for s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		var soonest string
+		for k, e := range s.entries {
+			if soonest == "" || e.expires.Before(s.entries[soonest].expires) {
+				soonest = k
+			}
+		}
+		s.delete(soonest)
+	}
+
+	s.entries[key] = &memoryResponse{res: res, expires: now.Add(ttl)}
+	if s.paths[res.Path] == nil {
+		s.paths[res.Path] = make(map[string]bool)
+	}
+	s.paths[res.Path][key] = true
+
+	return nil
+}
+
+func (s *MemoryResponseStore) DeletePaths(ctx context.Context, paths []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range paths {
+		for key := range s.paths[p] {
+			s.delete(key)
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryResponseStore) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.entries)
+	clear(s.paths)
+
+	return nil
+}
+
+// delete deletes the response of key, if any. s.mu must be held.
+func (s *MemoryResponseStore) delete(key string) {
+	e, ok := s.entries[key]
+	if !ok {
+		return
+	}
+
+	delete(s.entries, key)
+	delete(s.paths[e.res.Path], key)
+	if len(s.paths[e.res.Path]) == 0 {
+		delete(s.paths, e.res.Path)
+	}
+}
@@ -0,0 +1,71 @@
+package htmplx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+func newResponseCacheTestHandler(calls *atomic.Int32) *Handler[RequestDataMap] {
+	return newTestHandler(htmplxtest.FS(map[string]string{
+		"body.html.tmpl":         `<main>home</main>`,
+		"error/body.html.tmpl":   `<main>quote {{requestID}}</main>`,
+		"meeting/body.html.tmpl": `<main>{{timezone}}</main>`,
+	})).WithData(func(r *http.Request) RequestDataMap {
+		calls.Add(1)
+		return RequestDataMap{}
+	}).WithResponseCache(NewMemoryResponseStore(0), time.Minute)
+}
+
+func TestResponseCacheRequestIDs(t *testing.T) {
+	var calls atomic.Int32
+	h := newResponseCacheTestHandler(&calls).WithRequestIDs(true)
+
+	// pages rendering the request's id are never cached.
+	first, second := get(h, "/error"), get(h, "/error")
+	if first.Body.String() == second.Body.String() {
+		t.Errorf("got %q twice, want each request's own id", first.Body)
+	}
+	for _, w := range []*httptest.ResponseRecorder{first, second} {
+		if id := w.Header().Get("X-Request-Id"); !strings.Contains(w.Body.String(), "quote "+id) {
+			t.Errorf("got %q, want it to quote %s", w.Body, id)
+		}
+	}
+
+	// others still are.
+	calls.Store(0)
+	get(h, "/")
+	get(h, "/")
+	if calls.Load() != 1 {
+		t.Errorf("data callback called %d times, want once, the page cached", calls.Load())
+	}
+}
+
+func TestResponseCacheTimezones(t *testing.T) {
+	var calls atomic.Int32
+	h := newResponseCacheTestHandler(&calls).WithTimezoneResolver(TimezoneCookie("tz"))
+
+	getIn := func(tz string) string {
+		r := httptest.NewRequest(http.MethodGet, "/meeting", nil)
+		if tz != "" {
+			r.AddCookie(&http.Cookie{Name: "tz", Value: tz})
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	for _, tz := range []string{"Europe/Paris", "Asia/Tokyo", "Europe/Paris", "Asia/Tokyo"} {
+		if body := getIn(tz); !strings.Contains(body, tz) {
+			t.Errorf("%s: got %q, want the page rendered in its timezone", tz, body)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("data callback called %d times, want once per timezone", calls.Load())
+	}
+}
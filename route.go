@@ -0,0 +1,312 @@
+package htmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// Route is a request path resolved to a directory in the file system.
+type Route struct {
+	// Path is the url path that was resolved.
+	Path string
+	// Dirs are the names of the directories matched by each segment of the path,
+	// e.g. ["blog", "{(?P<id>[0-9]+)}"] for /blog/42. A catch-all directory, e.g. {...rest}, is the last
+	// and matches the remainder of the path.
+	Dirs []string
+	// Submatches are the path expression submatches of each directory in Dirs.
+	Submatches []DirEntryWithSubmatches
+	// Locale is the locale of the path's prefix, e.g. de for /de/produkte, when the tree declares locales.
+	Locale string
+}
+
+// Pattern is the directory path the route resolved to, e.g. /blog/{(?P<id>[0-9]+)}.
+func (r Route) Pattern() string {
+	return "/" + strings.Join(r.Dirs, "/")
+}
+
+// Dir is the directory path the route resolved to, relative to the root of the file system.
+func (r Route) Dir() string {
+	return strings.Join(r.Dirs, "/")
+}
+
+// matchedPath is the route's path with the segments matched by name in the case of their directories.
+func (r Route) matchedPath() string {
+	names := splitPath(r.Path)
+	segments := names[r.localeOffset():]
+	for i, dir := range r.Dirs[:min(len(r.Dirs), len(segments))] {
+		if !isRegexPathPart(dir) && strings.EqualFold(segments[i], dir) {
+			segments[i] = dir
+		}
+	}
+	return "/" + strings.Join(names, "/")
+}
+
+// localeOffset is the number of segments of the route's path before those of its directories, its locale's.
+func (r Route) localeOffset() int {
+	if r.Locale != "" {
+		return 1
+	}
+	return 0
+}
+
+// RouteSegment is a segment of a route's path, and the directory it resolved to.
+type RouteSegment struct {
+	// Name is the segment of the url path, e.g. 42, or the remainder of the path for a catch-all directory.
+	Name string
+	// Dir is the name of the directory matched, e.g. {(?P<id>[0-9]+)}.
+	Dir string
+	// Path is the url path up to and including the segment, e.g. /blog/42, without the base path.
+	Path string
+	// Submatches are the submatches of Dir's path expression, if it is a regex directory.
+	Submatches []KeyValuePair
+}
+
+// Segments are the segments of the route's path in order, each with the directory it resolved to,
+// e.g. blog and 42 for /blog/42, for building breadcrumbs and navigation from the resolved route.
+// Templates access them as .Route.Segments, when the data implements RouteData, or with the route template func.
+func (r Route) Segments() []RouteSegment {
+	offset := r.localeOffset()
+	names := splitPath(r.Path)
+	segments := make([]RouteSegment, 0, len(r.Dirs))
+	for i, dir := range r.Dirs[:min(len(r.Dirs), len(names)-offset)] {
+		end := i + 1
+		if isCatchAllPathPart(dir) {
+			end = len(names) - offset
+		}
+		s := RouteSegment{
+			Name: strings.Join(names[offset+i:offset+end], "/"),
+			Dir:  dir,
+			Path: "/" + strings.Join(names[:offset+end], "/"),
+		}
+		if i < len(r.Submatches) {
+			s.Submatches = r.Submatches[i].Submatches
+		}
+		segments = append(segments, s)
+	}
+	return segments
+}
+
+// RouteData is implemented by request data that accepts the resolved route, such as RequestDataMap.
+type RouteData interface {
+	SetRoute(route Route)
+}
+
+func (d RequestDataMap) SetRoute(route Route) {
+	d["Route"] = route
+}
+
+type routeContextKey struct{}
+
+// RouteFromContext returns the route resolved for the request, if any.
+// It is available to middleware added with Handler.Use.
+func RouteFromContext(ctx context.Context) (*Route, bool) {
+	r, ok := ctx.Value(routeContextKey{}).(*Route)
+	return r, ok
+}
+
+func contextWithRoute(ctx context.Context, r *Route) context.Context {
+	return context.WithValue(ctx, routeContextKey{}, r)
+}
+
+// splitPath splits a url path into its segments, ignoring repeated slashes.
+func splitPath(urlPath string) []string {
+	var segments []string
+	for _, s := range strings.Split(urlPath, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// resolveRoute matches each segment of the path to a directory, either by exact name or
+// by a regex directory name, or the remainder of the path to a catch-all directory,
+// and returns an error wrapping fs.ErrNotExist if none matches.
+func (h requestHandler) resolveRoute(urlPath string) (*Route, error) {
+	route := Route{
+		Path: urlPath,
+	}
+
+	locale, segments, err := h.delocalize(splitPath(urlPath))
+	if err != nil {
+		return nil, err
+	}
+	route.Locale = locale
+	for pathIndex, dir := range segments {
+		l := h.log.With("pathIndex", pathIndex)
+		currentDir := strings.Join(route.Dirs, "/")
+
+		// immediate fail urls with regex path parts so as to not expose regex paths directly
+		if isRegexPathPart(dir) {
+			l.Debug("path includes regex: " + dir)
+			return nil, fmt.Errorf("%w: path includes regex: %s", fs.ErrNotExist, dir)
+		}
+
+		if pathIndex == 0 && isReservedDir(dir) {
+			l.Debug("path is reserved: " + dir)
+			return nil, fmt.Errorf("%w: reserved directory: %s", fs.ErrNotExist, dir)
+		}
+
+		if h.hiddenFiles.hides(dir) {
+			l.Debug("path is hidden: " + dir)
+			return nil, fmt.Errorf("%w: hidden directory: %s", fs.ErrNotExist, dir)
+		}
+
+		// find a directory by exact name or one that is a regex matching
+		var dirExpSubmatches DirEntryWithSubmatches
+
+		if h.ignoreCase {
+			if name, err := h.findDirIgnoringCase(currentDir, dir, pathIndex == 0); err != nil {
+				return nil, err
+			} else if name != dir {
+				l.Debug("directory matched regardless of case: " + name)
+				dir = name
+			}
+		}
+
+		if info, err := fs.Stat(h.fs, joinPath(currentDir, dir)); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to check directory %s: %w", dir, err)
+			}
+
+			l.Debug("looking up matching regex directories")
+			matchingDirs, err := h.findMatchingRegexDirs(currentDir, dir)
+			if err != nil {
+				return nil, err
+			}
+			if len(matchingDirs) == 0 {
+				catchAll, err := h.findCatchAllDir(currentDir, segments[pathIndex:])
+				if err != nil {
+					return nil, err
+				}
+				if catchAll == nil {
+					return nil, fmt.Errorf("directory not found: %s: %w", dir, fs.ErrNotExist)
+				}
+
+				l.Debug("catch-all directory found: " + catchAll.File.Name())
+				route.Dirs = append(route.Dirs, catchAll.File.Name())
+				route.Submatches = append(route.Submatches, *catchAll)
+				break
+			}
+
+			if dirExpSubmatches, err = h.preferredRegexDir(currentDir, matchingDirs); err != nil {
+				return nil, err
+			}
+
+			dir = dirExpSubmatches.File.Name()
+			l.Debug("matching regex directory found: " + dir)
+		} else if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory: %w", dir, fs.ErrNotExist)
+		} else {
+			dirExpSubmatches = DirEntryWithSubmatches{
+				File: info,
+			}
+		}
+
+		route.Dirs = append(route.Dirs, dir)
+		route.Submatches = append(route.Submatches, dirExpSubmatches)
+	}
+
+	// at the last directory in the path.
+	// handle special cases:
+	// - 404 file means return a 404 Not Found response
+
+	if len(route.Dirs) > 0 {
+		h.log.Debug("checking for 404 file")
+		exists, err := h.doesStatusFileExist(route.Dirs, http.StatusNotFound)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("404 file found: %w", fs.ErrNotExist)
+		}
+	}
+
+	return &route, nil
+}
+
+// findDirIgnoringCase returns the name of the directory of parentDir named name regardless of case,
+// name itself if it exists or none does. Regex, hidden and, at the root, reserved directories are not matched.
+func (h requestHandler) findDirIgnoringCase(parentDir, name string, root bool) (string, error) {
+	if _, err := fs.Stat(h.fs, joinPath(parentDir, name)); err == nil {
+		return name, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("failed to check directory %s: %w", name, err)
+	}
+
+	entries, err := h.listDirEntries(parentDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up directory entries: %w", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() || !strings.EqualFold(e.Name(), name) {
+			continue
+		}
+		if isRegexPathPart(e.Name()) || h.hiddenFiles.hides(e.Name()) || root && isReservedDir(e.Name()) {
+			continue
+		}
+		return e.Name(), nil
+	}
+
+	return name, nil
+}
+
+// findCatchAllDir returns the catch-all directory of parentDir matching the remaining segments of a path,
+// with the remainder as its submatch, or nil if there is none. Of several, the first by name is used.
+func (h requestHandler) findCatchAllDir(parentDir string, rest []string) (*DirEntryWithSubmatches, error) {
+	for _, segment := range rest {
+		if isRegexPathPart(segment) || h.hiddenFiles.hides(segment) {
+			return nil, nil
+		}
+	}
+
+	entries, err := h.listDirEntries(parentDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up directory entries: %w", err)
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() || !isCatchAllPathPart(e.Name()) {
+			continue
+		}
+
+		re, err := compileRegexPathPart(e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("invalid catch-all directory name: %w", err)
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up file info on file %s: %w", e.Name(), err)
+		}
+
+		return &DirEntryWithSubmatches{
+			File: info,
+			Submatches: []KeyValuePair{{
+				Key:   re.SubexpNames()[1],
+				Value: strings.Join(rest, "/"),
+			}},
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// joinPath joins a directory relative to the root of the file system with a name.
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// currentRoute is the route being rendered, for the route template func.
+func (h requestHandler) currentRoute() Route {
+	if h.route == nil {
+		return Route{}
+	}
+	return *h.route
+}
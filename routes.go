@@ -0,0 +1,245 @@
+package htmplx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+)
+
+// RouteParam is the parameter of a route matched by a regex directory of its pattern.
+type RouteParam struct {
+	// Name is the name of the directory's only named submatch, e.g. id for {(?P<id>[0-9]+)},
+	// the name of a catch-all directory, or paramN for the route's Nth regex directory otherwise.
+	Name string `json:"name"`
+	// Pattern is the expression matching the path segment, e.g. [0-9]+, or .+ for a catch-all directory.
+	Pattern string `json:"pattern"`
+	// Type is integer if the expression only matches digits, and string otherwise.
+	Type string `json:"type"`
+	// CatchAll is set when the parameter matches the remainder of the path, slashes included.
+	CatchAll bool `json:"catchAll,omitempty"`
+}
+
+// RoutesFormat is the format routes are written in by WriteRoutes.
+type RoutesFormat string
+
+const (
+	// RoutesJSON is a JSON array of RouteInfo.
+	RoutesJSON RoutesFormat = "json"
+	// RoutesOpenAPI is an OpenAPI 3 document.
+	RoutesOpenAPI RoutesFormat = "openapi"
+)
+
+// WriteRoutes writes the routes of the tree in format, for gateways, uptime checks and documentation
+// to consume. An OpenAPI document is titled title.
+func (h *Handler[D]) WriteRoutes(w io.Writer, format RoutesFormat, title string) error {
+	routes, err := h.Routes()
+	if err != nil {
+		return err
+	}
+
+	var doc any
+	switch format {
+	case RoutesJSON:
+		doc = routes
+	case RoutesOpenAPI:
+		doc = openAPIDocument(routes, title)
+	default:
+		return fmt.Errorf("unknown routes format %q", format)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write routes: %w", err)
+	}
+	return nil
+}
+
+// routeInfo describes the route of the directory at pattern.
+func (h *Handler[D]) routeInfo(rh requestHandler, pattern string) (RouteInfo, error) {
+	route := RouteInfo{
+		Pattern: pattern,
+		Methods: h.allowedMethods(pattern),
+	}
+
+	for _, dir := range splitPath(pattern) {
+		if !isRegexPathPart(dir) {
+			continue
+		}
+		param, err := routeParam(dir, len(route.Params)+1)
+		if err != nil {
+			return route, err
+		}
+		route.Params = append(route.Params, param)
+	}
+
+	dir := strings.TrimPrefix(pattern, "/")
+	hasHTML, err := rh.hasBodyFile(dir)
+	if err != nil {
+		return route, err
+	}
+	for _, b := range textBodies {
+		name := joinPath(dir, b.representation.bodyFilename())
+		if _, err := fs.Stat(rh.fs, name); err == nil {
+			route.ContentTypes = append(route.ContentTypes, b.mediaType)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return route, fmt.Errorf("failed to look up %s: %w", name, err)
+		}
+	}
+	// a directory without a body of its own may define one in another template, or inherit one.
+	if hasHTML || len(route.ContentTypes) == 0 {
+		route.ContentTypes = append([]string{"text/html"}, route.ContentTypes...)
+	}
+
+	return route, nil
+}
+
+// routeParam describes the parameter of the regex directory name, the nth of its route.
+func routeParam(name string, n int) (RouteParam, error) {
+	param := RouteParam{Name: "param" + strconv.Itoa(n), Type: "string"}
+
+	re, err := compileRegexPathPart(name)
+	if err != nil {
+		return param, fmt.Errorf("invalid regex directory name %s: %w", name, err)
+	}
+
+	if isCatchAllPathPart(name) {
+		param.Name = re.SubexpNames()[1]
+		param.Pattern = ".+"
+		param.CatchAll = true
+		return param, nil
+	}
+
+	param.Pattern = trimRegexPathPart(name)
+
+	var named []string
+	for _, sub := range re.SubexpNames()[1:] {
+		if sub != "" {
+			named = append(named, sub)
+		}
+	}
+	if len(named) == 1 {
+		param.Name = named[0]
+	}
+
+	if exp, err := syntax.Parse(param.Pattern, syntax.Perl); err == nil && matchesOnlyDigits(exp.Simplify()) {
+		param.Type = "integer"
+	}
+
+	return param, nil
+}
+
+// matchesOnlyDigits reports whether every string the expression matches is made of ascii digits.
+func matchesOnlyDigits(exp *syntax.Regexp) bool {
+	switch exp.Op {
+	case syntax.OpCapture, syntax.OpPlus, syntax.OpRepeat:
+		return matchesOnlyDigits(exp.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range exp.Sub {
+			if !matchesOnlyDigits(sub) {
+				return false
+			}
+		}
+		return len(exp.Sub) > 0
+	case syntax.OpCharClass:
+		for i := 0; i < len(exp.Rune); i += 2 {
+			if exp.Rune[i] < '0' || exp.Rune[i+1] > '9' {
+				return false
+			}
+		}
+		return len(exp.Rune) > 0
+	case syntax.OpLiteral:
+		for _, r := range exp.Rune {
+			if r < '0' || r > '9' {
+				return false
+			}
+		}
+		return len(exp.Rune) > 0
+	default:
+		return false
+	}
+}
+
+// openAPIDocument describes the routes as an OpenAPI 3 document. Regex directories are path parameters,
+// constrained by their expression, and the methods other than GET are documented without a body.
+func openAPIDocument(routes []RouteInfo, title string) map[string]any {
+	paths := make(map[string]any, len(routes))
+
+	for _, route := range routes {
+		var parameters []any
+		params := route.Params
+		segments := splitPath(route.Pattern)
+		for i, dir := range segments {
+			if !isRegexPathPart(dir) {
+				continue
+			}
+			param := params[0]
+			params = params[1:]
+			segments[i] = "{" + param.Name + "}"
+
+			schema := map[string]any{"type": param.Type}
+			if param.Type == "string" {
+				schema["pattern"] = "^" + param.Pattern + "$"
+			}
+			p := map[string]any{
+				"name":     param.Name,
+				"in":       "path",
+				"required": true,
+				"schema":   schema,
+			}
+			if param.CatchAll {
+				p["description"] = "The remainder of the path, slashes included."
+			}
+			parameters = append(parameters, p)
+		}
+
+		operations := make(map[string]any, len(route.Methods))
+		for _, method := range route.Methods {
+			op := map[string]any{}
+			if len(parameters) > 0 {
+				op["parameters"] = parameters
+			}
+			switch method {
+			case http.MethodGet:
+				content := make(map[string]any, len(route.ContentTypes))
+				for _, contentType := range route.ContentTypes {
+					content[contentType] = map[string]any{}
+				}
+				op["responses"] = map[string]any{
+					"200": map[string]any{"description": "OK", "content": content},
+				}
+			case http.MethodHead:
+				op["responses"] = map[string]any{
+					"200": map[string]any{"description": "The headers of the GET response."},
+				}
+			case http.MethodOptions:
+				op["responses"] = map[string]any{
+					"204": map[string]any{"description": "The methods of the route, in the Allow header."},
+				}
+			default:
+				op["responses"] = map[string]any{
+					"default": map[string]any{"description": "The response of the method's handler."},
+				}
+			}
+			operations[strings.ToLower(method)] = op
+		}
+
+		paths["/"+strings.Join(segments, "/")] = operations
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   title,
+			"version": "1.0.0",
+		},
+		"paths": paths,
+	}
+}
@@ -0,0 +1,144 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Sandbox limits what templates may do, for trees of templates that are not trusted, such as those uploaded by
+// the tenants of WithHostFS. Limits that are zero are not enforced.
+type Sandbox struct {
+	// Funcs are the names of the template funcs templates may call, of the built-in funcs, DefaultFuncs and
+	// WithFuncs. Calling any other fails the render. Those of text/template itself, such as printf, are always
+	// available, as are those the page layout calls.
+	Funcs []string
+	// MaxTemplateSize is the size in bytes of the largest template, or other file, read to render a page.
+	MaxTemplateSize int64
+	// Timeout is how long a template may take to render. Renders taking longer are abandoned, and respond 500.
+	Timeout time.Duration
+	// MaxOutputSize is the size in bytes of the largest page a template may render.
+	MaxOutputSize int64
+}
+
+// WithSandbox limits every template rendered by sandbox, so that untrusted templates cannot take down the process.
+// Templates that exceed a limit fail to render, responding 500.
+func (h *Handler[D]) WithSandbox(sandbox Sandbox) *Handler[D] {
+	h.sandbox = &sandbox
+	return h
+}
+
+// layoutFuncs are the funcs the page layout calls, which are available in a sandbox.
+var layoutFuncs = []string{"alternateLinks", "canonicalLink", "iconSprite", "liveReload"}
+
+var (
+	errSandboxTimeout    = errors.New("sandbox: template took too long to render")
+	errSandboxOutputSize = errors.New("sandbox: template output too large")
+)
+
+// restrictFuncs returns funcs replacing those of funcMaps that are not allowed with funcs that fail,
+// so that templates calling them still parse, but do not render.
+func (s *Sandbox) restrictFuncs(funcMaps ...template.FuncMap) template.FuncMap {
+	restricted := template.FuncMap{}
+	for _, funcs := range funcMaps {
+		for name := range funcs {
+			if slices.Contains(s.Funcs, name) || slices.Contains(layoutFuncs, name) {
+				continue
+			}
+			restricted[name] = func(...any) (string, error) {
+				return "", fmt.Errorf("sandbox: func %s is not allowed", name)
+			}
+		}
+	}
+	return restricted
+}
+
+// readLimit returns r, failing once more than MaxTemplateSize bytes are read, if limited.
+func (s *Sandbox) readLimit(r io.Reader, name string) io.Reader {
+	if s == nil || s.MaxTemplateSize <= 0 {
+		return r
+	}
+	return &sandboxReader{r: r, name: name, max: s.MaxTemplateSize}
+}
+
+type sandboxReader struct {
+	r    io.Reader
+	name string
+	max  int64
+	read int64
+}
+
+func (r *sandboxReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if r.read > r.max {
+		return n, fmt.Errorf("sandbox: %s is larger than %d bytes", r.name, r.max)
+	}
+	return n, err
+}
+
+// execute calls execute with w, limited to MaxOutputSize bytes, and abandons it once it takes longer than Timeout,
+// if limited. Templates cannot be interrupted, so an abandoned render runs on in the background until it ends,
+// or writes, but nothing more reaches w.
+func (s *Sandbox) execute(w io.Writer, execute func(io.Writer) error) error {
+	if s == nil || s.Timeout <= 0 && s.MaxOutputSize <= 0 {
+		return execute(w)
+	}
+
+	sw := &sandboxWriter{w: w, max: s.MaxOutputSize}
+	if s.Timeout <= 0 {
+		return execute(sw)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- execute(sw)
+	}()
+
+	timer := time.NewTimer(s.Timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		sw.abandon()
+		return errSandboxTimeout
+	}
+}
+
+type sandboxWriter struct {
+	w io.Writer
+	// max is the number of bytes that may be written, if positive.
+	max int64
+
+	mu        sync.Mutex
+	written   int64
+	abandoned bool
+}
+
+func (w *sandboxWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.abandoned {
+		return 0, errSandboxTimeout
+	}
+	if w.max > 0 && w.written+int64(len(p)) > w.max {
+		return 0, errSandboxOutputSize
+	}
+	w.written += int64(len(p))
+	return w.w.Write(p)
+}
+
+// abandon fails every later write, once the render is abandoned, so that it stops if it writes again,
+// and never writes to w once w is reused.
+func (w *sandboxWriter) abandon() {
+	w.mu.Lock()
+	w.abandoned = true
+	w.mu.Unlock()
+}
@@ -0,0 +1,51 @@
+package htmplx
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+func TestSandboxTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"loop writing nothing", `{{range 2000000000}}{{end}}`},
+		{"loop writing", `{{range 2000000000}}.{{end}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(htmplxtest.FS(map[string]string{
+				"body.html.tmpl": tt.body,
+			})).WithSandbox(Sandbox{Timeout: 100 * time.Millisecond})
+
+			start := time.Now()
+			w := get(h, "/")
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("got status %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("took %s, want the render abandoned after its timeout", elapsed)
+			}
+		})
+	}
+}
+
+func TestSandboxOutputSize(t *testing.T) {
+	h := newTestHandler(htmplxtest.FS(map[string]string{
+		"body.html.tmpl":       `{{range 10000}}.{{end}}`,
+		"small/body.html.tmpl": `small`,
+	})).WithSandbox(Sandbox{Timeout: time.Second, MaxOutputSize: 4096})
+
+	if w := get(h, "/"); w.Code != http.StatusInternalServerError {
+		t.Errorf("/: got status %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if w := get(h, "/small"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "small") {
+		t.Errorf("/small: got %d %q, want the page", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,138 @@
+package htmplx
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+	"strings"
+	"time"
+)
+
+// WithClock sets the clock used to evaluate scheduled content at render time.
+// Defaults to time.Now.
+func (h *Handler[D]) WithClock(now func() time.Time) *Handler[D] {
+	h.now = now
+	return h
+}
+
+// scheduleBlock defines a template in a directory for a window of time.
+// In a directory's _config.json:
+//
+//	{
+//		"schedule": [
+//			{"template": "banner", "from": "2026-12-01", "until": "2026-12-27", "file": "holiday-banner.html"},
+//			{"template": "notice", "from": "2026-06-01T09:00:00Z", "content": "<p>Maintenance at noon</p>"}
+//		]
+//	}
+//
+// While the window is open, the block's content replaces any template of the same name
+// in the directory. Omitting from or until leaves the window open ended.
+type scheduleBlock struct {
+	Template string       `json:"template"`
+	From     scheduleTime `json:"from"`
+	Until    scheduleTime `json:"until"`
+	// Content is the template's content, inline.
+	Content string `json:"content"`
+	// File is a file in the directory holding the template's content, used when Content is empty.
+	File string `json:"file"`
+}
+
+func (b scheduleBlock) active(now time.Time) bool {
+	if !b.From.IsZero() && now.Before(b.From.Time) {
+		return false
+	}
+	if !b.Until.IsZero() && !now.Before(b.Until.Time) {
+		return false
+	}
+	return true
+}
+
+// scheduleTime is a time.Time that may also be written as a date, e.g. 2026-12-01, meaning midnight UTC.
+type scheduleTime struct {
+	time.Time
+}
+
+func (t *scheduleTime) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if v, err := time.Parse(layout, s); err == nil {
+			t.Time = v
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid schedule time %q: expected RFC 3339 timestamp or YYYY-MM-DD date", s)
+}
+
+// isScheduledFile reports whether the file holds the content of a template scheduled by the config of its directory,
+// or of a directory above it, so is a template source, not a static file, whether or not its window is open.
+// Files under a directory whose config fails to be read are considered scheduled, so as not to be served early.
+func (h requestHandler) isScheduledFile(filename string) bool {
+	filename = strings.TrimPrefix(path.Clean("/"+filename), "/")
+
+	for dir := path.Dir(filename); ; dir = path.Dir(dir) {
+		if dir == "." {
+			dir = ""
+		}
+
+		cfg, err := h.readDirectoryConfig(dir)
+		if err != nil {
+			h.log.With("error", err).
+				Error("failed to look up the scheduled templates of " + filename)
+			return true
+		}
+		for _, b := range cfg.Schedule {
+			if b.File != "" && path.Join(dir, b.File) == filename {
+				return true
+			}
+		}
+
+		if dir == "" {
+			return false
+		}
+	}
+}
+
+// applySchedule parses the templates scheduled in dir's config that are in effect at render time.
+func (h requestHandler) applySchedule(layout *template.Template, dir string) (map[string]string, error) {
+	cfg, err := h.readDirectoryConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := make(map[string]string)
+
+	for _, b := range cfg.Schedule {
+		if b.Template == "" || !b.active(h.now) {
+			continue
+		}
+
+		h.log.Debug("applying scheduled template: " + b.Template)
+
+		content := b.Content
+		if content == "" && b.File != "" {
+			raw, err := h.readFile(path.Join(dir, b.File))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read scheduled template %s: %w", b.Template, err)
+			}
+			content = string(raw)
+		}
+
+		if _, err := layout.New(b.Template).Parse(content); err != nil {
+			return nil, fmt.Errorf("failed to parse scheduled template %s: %w", b.Template, err)
+		}
+
+		scheduled[b.Template] = content
+	}
+
+	return scheduled, nil
+}
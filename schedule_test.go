@@ -0,0 +1,48 @@
+package htmplx
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+func TestScheduledFilesAreNotServed(t *testing.T) {
+	now := time.Date(2026, 11, 30, 12, 0, 0, 0, time.UTC)
+	h := newTestHandler(htmplxtest.FS(map[string]string{
+		"body.html.tmpl":   `<main>{{block "banner" .}}no banner{{end}}</main>`,
+		"style.css":        `body { margin: 0; }`,
+		"holiday.html":     `<p>holiday sale</p>`,
+		"banners/new.html": `<p>new year sale</p>`,
+		"_config.json": `{"schedule": [
+			{"template": "banner", "from": "2026-12-01", "until": "2026-12-27", "file": "holiday.html"},
+			{"template": "banner", "from": "2026-12-27", "file": "banners/new.html"}
+		]}`,
+	})).WithClock(func() time.Time { return now })
+
+	for _, at := range []time.Time{now, now.AddDate(0, 0, 2), now.AddDate(0, 1, 0)} {
+		now = at
+
+		for _, path := range []string{"/holiday.html", "/banners/new.html"} {
+			if w := get(h, path); w.Code != http.StatusNotFound {
+				t.Errorf("%s at %s: got status %d, want %d", path, at, w.Code, http.StatusNotFound)
+			}
+		}
+		if w := get(h, "/style.css"); w.Code != http.StatusOK {
+			t.Errorf("/style.css at %s: got status %d, want %d", at, w.Code, http.StatusOK)
+		}
+	}
+
+	for at, want := range map[time.Time]string{
+		time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC): "no banner",
+		time.Date(2026, 12, 10, 0, 0, 0, 0, time.UTC): "holiday sale",
+		time.Date(2026, 12, 30, 0, 0, 0, 0, time.UTC): "new year sale",
+	} {
+		now = at
+		if w := get(h, "/"); !strings.Contains(w.Body.String(), want) {
+			t.Errorf("/ at %s: got %q, want it to contain %q", at, w.Body, want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package htmplx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultShutdownTimeout is how long Serve waits for requests to finish when shutting down.
+	defaultShutdownTimeout = 10 * time.Second
+	// acmeTLSProto is the ALPN protocol of the tls-alpn-01 challenge of ACME certificate authorities.
+	acmeTLSProto = "acme-tls/1"
+)
+
+// CertManager obtains certificates on demand from an ACME certificate authority, such as Let's Encrypt,
+// as *autocert.Manager of golang.org/x/crypto/acme/autocert does.
+type CertManager interface {
+	// GetCertificate returns the certificate of a TLS handshake, obtaining it if need be.
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	// HTTPHandler answers the http-01 challenges of the certificate authority, passing other requests to fallback,
+	// or redirecting them to https if it is nil.
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// ServeOption configures Serve.
+type ServeOption func(*serveConfig)
+
+type serveConfig struct {
+	server          *http.Server
+	shutdownTimeout time.Duration
+	certFile        string
+	keyFile         string
+	certs           CertManager
+	// httpAddr is the address challenges are answered on, and requests redirected to https from, with certs.
+	httpAddr string
+}
+
+// ServeTimeouts sets how long reading a request, and writing its response, may take. A zero duration is no limit.
+// By default, requests must be read within 30 seconds, their headers within 10, and responses are not limited,
+// since live reload and long polling responses are held open.
+func ServeTimeouts(read, write time.Duration) ServeOption {
+	return func(c *serveConfig) {
+		c.server.ReadTimeout = read
+		c.server.WriteTimeout = write
+	}
+}
+
+// ServeShutdownTimeout sets how long requests being served are given to finish when shutting down,
+// 10 seconds by default.
+func ServeShutdownTimeout(timeout time.Duration) ServeOption {
+	return func(c *serveConfig) {
+		c.shutdownTimeout = timeout
+	}
+}
+
+// ServeTLS serves https with the certificate and key of the given PEM files.
+func ServeTLS(certFile, keyFile string) ServeOption {
+	return func(c *serveConfig) {
+		c.certFile = certFile
+		c.keyFile = keyFile
+	}
+}
+
+// ServeAutocert serves https with certificates obtained by certs, such as an *autocert.Manager, and serves
+// its challenges on httpAddr, :80 if empty, redirecting every other request there to https.
+func ServeAutocert(certs CertManager, httpAddr string) ServeOption {
+	if httpAddr == "" {
+		httpAddr = ":80"
+	}
+	return func(c *serveConfig) {
+		c.certs = certs
+		c.httpAddr = httpAddr
+	}
+}
+
+// ServeServer configures the http.Server further, e.g. to set its ErrorLog or TLSConfig.
+func ServeServer(configure func(*http.Server)) ServeOption {
+	return func(c *serveConfig) {
+		configure(c.server)
+	}
+}
+
+// Serve serves handler on addr, any address Listen takes, until ctx is done or the process is interrupted
+// or terminated, then shuts down gracefully: it stops accepting connections, and gives the requests being served
+// up to the shutdown timeout to finish before closing their connections. It returns nil once shut down.
+//
+//	if err := htmplx.Serve(ctx, ":443", h, htmplx.ServeAutocert(&autocert.Manager{
+//		Prompt:     autocert.AcceptTOS,
+//		HostPolicy: autocert.HostWhitelist("example.com"),
+//		Cache:      autocert.DirCache("certs"),
+//	}, "")); err != nil {
+//		log.Fatal(err)
+//	}
+func Serve(ctx context.Context, addr string, handler http.Handler, opts ...ServeOption) error {
+	c := serveConfig{
+		server: &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		},
+		shutdownTimeout: defaultShutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	servers := []*http.Server{c.server}
+	if c.certs != nil {
+		tlsConfig := &tls.Config{}
+		if c.server.TLSConfig != nil {
+			tlsConfig = c.server.TLSConfig.Clone()
+		}
+		tlsConfig.GetCertificate = c.certs.GetCertificate
+		tlsConfig.NextProtos = append(tlsConfig.NextProtos, "h2", "http/1.1", acmeTLSProto)
+		c.server.TLSConfig = tlsConfig
+
+		servers = append(servers, &http.Server{
+			Addr:              c.httpAddr,
+			Handler:           c.certs.HTTPHandler(nil),
+			ReadHeaderTimeout: c.server.ReadHeaderTimeout,
+			ReadTimeout:       c.server.ReadTimeout,
+			IdleTimeout:       c.server.IdleTimeout,
+		})
+	}
+
+	l, err := Listen(addr)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, len(servers))
+	go func() {
+		if c.certs != nil || c.certFile != "" {
+			errs <- c.server.ServeTLS(l, c.certFile, c.keyFile)
+		} else {
+			errs <- c.server.Serve(l)
+		}
+	}()
+	for _, srv := range servers[1:] {
+		go func() {
+			errs <- srv.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err = <-errs:
+		err = fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.shutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		// connections still open once the timeout passes, such as live reload streams, are closed.
+		if shutdownErr := srv.Shutdown(shutdownCtx); errors.Is(shutdownErr, context.DeadlineExceeded) {
+			srv.Close()
+		}
+	}
+
+	return err
+}
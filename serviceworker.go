@@ -0,0 +1,142 @@
+package htmplx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"text/template"
+)
+
+// ServiceWorker configures a generated service worker, giving the site offline support.
+// The worker precaches the fingerprinted assets and the offline page on install,
+// serves precached assets from the cache, and falls back to the offline page when navigating offline.
+// Pages register it with:
+//
+//	<script>navigator.serviceWorker?.register("/sw.js")</script>
+type ServiceWorker struct {
+	// Path is the url path the worker is served at, /sw.js by default.
+	// The worker's scope is the whole site wherever it is served from.
+	Path string
+	// Offline is the route rendered when navigation fails for lack of a connection, e.g. /offline.
+	Offline string
+}
+
+// WithServiceWorker serves a service worker generated from the fingerprinted assets, see ServiceWorker.
+// Assets are precached once fingerprinted, by the asset template func or FingerprintAssets;
+// call FingerprintAssets before serving to precache them all. Nothing is precached in DevAssets mode.
+func (h *Handler[D]) WithServiceWorker(sw ServiceWorker) *Handler[D] {
+	if sw.Path == "" {
+		sw.Path = "/sw.js"
+	}
+	h.serviceWorker = &sw
+	return h
+}
+
+var serviceWorkerTemplate = template.Must(template.New("sw").Parse(`// generated by htmplx
+const CACHE = {{.Cache}};
+const PRECACHE = {{.Precache}};
+const OFFLINE = {{.Offline}};
+
+self.addEventListener("install", (event) => {
+	event.waitUntil(
+		caches.open(CACHE)
+			.then((cache) => cache.addAll(OFFLINE ? PRECACHE.concat(OFFLINE) : PRECACHE))
+			.then(() => self.skipWaiting())
+	);
+});
+
+self.addEventListener("activate", (event) => {
+	event.waitUntil(
+		caches.keys()
+			.then((keys) => Promise.all(keys
+				.filter((key) => key.startsWith("htmplx-") && key !== CACHE)
+				.map((key) => caches.delete(key))))
+			.then(() => self.clients.claim())
+	);
+});
+
+self.addEventListener("fetch", (event) => {
+	const request = event.request;
+	if (request.method !== "GET") {
+		return;
+	}
+
+	if (request.mode === "navigate") {
+		if (OFFLINE) {
+			event.respondWith(fetch(request).catch(() => caches.match(OFFLINE)));
+		}
+		return;
+	}
+
+	event.respondWith(caches.match(request).then((cached) => cached || fetch(request)));
+});
+`))
+
+// serveServiceWorker serves the generated service worker.
+// It is never cached by the browser, so that changed assets are picked up by the next navigation.
+func (h *Handler[D]) serveServiceWorker(w http.ResponseWriter, r *http.Request, l *slog.Logger) {
+	l.Debug("serving service worker")
+
+	precache := h.assets.urls()
+	if h.assetMode == DevAssets {
+		precache = nil
+	}
+	if precache == nil {
+		precache = []string{}
+	}
+
+	manifest, err := json.Marshal(precache)
+	if err != nil {
+		h.newRequestHandler(l).internalServerError(w, r, err)
+		return
+	}
+	offline, err := json.Marshal(h.serviceWorker.Offline)
+	if err != nil {
+		h.newRequestHandler(l).internalServerError(w, r, err)
+		return
+	}
+
+	// the cache is versioned by its content, so that a new worker replaces the old cache.
+	version := sha256.Sum256(append(manifest, offline...))
+	cache, err := json.Marshal("htmplx-" + hex.EncodeToString(version[:])[:assetHashLen])
+	if err != nil {
+		h.newRequestHandler(l).internalServerError(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := serviceWorkerTemplate.Execute(&buf, map[string]string{
+		"Cache":    string(cache),
+		"Precache": string(manifest),
+		"Offline":  string(offline),
+	}); err != nil {
+		h.newRequestHandler(l).internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Service-Worker-Allowed", "/")
+	w.Write(buf.Bytes())
+}
+
+// urls returns the fingerprinted urls of the hashed assets, sorted, excluding source maps.
+func (a *assetFingerprints) urls() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	urls := make([]string, 0, len(a.hashes))
+	for name, fp := range a.hashes {
+		if isSourceMap(name) {
+			continue
+		}
+		urls = append(urls, "/"+fingerprintedName(name, fp.hash))
+	}
+	slices.Sort(urls)
+
+	return urls
+}
@@ -0,0 +1,284 @@
+package htmplx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Sitemap configures the generated sitemap.xml, listing every page for search engines.
+// Pages are the directories with templates of their own, outside regex directories.
+// A directory's _config.json may set its priority and change frequency, or exclude it, along with those beneath it:
+//
+//	{
+//		"sitemap": {"priority": 0.8, "changefreq": "weekly"}
+//	}
+type Sitemap struct {
+	// Path is the url path of the sitemap, /sitemap.xml by default.
+	Path string
+	// BaseURL is the scheme and host of the urls listed, e.g. https://example.com.
+	// By default, that of the request for the sitemap.
+	BaseURL string
+	// Expand lists the url paths of a regex directory's pages, given its pattern, e.g. /blog/{[0-9]+}.
+	// Regex directories are not listed without it.
+	Expand func(r *http.Request, pattern string) ([]string, error)
+}
+
+// WithSitemap serves a sitemap.xml generated from the directory tree.
+func (h *Handler[D]) WithSitemap(sitemap Sitemap) *Handler[D] {
+	if sitemap.Path == "" {
+		sitemap.Path = "/sitemap.xml"
+	}
+	h.sitemap = &sitemap
+	return h
+}
+
+// sitemapConfig is the "sitemap" of a directory's _config.json.
+type sitemapConfig struct {
+	Exclude    *bool    `json:"exclude"`
+	Priority   *float64 `json:"priority"`
+	ChangeFreq string   `json:"changefreq"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	// XHTML declares the namespace of the alternate links of pages, when there are locales.
+	XHTML string       `xml:"xmlns:xhtml,attr,omitempty"`
+	URLs  []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string   `xml:"loc"`
+	LastMod    string   `xml:"lastmod,omitempty"`
+	ChangeFreq string   `xml:"changefreq,omitempty"`
+	Priority   *float64 `xml:"priority,omitempty"`
+	// Links are the page's alternates in each locale, itself included.
+	Links []sitemapLink `xml:"xhtml:link"`
+}
+
+type sitemapLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// xhtmlNamespace is the namespace of the alternate links of a sitemap's pages.
+const xhtmlNamespace = "http://www.w3.org/1999/xhtml"
+
+// serveSitemap serves the sitemap, listing the pages of the directory tree.
+func (h *Handler[D]) serveSitemap(w http.ResponseWriter, r *http.Request, l *slog.Logger) {
+	l.Debug("serving sitemap")
+
+	rh := h.newRequestHandler(l)
+	rh.fs = h.requestFS(r)
+
+	urls, err := h.sitemapURLs(r, rh)
+	if err != nil {
+		rh.internalServerError(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "\t")
+	urlSet := sitemapURLSet{URLs: urls}
+	if slices.ContainsFunc(urls, func(u sitemapURL) bool { return len(u.Links) > 0 }) {
+		urlSet.XHTML = xhtmlNamespace
+	}
+	if err := enc.Encode(urlSet); err != nil {
+		rh.internalServerError(w, r, fmt.Errorf("failed to encode sitemap: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func (h *Handler[D]) sitemapURLs(r *http.Request, rh requestHandler) ([]sitemapURL, error) {
+	baseURL := strings.TrimSuffix(h.sitemap.BaseURL, "/")
+	if baseURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + r.Host
+	}
+	baseURL += h.basePath
+
+	var urls []sitemapURL
+
+	err := fs.WalkDir(rh.fs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if name != "." && (name == d.Name() && isReservedDir(name) || rh.hiddenFiles.hides(d.Name())) {
+			return fs.SkipDir
+		}
+
+		var dirs []string
+		if name != "." {
+			dirs = strings.Split(name, "/")
+		}
+
+		dynamic := slices.ContainsFunc(dirs, isRegexPathPart)
+		if dynamic && h.sitemap.Expand == nil {
+			return fs.SkipDir
+		}
+
+		cfg, err := rh.directorySitemap(dirs)
+		if err != nil {
+			return err
+		}
+		if cfg.Exclude != nil && *cfg.Exclude {
+			return fs.SkipDir
+		}
+
+		lastMod, isPage, err := rh.pageModTime(name)
+		if err != nil || !isPage {
+			return err
+		}
+		for _, status := range []int{http.StatusNotFound, http.StatusGone} {
+			if exists, err := rh.doesStatusFileExist(dirs, status); err != nil || exists {
+				return err
+			}
+		}
+
+		entry := sitemapURL{
+			ChangeFreq: cfg.ChangeFreq,
+			Priority:   cfg.Priority,
+		}
+
+		if !dynamic {
+			if !lastMod.IsZero() {
+				entry.LastMod = lastMod.UTC().Format(time.RFC3339)
+			}
+			localized, err := rh.localizeSitemapURL(entry, baseURL, "/"+strings.Join(dirs, "/"))
+			urls = append(urls, localized...)
+			return err
+		}
+
+		paths, err := h.sitemap.Expand(r, "/"+strings.Join(dirs, "/"))
+		if err != nil {
+			return fmt.Errorf("failed to expand %s: %w", name, err)
+		}
+		for _, p := range paths {
+			localized, err := rh.localizeSitemapURL(entry, baseURL, p)
+			if err != nil {
+				return err
+			}
+			urls = append(urls, localized...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pages: %w", err)
+	}
+
+	return urls, nil
+}
+
+// localizeSitemapURL returns the entries of the page at urlPath, a path of directory names, one per locale with
+// links to the others, or the page's own if the tree declares no locales, their paths canonical per the slash policy.
+func (h requestHandler) localizeSitemapURL(entry sitemapURL, baseURL, urlPath string) ([]sitemapURL, error) {
+	cfg, err := h.locales()
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		entry.Loc = baseURL + h.slashes.canonical(urlPath)
+		return []sitemapURL{entry}, nil
+	}
+
+	locales := sortedKeys(cfg.Translations, strings.Compare)
+	var links []sitemapLink
+	for _, locale := range locales {
+		localized, err := h.localizedPath(locale, splitPath(urlPath))
+		if err != nil {
+			return nil, err
+		}
+		href := baseURL + localized
+		links = append(links, sitemapLink{Rel: "alternate", Hreflang: locale, Href: href})
+		if locale == cfg.Default {
+			links = append(links, sitemapLink{Rel: "alternate", Hreflang: xDefaultHreflang, Href: href})
+		}
+	}
+
+	var entries []sitemapURL
+	for _, link := range links {
+		if link.Hreflang == xDefaultHreflang {
+			continue
+		}
+		entry.Loc = link.Href
+		entry.Links = links
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// directorySitemap merges the sitemap configs of dirs, from the root down, deeper directories overriding their parents.
+func (h requestHandler) directorySitemap(dirs []string) (sitemapConfig, error) {
+	var merged sitemapConfig
+
+	for i := 0; i <= len(dirs); i++ {
+		cfg, err := h.readDirectoryConfig(strings.Join(dirs[:i], "/"))
+		if err != nil {
+			return merged, err
+		}
+		if cfg.Sitemap == nil {
+			continue
+		}
+
+		if cfg.Sitemap.Exclude != nil {
+			merged.Exclude = cfg.Sitemap.Exclude
+		}
+		if cfg.Sitemap.Priority != nil {
+			merged.Priority = cfg.Sitemap.Priority
+		}
+		if cfg.Sitemap.ChangeFreq != "" {
+			merged.ChangeFreq = cfg.Sitemap.ChangeFreq
+		}
+	}
+
+	return merged, nil
+}
+
+// pageModTime reports whether the directory has templates of its own, making it a page,
+// and the time they were last modified.
+func (h requestHandler) pageModTime(dir string) (lastMod time.Time, isPage bool, err error) {
+	entries, err := fs.ReadDir(h.fs, dir)
+	if err != nil {
+		return lastMod, false, err
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		_, isTemplate := h.templateExt(name)
+		if e.IsDir() || !(isTemplate || h.markdown != nil && path.Ext(name) == markdownExt) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			return lastMod, false, err
+		}
+
+		isPage = true
+		if info.ModTime().After(lastMod) {
+			lastMod = info.ModTime()
+		}
+	}
+
+	return lastMod, isPage, nil
+}
@@ -0,0 +1,73 @@
+package htmplx
+
+import (
+	"encoding/xml"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+// alternateLinkPattern matches the hreflang alternate links of a page's head.
+var alternateLinkPattern = regexp.MustCompile(`<link rel="alternate" href="([^"]+)" hreflang="([^"]+)">`)
+
+func TestSitemapAlternatesMatchPageHead(t *testing.T) {
+	const baseURL = "https://x.test"
+
+	for _, policy := range []SlashPolicy{SlashesAsIs, StripTrailingSlash, AddTrailingSlash} {
+		h := newTestHandler(htmplxtest.FS(map[string]string{
+			"_config.json": `{"locales": {"default": "en", "translations": {
+				"en": {},
+				"de": {"products": "produkte"}
+			}}}`,
+			"body.html.tmpl":          `<main>home</main>`,
+			"products/body.html.tmpl": `<main>products</main>`,
+		})).WithSitemap(Sitemap{BaseURL: baseURL}).WithSlashPolicy(policy, http.StatusMovedPermanently)
+
+		w := get(h, "/sitemap.xml")
+		if w.Code != http.StatusOK {
+			t.Fatalf("policy %d: got sitemap status %d", policy, w.Code)
+		}
+		var urlSet struct {
+			URLs []struct {
+				Loc   string `xml:"loc"`
+				Links []struct {
+					Hreflang string `xml:"hreflang,attr"`
+					Href     string `xml:"href,attr"`
+				} `xml:"http://www.w3.org/1999/xhtml link"`
+			} `xml:"url"`
+		}
+		if err := xml.Unmarshal(w.Body.Bytes(), &urlSet); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, u := range urlSet.URLs {
+			// each page listed is served at its url, not redirected.
+			loc := strings.TrimPrefix(u.Loc, baseURL)
+			page := get(h, loc)
+			if page.Code != http.StatusOK {
+				t.Errorf("policy %d: %s: got status %d, want %d", policy, u.Loc, page.Code, http.StatusOK)
+				continue
+			}
+
+			var head, listed []string
+			for _, m := range alternateLinkPattern.FindAllStringSubmatch(page.Body.String(), -1) {
+				head = append(head, m[2]+" "+m[1])
+			}
+			for _, link := range u.Links {
+				listed = append(listed, link.Hreflang+" "+strings.TrimPrefix(link.Href, baseURL))
+			}
+			slices.Sort(head)
+			slices.Sort(listed)
+			if !slices.Equal(head, listed) {
+				t.Errorf("policy %d: %s: got sitemap alternates %q, want those of the page head %q", policy, u.Loc, listed, head)
+			}
+		}
+		if len(urlSet.URLs) != 4 {
+			t.Errorf("policy %d: got %d urls, want 4", policy, len(urlSet.URLs))
+		}
+	}
+}
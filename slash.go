@@ -0,0 +1,69 @@
+package htmplx
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// SlashPolicy is whether page urls end with a slash.
+// Repeated slashes are collapsed regardless, so /blog//42 resolves as /blog/42.
+type SlashPolicy int
+
+const (
+	// SlashesAsIs resolves /blog and /blog/ alike, without redirecting either, the default.
+	SlashesAsIs SlashPolicy = iota
+	// StripTrailingSlash redirects /blog/ to /blog, and /blog//42 to /blog/42.
+	StripTrailingSlash
+	// AddTrailingSlash redirects /blog to /blog/, and /blog//42 to /blog/42/.
+	AddTrailingSlash
+)
+
+// WithSlashPolicy redirects page urls not in the form of the policy with status,
+// http.StatusMovedPermanently or http.StatusPermanentRedirect, which keeps the method and body.
+// Static files are only redirected to collapse repeated slashes.
+func (h *Handler[D]) WithSlashPolicy(policy SlashPolicy, status int) *Handler[D] {
+	if status == 0 {
+		status = http.StatusMovedPermanently
+	}
+	h.slashes = policy
+	h.slashRedirect = status
+	return h
+}
+
+// apply returns the url path in the form of the policy.
+func (p SlashPolicy) apply(urlPath string) string {
+	isFile := path.Ext(urlPath) != ""
+
+	cleaned := "/" + strings.Join(splitPath(urlPath), "/")
+
+	switch {
+	case cleaned == "/":
+		return cleaned
+	case p == AddTrailingSlash && !isFile:
+		return cleaned + "/"
+	case p == SlashesAsIs && strings.HasSuffix(urlPath, "/"):
+		return cleaned + "/"
+	default:
+		return cleaned
+	}
+}
+
+// redirectToSlashPolicy redirects the request to the form of its path required by the policy, if not already.
+func (h *Handler[D]) redirectToSlashPolicy(w http.ResponseWriter, r *http.Request) bool {
+	if h.slashes == SlashesAsIs {
+		return false
+	}
+
+	target := h.slashes.apply(r.URL.Path)
+	if target == r.URL.Path {
+		return false
+	}
+
+	u := *r.URL
+	u.Path = withBasePath(h.basePath, target)
+	u.RawPath = ""
+	http.Redirect(w, r, u.RequestURI(), h.slashRedirect)
+
+	return true
+}
@@ -0,0 +1,113 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+)
+
+// ErrNotFound is returned by a data callback when the entity a route names does not exist,
+// e.g. /blog/42 when there is no post 42, responding 404 with the 404 template rather than an empty page.
+var ErrNotFound = errors.New("not found")
+
+// StatusError is an error to be reported with a http status code.
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+func (e *StatusError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%d %s", e.Code, http.StatusText(e.Code))
+	}
+	return fmt.Sprintf("%d %s: %s", e.Code, http.StatusText(e.Code), e.Err)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// loadData calls the data callback of the request's tenant, or else the handler's, if any.
+// status is 0 unless the callback reports an error status.
+// A replayed capture's data is used in place of the callback's, and a captured request's data is recorded.
+func (h *Handler[D]) loadData(r *http.Request) (data D, status int, err error) {
+	defer func() {
+		// the panic is recovered while rendering, marked as the data callback's.
+		if v := recover(); v != nil {
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			panic(&panicError{value: v, stack: debug.Stack(), data: true})
+		}
+	}()
+
+	if err := h.injectFault(r, FaultData); err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			return data, statusErr.Code, statusErr.Err
+		}
+		return data, http.StatusInternalServerError, err
+	}
+
+	c, captured := captureFromContext(r.Context())
+	tenant, _ := h.requestTenant(r)
+
+	switch {
+	case captured && c.replay:
+		data, status, err = capturedData[D](c.capture)
+	case tenant != nil && tenant.Data != nil:
+		data, status, err = tenant.Data(r)
+	case h.dataErr != nil:
+		data, status, err = h.dataErr(r)
+	case h.data != nil:
+		data = h.data(r)
+	}
+
+	switch {
+	case errors.Is(err, ErrNotFound) && status < http.StatusBadRequest:
+		status = http.StatusNotFound
+	case err != nil && status < http.StatusBadRequest:
+		status = http.StatusInternalServerError
+	case err == nil && status < http.StatusBadRequest:
+		status = 0
+	}
+
+	if captured && !c.replay {
+		recordData(c.capture, data, status, err)
+	}
+
+	return data, status, err
+}
+
+// useStatusTemplate replaces the layout's body with the template named by the status code,
+// e.g. 404 defined by a 404.html.tmpl file, looked up along the route and then at the root.
+// It reports whether such a template exists.
+func (h requestHandler) useStatusTemplate(layout *template.Template, status int) (bool, error) {
+	name := strconv.Itoa(status)
+
+	if layout.Lookup(name) == nil {
+		found := false
+		for _, ext := range h.templateExts {
+			h.log.Debug("loading " + name + ext)
+			if _, err := h.loadTemplate(layout, name, name+ext); err == nil {
+				found = true
+				break
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return false, err
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	if _, err := layout.New("body").Parse(`{{template "` + name + `" .}}`); err != nil {
+		return false, fmt.Errorf("failed to use status template %s: %w", name, err)
+	}
+
+	return true, nil
+}
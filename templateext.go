@@ -0,0 +1,159 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+)
+
+// textTemplateExt is the extension of text templates, rendering the file named without it,
+// e.g. feed.xml.tmpl rendering /feed.xml.
+const textTemplateExt = ".tmpl"
+
+// defaultTemplateExtensions are the extensions of html templates, unless set with WithTemplateExtensions.
+var defaultTemplateExtensions = []string{".html.tmpl"}
+
+// WithTemplateExtensions sets the extensions of html templates, .html.tmpl by default,
+// e.g. WithTemplateExtensions(".html.tmpl", ".gohtml"). Templates with an earlier extension take precedence
+// over those of the same name with a later one. Files with these extensions are never served.
+func (h *Handler[D]) WithTemplateExtensions(exts ...string) *Handler[D] {
+	h.templateExts = exts
+	return h
+}
+
+func (h *Handler[D]) templateExtensions() []string {
+	if len(h.templateExts) == 0 {
+		return defaultTemplateExtensions
+	}
+	return h.templateExts
+}
+
+// templateExt returns the html template extension of filename, if it has one.
+func (h requestHandler) templateExt(filename string) (string, bool) {
+	for _, ext := range h.templateExts {
+		if strings.HasSuffix(filename, ext) {
+			return ext, true
+		}
+	}
+	return "", false
+}
+
+// templateRank orders template files by precedence, lower first, markdown files last.
+func (h requestHandler) templateRank(filename string) int {
+	if ext, ok := h.templateExt(filename); ok {
+		return slices.Index(h.templateExts, ext)
+	}
+	return len(h.templateExts)
+}
+
+// serveTextTemplate serves the file rendered from its text template, if there is no such static file
+// and there is a template, reporting whether a response was written.
+func (h *Handler[D]) serveTextTemplate(w http.ResponseWriter, r *http.Request, rh requestHandler, filename string) bool {
+	if _, err := fs.Stat(rh.fs, filename); !errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+
+	out, contentType, err := h.renderTextTemplate(r, rh, filename)
+	if err != nil {
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) {
+			rh.internalServerError(w, r, err)
+			return true
+		}
+		rh.log.With("error", err).
+			Debug("data callback reported error status")
+		w.WriteHeader(statusErr.Code)
+		return true
+	}
+	if out == nil {
+		return false
+	}
+	defer out.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	io.Copy(w, out)
+
+	return true
+}
+
+// renderTextTemplate renders the file from its text template, e.g. /feed.xml from feed.xml.tmpl,
+// for outputs other than html, with the Content-Type of its extension.
+// Text templates are not html escaped, and have the same funcs and data as pages.
+// out is nil if there is no such template.
+func (h *Handler[D]) renderTextTemplate(r *http.Request, rh requestHandler, filename string) (
+	out io.ReadCloser,
+	contentType string,
+	err error,
+) {
+	tmplFilename := filename + textTemplateExt
+	if _, ok := rh.templateExt(tmplFilename); ok || isTextBody(tmplFilename) {
+		// an html template, such as page.html.tmpl, or a route's text body, is not rendered on its own.
+		return nil, "", nil
+	}
+
+	contentType = mime.TypeByExtension(path.Ext(filename))
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	return h.executeTextTemplate(r, rh, tmplFilename, contentType)
+}
+
+// executeTextTemplate renders the text template with the request's data.
+// out is nil if there is no such template.
+// Text templates have no front matter, so that outputs starting with ---, such as YAML documents, are rendered whole.
+func (h *Handler[D]) executeTextTemplate(r *http.Request, rh requestHandler, tmplFilename, contentType string) (
+	out io.ReadCloser,
+	_ string,
+	err error,
+) {
+	b, err := rh.readFile(tmplFilename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	rh.log.Debug("rendering text template " + tmplFilename)
+
+	t := texttemplate.New(tmplFilename)
+	for _, funcs := range h.templateFuncs(r, rh) {
+		t = t.Funcs(texttemplate.FuncMap(funcs))
+	}
+	if t, err = t.Parse(string(b)); err != nil {
+		return nil, "", fmt.Errorf("failed to parse template %s: %w", tmplFilename, err)
+	}
+
+	data, status, err := h.loadData(r)
+	if status != 0 {
+		return nil, "", &StatusError{Code: status, Err: err}
+	}
+	if h.hasData(r) {
+		if rh.route != nil {
+			data.SetPathExpressionSubmatches(rh.route.Submatches)
+			if routeData, ok := any(data).(RouteData); ok {
+				routeData.SetRoute(*rh.route)
+			}
+		}
+		if pageData, ok := any(data).(PageData); ok {
+			pageData.SetPage(*rh.page)
+		}
+	}
+
+	buf := getRenderBuffer()
+	if err := h.sandbox.execute(buf, func(w io.Writer) error {
+		return t.Execute(w, data)
+	}); err != nil {
+		putRenderBuffer(buf)
+		return nil, "", fmt.Errorf("failed to execute template %s: %w", tmplFilename, err)
+	}
+
+	return &renderedPage{buf}, contentType, nil
+}
@@ -0,0 +1,26 @@
+package htmplx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/angelbeltran/htmplx/htmplxtest"
+)
+
+func TestTextTemplatesKeepLeadingDashes(t *testing.T) {
+	const config = "---\nname: site\n---\nkey: value\n"
+	h := newTestHandler(htmplxtest.FS(map[string]string{
+		"config.yaml.tmpl": config,
+		"body.html.tmpl":   "---\ntitle: Home\n---\n<main>home</main>",
+	}))
+
+	if w := get(h, "/config.yaml"); w.Body.String() != config {
+		t.Errorf("/config.yaml: got %q, want %q", w.Body, config)
+	}
+
+	// html templates still have front matter.
+	w := get(h, "/")
+	if body := w.Body.String(); strings.Contains(body, "title: Home") || !strings.Contains(body, "<title>Home</title>") {
+		t.Errorf("/: got %q, want the front matter stripped and used", body)
+	}
+}
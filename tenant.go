@@ -0,0 +1,81 @@
+package htmplx
+
+import (
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// Tenant is a site hosted by WithTenants: its tree, and the funcs and data of its templates.
+type Tenant[D RequestData] struct {
+	// FS is the tenant's tree.
+	FS fs.FS
+	// Funcs returns funcs for the tenant's templates, taking precedence over those of WithFuncs. Optional.
+	Funcs func(*http.Request) template.FuncMap
+	// Data loads the data of the tenant's templates, in place of the handler's data callback,
+	// as with WithDataErr. Optional, the handler's data callback is used if nil.
+	Data func(*http.Request) (D, int, error)
+}
+
+// WithTenants hosts many sites in one handler, such as the customer sites of a platform, keyed by host name
+// as with WithHostFS, which it replaces. Each tenant's templates are rendered from its own tree only,
+// with its own funcs and data, so no template of one tenant is ever visible to another.
+// Asset fingerprints, cached fragments, cache keys and cached responses are kept per host.
+//
+//	h.WithTenants(map[string]htmplx.Tenant[htmplx.RequestDataMap]{
+//		"acme.example.com": {FS: os.DirFS("tenants/acme"), Data: acmeData},
+//		"*.example.com":    {FS: os.DirFS("tenants/default")},
+//	})
+func (h *Handler[D]) WithTenants(tenants map[string]Tenant[D]) *Handler[D] {
+	hosts := make(map[string]fs.FS, len(tenants))
+	h.tenants = make(map[string]*Tenant[D], len(tenants))
+	for host, tenant := range tenants {
+		hosts[host] = tenant.FS
+		h.tenants[strings.ToLower(host)] = &tenant
+	}
+	return h.WithHostFS(hosts)
+}
+
+// requestTenant returns the tenant of the request's host, if any.
+func (h *Handler[D]) requestTenant(r *http.Request) (*Tenant[D], bool) {
+	if len(h.tenants) == 0 {
+		return nil, false
+	}
+	key, _, ok := h.hostTree(r)
+	if !ok {
+		return nil, false
+	}
+	tenant, ok := h.tenants[key]
+	return tenant, ok
+}
+
+// tenantFuncs returns the funcs of the request's tenant, if any.
+func (h *Handler[D]) tenantFuncs(r *http.Request) template.FuncMap {
+	if tenant, ok := h.requestTenant(r); ok && tenant.Funcs != nil {
+		return tenant.Funcs(r)
+	}
+	return nil
+}
+
+// hasData reports whether the request's data is loaded by a data callback, the tenant's or the handler's.
+func (h *Handler[D]) hasData(r *http.Request) bool {
+	if tenant, ok := h.requestTenant(r); ok && tenant.Data != nil {
+		return true
+	}
+	return h.data != nil || h.dataErr != nil
+}
+
+// requestAssets returns the asset fingerprints of the request's tree, kept per host
+// so that the fingerprint of one host's file is never served for another's.
+func (h *Handler[D]) requestAssets(r *http.Request) *assetFingerprints {
+	if _, ok := r.Context().Value(treeContextKey{}).(fs.FS); ok {
+		return &h.assets
+	}
+	if key, _, ok := h.hostTree(r); ok {
+		if assets, ok := h.hostAssets[key]; ok {
+			return assets
+		}
+	}
+	return &h.assets
+}
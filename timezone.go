@@ -0,0 +1,78 @@
+package htmplx
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WithTimezoneResolver sets the timezone times are rendered in for each request, such as the user's,
+// by the localTime and localDate template funcs. Times are rendered in their own location
+// if resolve returns nil.
+//
+//	h.WithTimezoneResolver(htmplx.TimezoneCookie("tz"))
+func (h *Handler[D]) WithTimezoneResolver(resolve func(*http.Request) *time.Location) *Handler[D] {
+	h.timezone = resolve
+	return h
+}
+
+// TimezoneCookie resolves the timezone from the IANA name in the named cookie, e.g. tz=Europe/Paris,
+// such as one set by a script from Intl.DateTimeFormat().resolvedOptions().timeZone.
+func TimezoneCookie(name string) func(*http.Request) *time.Location {
+	return func(r *http.Request) *time.Location {
+		c, err := r.Cookie(name)
+		if err != nil || c.Value == "" {
+			return nil
+		}
+		loc, err := time.LoadLocation(c.Value)
+		if err != nil {
+			return nil
+		}
+		return loc
+	}
+}
+
+// resolveTimezone returns the timezone of the request, nil if there is none.
+func (h *Handler[D]) resolveTimezone(r *http.Request) *time.Location {
+	if h.timezone == nil {
+		return nil
+	}
+	return h.timezone(r)
+}
+
+// localTime returns the time.Time t in the request's timezone, the zero time if t is nil.
+func (h requestHandler) localTime(t any) (time.Time, error) {
+	switch t := t.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		if h.location == nil || t.IsZero() {
+			return t, nil
+		}
+		return t.In(h.location), nil
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, nil
+		}
+		return h.localTime(*t)
+	default:
+		return time.Time{}, fmt.Errorf("localTime: %T is not a time.Time", t)
+	}
+}
+
+// localDate formats t with layout in the request's timezone, empty for the zero time.
+func (h requestHandler) localDate(layout string, t any) (string, error) {
+	lt, err := h.localTime(t)
+	if err != nil {
+		return "", fmt.Errorf("localDate: %T is not a time.Time", t)
+	}
+	return formatDate(layout, lt)
+}
+
+// timezoneName returns the name of the request's timezone, empty if there is none.
+func (h requestHandler) timezoneName() string {
+	if h.location == nil {
+		return ""
+	}
+	return h.location.String()
+}
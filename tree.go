@@ -0,0 +1,191 @@
+package htmplx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// treeFS is the handler's file system, switched atomically from one version of the tree to the next.
+// Each request is served a single version, the one current when it started.
+type treeFS struct {
+	current atomic.Pointer[treeVersion]
+
+	// mu guards next and previous.
+	mu   sync.Mutex
+	next *treeVersion
+	// previous is the version replaced by the last promotion, which may be rolled back to.
+	previous *treeVersion
+}
+
+type treeVersion struct {
+	fsys    fs.FS
+	version string
+}
+
+func newTreeFS(fsys fs.FS) *treeFS {
+	t := &treeFS{}
+	t.current.Store(&treeVersion{fsys: fsys})
+	return t
+}
+
+// load returns the current version of the tree.
+func (t *treeFS) load() fs.FS {
+	return t.current.Load().fsys
+}
+
+func (t *treeFS) Open(name string) (fs.File, error) {
+	return t.load().Open(name)
+}
+
+func (t *treeFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(t.load(), name)
+}
+
+func (t *treeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(t.load(), name)
+}
+
+func (t *treeFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(t.load(), name)
+}
+
+type treeContextKey struct{}
+
+// requestFS returns the tree the request is served: the staged version for the requests warming it up,
+// the tree of the request's host, or else the current version.
+func (h *Handler[D]) requestFS(r *http.Request) fs.FS {
+	if fsys, ok := r.Context().Value(treeContextKey{}).(fs.FS); ok {
+		return fsys
+	}
+	if _, fsys, ok := h.hostTree(r); ok {
+		return fsys
+	}
+	return h.tree.load()
+}
+
+// StageTree prepares next as the next version of the tree, such as a new checkout of the templates,
+// without serving it: it is validated, if WithValidation is enabled, and the routes set by WithWarmup are rendered
+// with it. Requests are served the current version until PromoteTree switches to the staged one.
+// A staged version replaces any staged before it.
+func (h *Handler[D]) StageTree(ctx context.Context, version string, next fs.FS) error {
+	l := h.log.With("version", version)
+	l.Info("staging tree")
+
+	if h.validate {
+		if err := h.validateTree(next); err != nil {
+			return fmt.Errorf("failed to stage version %s: %w", version, err)
+		}
+	}
+	if err := h.warm(context.WithValue(ctx, treeContextKey{}, next), next); err != nil {
+		return fmt.Errorf("failed to stage version %s: %w", version, err)
+	}
+
+	h.tree.mu.Lock()
+	h.tree.next = &treeVersion{fsys: next, version: version}
+	h.tree.mu.Unlock()
+
+	l.Info("tree staged")
+
+	return nil
+}
+
+// PromoteTree atomically switches to the version of the tree staged by StageTree.
+// Requests in flight finish with the version they started with. Asset fingerprints and cached fragments
+// of the previous version are dropped, and the previous version is kept for RollbackTree.
+func (h *Handler[D]) PromoteTree() error {
+	h.tree.mu.Lock()
+	defer h.tree.mu.Unlock()
+
+	next := h.tree.next
+	if next == nil {
+		return errors.New("no tree staged")
+	}
+	h.tree.next = nil
+	h.tree.previous = h.tree.current.Swap(next)
+
+	h.treeChanged()
+
+	h.log.With("version", next.version).
+		Info("tree promoted")
+
+	return nil
+}
+
+// SwapFS atomically replaces the tree served with fsys, such as a new content bundle, in one step rather than
+// staging and promoting it. It is validated first, if WithValidation is enabled, and the tree is not replaced
+// if it is invalid. Requests in flight finish with the tree they started with. Asset fingerprints and cached
+// fragments of the previous tree are dropped, and the previous tree is kept for RollbackTree.
+func (h *Handler[D]) SwapFS(fsys fs.FS) error {
+	if h.validate {
+		if err := h.validateTree(fsys); err != nil {
+			return fmt.Errorf("failed to swap file system: %w", err)
+		}
+	}
+
+	h.tree.mu.Lock()
+	defer h.tree.mu.Unlock()
+
+	h.tree.previous = h.tree.current.Swap(&treeVersion{fsys: fsys})
+
+	h.treeChanged()
+
+	h.log.Info("file system swapped")
+
+	return nil
+}
+
+// treeChanged drops what was derived from the previous version of the tree.
+func (h *Handler[D]) treeChanged() {
+	h.assets.mu.Lock()
+	h.assets.hashes = nil
+	h.assets.mu.Unlock()
+	h.PurgeFragments()
+	h.clearResponseCache()
+	h.resetErrorBudget()
+	h.purgeTree()
+}
+
+// TreeVersions returns the versions of the current and staged trees, as given to StageTree.
+// The version of the tree the handler was created with is empty, and staged is empty when none is staged.
+func (h *Handler[D]) TreeVersions() (current, staged string) {
+	h.tree.mu.Lock()
+	defer h.tree.mu.Unlock()
+
+	if h.tree.next != nil {
+		staged = h.tree.next.version
+	}
+	return h.tree.current.Load().version, staged
+}
+
+// TreeRolloutHandler is an admin endpoint for rolling out the staged tree, to be mounted behind authentication.
+// GET responds with the current and staged versions as JSON, and POST promotes the staged version,
+// responding 409 Conflict if none is staged.
+func (h *Handler[D]) TreeRolloutHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost:
+			if err := h.PromoteTree(); err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		current, staged := h.TreeVersions()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Current string `json:"current"`
+			Staged  string `json:"staged,omitempty"`
+		}{current, staged})
+	})
+}
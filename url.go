@@ -0,0 +1,100 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// reverseURL builds the url of the route at routePath, a path of directories, followed by a path
+// segment for each param, resolved like any request path, e.g. url "blog" .Post.ID for /blog/42 and
+// the directory blog/{(?P<id>[0-9]+)}. It fails if the url does not resolve to a route.
+// When rendering a page of a locale, the url is of that locale, e.g. /de/produkte for url "products".
+//
+//	<a href="{{url "blog" .Post.ID}}">
+//	<a href="{{url "/users" .User.Name "settings"}}">
+func (h requestHandler) reverseURL(routePath string, params ...any) (string, error) {
+	segments := splitPath(routePath)
+	for _, p := range params {
+		s := fmt.Sprint(p)
+		if s == "" || strings.Contains(s, "/") {
+			return "", fmt.Errorf("url %s: invalid path segment %q", routePath, s)
+		}
+		segments = append(segments, s)
+	}
+
+	if h.route != nil && h.route.Locale != "" {
+		localized, err := h.localize(h.route.Locale, segments)
+		if err != nil {
+			return "", fmt.Errorf("url %s: %w", routePath, err)
+		}
+		segments = localized
+	}
+
+	rawPath := "/" + strings.Join(segments, "/")
+
+	if _, err := h.resolveRoute(rawPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("url %s: no route for %s", routePath, rawPath)
+		}
+		return "", fmt.Errorf("url %s: %w", routePath, err)
+	}
+
+	escaped := make([]string, len(segments))
+	for i, s := range segments {
+		escaped[i] = url.PathEscape(s)
+	}
+
+	return h.withBasePath("/" + strings.Join(escaped, "/")), nil
+}
+
+// activeExact is the mode of isActive and activeClass matching only the link's own page.
+const activeExact = "exact"
+
+// isActive reports whether the route being rendered is at link, a root relative path, or under it,
+// for highlighting navigation, e.g. /settings is active for /settings/profile. With the "exact" mode
+// only the page itself is. The root, /, is only active for itself.
+// Links built with url, including the base path, may be given as is.
+//
+//	<a href="/settings" {{if isActive "/settings"}}aria-current="page"{{end}}>
+func (h requestHandler) isActive(link string, mode ...string) (bool, error) {
+	exact := false
+	for _, m := range mode {
+		if m != activeExact {
+			return false, fmt.Errorf("isActive %s: unknown mode %q", link, m)
+		}
+		exact = true
+	}
+
+	if h.route == nil {
+		return false, nil
+	}
+
+	if h.basePath != "" {
+		if rest, ok := strings.CutPrefix(link, h.basePath); ok && (rest == "" || rest[0] == '/') {
+			link = rest
+		}
+	}
+
+	linkSegments := splitPath(link)
+	routeSegments := splitPath(h.route.Path)
+
+	if exact || len(linkSegments) == 0 {
+		return slices.Equal(linkSegments, routeSegments), nil
+	}
+	return len(linkSegments) <= len(routeSegments) && slices.Equal(linkSegments, routeSegments[:len(linkSegments)]), nil
+}
+
+// activeClass returns class if isActive reports the link is active, and nothing otherwise.
+//
+//	<a href="/settings" class="{{activeClass "/settings" "active"}}">
+func (h requestHandler) activeClass(link, class string, mode ...string) (string, error) {
+	active, err := h.isActive(link, mode...)
+	if err != nil || !active {
+		return "", err
+	}
+	return class, nil
+}
@@ -0,0 +1,136 @@
+package htmplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+)
+
+// Validate parses every template, markdown file, directory config and regex directory name in the tree,
+// returning every error found, each naming its file, so broken templates are caught at deploy time
+// rather than as 500 responses.
+// Funcs set with WithFuncs are looked up with a GET request for /.
+func (h *Handler[D]) Validate() error {
+	return h.validateTree(h.tree.load())
+}
+
+func (h *Handler[D]) validateTree(fsys fs.FS) error {
+	l := h.log.With("validating", true)
+	l.Debug("validating templates")
+
+	rh := h.newRequestHandler(l)
+	rh.fs = fsys
+	rh.page = nil
+
+	funcs := template.FuncMap{}
+	for name, f := range builtinFuncs {
+		funcs[name] = f
+	}
+	if h.defaultFuncs {
+		for name, f := range DefaultFuncs() {
+			funcs[name] = f
+		}
+	}
+	if h.funcs != nil {
+		for name, f := range h.funcs(httptest.NewRequest(http.MethodGet, "/", nil)) {
+			funcs[name] = f
+		}
+	}
+
+	var errs []error
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if isRegexPathPart(d.Name()) {
+				if _, err := compileRegexPathPart(d.Name()); err != nil {
+					errs = append(errs, fmt.Errorf("invalid regex directory %s: %w", name, err))
+				}
+			}
+			return nil
+		}
+
+		_, isTemplate := rh.templateExt(name)
+
+		switch {
+		case isTemplate || strings.HasSuffix(name, textTemplateExt):
+			errs = append(errs, rh.validateTemplate(name, funcs))
+		case path.Base(name) == priorityFilename && isRegexPathPart(path.Base(path.Dir(name))):
+			dir := path.Dir(name)
+			_, err := rh.regexDirectory(path.Dir(dir), path.Base(dir))
+			errs = append(errs, err)
+		case isDirectoryConfigFile(name):
+			_, err := rh.readDirectoryConfig(path.Dir(name))
+			errs = append(errs, err)
+		case rh.markdown != nil && path.Ext(name) == markdownExt:
+			errs = append(errs, rh.validateMarkdown(name))
+		}
+
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid templates:\n%w", err)
+	}
+
+	h.warnAmbiguousDirectories(fsys)
+
+	return nil
+}
+
+// WithValidation validates the tree before Build and Export, failing them when it is invalid.
+func (h *Handler[D]) WithValidation(enabled bool) *Handler[D] {
+	h.validate = enabled
+	return h
+}
+
+func (h requestHandler) validateTemplate(name string, funcs template.FuncMap) error {
+	b, err := h.readFile(name)
+	if err != nil {
+		return err
+	}
+	// text templates have no front matter.
+	if _, ok := h.templateExt(name); ok {
+		if b, err = h.stripFrontMatter(name, b); err != nil {
+			return err
+		}
+	}
+
+	if _, err := template.New(name).Funcs(funcs).Parse(string(b)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (h requestHandler) validateMarkdown(name string) error {
+	b, err := h.readFile(name)
+	if err != nil {
+		return err
+	}
+	if b, err = h.stripFrontMatter(name, b); err != nil {
+		return err
+	}
+
+	if err := h.markdown.RenderMarkdown(io.Discard, b); err != nil {
+		return fmt.Errorf("invalid markdown %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package htmplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"runtime"
+)
+
+// WithWarmup renders routes when Build runs, before serving, so that the first requests after a deploy
+// find the file system, asset fingerprints, cached fragments and whatever the data callback caches warm.
+// Every route not under a regex directory is warmed if routes is nil.
+func (h *Handler[D]) WithWarmup(routes []string) *Handler[D] {
+	h.warmup = &warmup{routes: routes}
+	return h
+}
+
+type warmup struct {
+	routes []string
+}
+
+// Warmup renders the routes set by WithWarmup, discarding the pages.
+// Listed routes failing to render are an error, as are any routes failing for other reasons than not being found.
+func (h *Handler[D]) Warmup(ctx context.Context) error {
+	return h.warm(ctx, h.fs)
+}
+
+// warm renders the warmup routes of the tree, discovering them in fsys if none were given.
+func (h *Handler[D]) warm(ctx context.Context, fsys fs.FS) error {
+	if h.warmup == nil {
+		return nil
+	}
+
+	routes, explicit := h.warmup.routes, h.warmup.routes != nil
+	if !explicit {
+		var err error
+		if routes, err = h.exportableRoutes(fsys); err != nil {
+			return err
+		}
+	}
+
+	l := h.log.With("routes", len(routes))
+	l.Info("warming up")
+
+	jobs := make([]RenderJob[D], len(routes))
+	for i, route := range routes {
+		jobs[i] = RenderJob[D]{Route: route}
+	}
+
+	var errs []error
+	for _, res := range h.RenderAll(ctx, jobs, runtime.GOMAXPROCS(0)) {
+		if res.Err == nil || !explicit && (res.Status == http.StatusNotFound || res.Status == http.StatusGone) {
+			continue
+		}
+		errs = append(errs, res.Err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to warm up:\n%w", err)
+	}
+
+	l.Info("warmed up")
+
+	return nil
+}